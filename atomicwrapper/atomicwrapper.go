@@ -0,0 +1,423 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのための並行に使用できるラッパーの実装例
+// immutabletreeのような変更の度に新しい木を返す不変ぽい木を前提とし、現在の木をatomic.Valueで保持する
+//
+// Find,Range,Count,Iterateなどの読み取りの操作はロックを一切取らず、その時点の木(スナップショット)に対して実行される
+// Snapshotで得た木は以降の変更の影響を受けないので、長い走査の間も一貫した内容を読み続けることができる
+// 読み取りの操作で得たノードも変更されることはないのでそのまま使用してよい
+//
+// Insert,Deleteなどの変更の操作はミューテックスで直列化したうえで、
+// 現在の木から新しい木を作りCompareAndSwapで差し替える
+// Swapはミューテックスを取らずにCompareAndSwapが成功するまで再試行する(楽観的な更新)
+// 再試行の際には変更の処理(Update等のコールバックも含む)がやり直されるため、コールバックに副作用を持たせてはならない
+//
+// 木のノードを直接書き換える木(simpletreeなど)を使用してはならない
+//
+// 複数の変更をまとめて反映する場合はBeginかBeginOptimisticでトランザクション(Txn)を使用する
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"sync"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/atomicwrapper"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//		)
+//		func Example_atomicwrapper() {
+//			w := atomicwrapper.New(false)
+//			w.Insert(IntKey(1), 100)
+//			w.Insert(IntKey(2), 200)
+//			snapshot := w.Snapshot()
+//			var wg sync.WaitGroup
+//			for i := 0; i < 4; i++ {
+//				wg.Add(1)
+//				go func() {
+//					defer wg.Done()
+//					for k := 0; k < 10; k++ {
+//						w.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+//							modified, _ = avltree.Update(current, IntKey(1), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+//								newValue = oldValue.(int) + 1
+//								return
+//							})
+//							return
+//						})
+//					}
+//				}()
+//			}
+//			wg.Wait()
+//			w.Delete(IntKey(2))
+//			avltree.Iterate(snapshot, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Snapshot!", node.Key(), node.Value())
+//				return
+//			})
+//			w.Iterate(func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Current!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Snapshot! 1 100
+//			// Snapshot! 2 200
+//			// Current! 1 140
+//		}
+//
+package atomicwrapper
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+)
+
+type AVLTree struct {
+	current atomic.Value
+	mutex   sync.Mutex
+}
+
+// atomic.Valueには常に同じ型の値を保持する必要があるので木をこの型に包む
+type version struct {
+	tree avltree.Tree
+}
+
+// immutabletreeを用いたAVLTreeを生成する
+func New(allowDuplicateKeys bool) *AVLTree {
+	return Wrap(immutabletree.New(allowDuplicateKeys))
+}
+
+// 任意の不変ぽい木を用いたAVLTreeを生成する
+func Wrap(tree avltree.Tree) *AVLTree {
+	w := &AVLTree{}
+	w.current.Store(&version{tree})
+	return w
+}
+
+func (tree *AVLTree) load() *version {
+	return tree.current.Load().(*version)
+}
+
+// 現在の木を返す
+func (tree *AVLTree) Snapshot() avltree.Tree {
+	return tree.load().tree
+}
+
+// 現在の木をmodifyに渡し、その戻り値の木で差し替える
+// 他の変更と競合した場合は新しい現在の木でmodifyを呼び出し直す
+// modifyが受け取った木をそのまま返した場合は何もしない
+// 差し替える前の木と差し替えた後の木を返す
+func (tree *AVLTree) Swap(modify func(current avltree.Tree) (modified avltree.Tree)) (oldTree, newTree avltree.Tree) {
+	for {
+		current := tree.load()
+		modified := modify(current.tree)
+		if modified == current.tree {
+			return current.tree, current.tree
+		}
+		if tree.current.CompareAndSwap(current, &version{modified}) {
+			return current.tree, modified
+		}
+	}
+}
+
+// 変更の操作同士はミューテックスで直列化し無駄な再試行を避ける
+func (tree *AVLTree) modify(modify func(current avltree.Tree) (modified avltree.Tree)) {
+	tree.mutex.Lock()
+	defer tree.mutex.Unlock()
+	tree.Swap(modify)
+}
+
+func (tree *AVLTree) Insert(key avltree.Key, value interface{}) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(current, false, key, value)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) InsertOrReplace(key avltree.Key, value interface{}) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(current, true, key, value)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) Delete(key avltree.Key) (deletedValue avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue = avltree.Delete(current, key)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) Update(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Update(current, key, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) Replace(key avltree.Key, value interface{}) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Replace(current, key, value)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) Alter(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValue avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue, ok = avltree.Alter(current, key, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) Clear() {
+	tree.modify(func(current avltree.Tree) avltree.Tree {
+		return avltree.Clear(current)
+	})
+}
+
+func (tree *AVLTree) Find(key avltree.Key) (node avltree.Node) {
+	return avltree.Find(tree.Snapshot(), key)
+}
+
+func (tree *AVLTree) Iterate(callBack avltree.IterateCallBack) {
+	avltree.Iterate(tree.Snapshot(), false, callBack)
+}
+
+func (tree *AVLTree) IterateRev(callBack avltree.IterateCallBack) {
+	avltree.Iterate(tree.Snapshot(), true, callBack)
+}
+
+func (tree *AVLTree) Range(lower, upper avltree.Key) (nodes []avltree.Node) {
+	return avltree.Range(tree.Snapshot(), false, lower, upper)
+}
+
+func (tree *AVLTree) RangeRev(lower, upper avltree.Key) (nodes []avltree.Node) {
+	return avltree.Range(tree.Snapshot(), true, lower, upper)
+}
+
+func (tree *AVLTree) RangeIterate(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	avltree.RangeIterate(tree.Snapshot(), false, lower, upper, callBack)
+}
+
+func (tree *AVLTree) RangeIterateRev(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	avltree.RangeIterate(tree.Snapshot(), true, lower, upper, callBack)
+}
+
+func (tree *AVLTree) Count() int {
+	return avltree.Count(tree.Snapshot())
+}
+
+func (tree *AVLTree) CountRange(lower, upper avltree.Key) int {
+	return avltree.CountRange(tree.Snapshot(), lower, upper)
+}
+
+func (tree *AVLTree) Min() (node avltree.Node) {
+	return avltree.Min(tree.Snapshot())
+}
+
+func (tree *AVLTree) Max() (node avltree.Node) {
+	return avltree.Max(tree.Snapshot())
+}
+
+func (tree *AVLTree) DeleteAll(key avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteAll(current, key)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateAll(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateAll(current, key, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) ReplaceAll(key avltree.Key, value interface{}) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.ReplaceAll(current, key, value)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterAll(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterAll(current, key, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) FindAll(key avltree.Key) (nodes []avltree.Node) {
+	return avltree.FindAll(tree.Snapshot(), key)
+}
+
+func (tree *AVLTree) MinAll() (nodes []avltree.Node) {
+	return avltree.MinAll(tree.Snapshot())
+}
+
+func (tree *AVLTree) MaxAll() (nodes []avltree.Node) {
+	return avltree.MaxAll(tree.Snapshot())
+}
+
+func (tree *AVLTree) DeleteIterate(callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteIterate(current, false, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) DeleteIterateRev(callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteIterate(current, true, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) DeleteRange(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteRange(current, false, lower, upper)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) DeleteRangeRev(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteRange(current, true, lower, upper)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) DeleteRangeIterate(lower, upper avltree.Key, callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteRangeIterate(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) DeleteRangeIterateRev(lower, upper avltree.Key, callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteRangeIterate(current, true, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateIterate(callBack avltree.UpdateIterateCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateIterate(current, false, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateIterateRev(callBack avltree.UpdateIterateCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateIterate(current, true, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateRange(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateRange(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateRangeRev(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateRange(current, true, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateRangeIterate(lower, upper avltree.Key, callBack avltree.UpdateIterateCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateRangeIterate(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) UpdateRangeIterateRev(lower, upper avltree.Key, callBack avltree.UpdateIterateCallBack) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateRangeIterate(current, true, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) ReplaceRange(lower, upper avltree.Key, value interface{}) (ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.ReplaceRange(current, lower, upper, value)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterIterate(callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterIterate(current, false, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterIterateRev(callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterIterate(current, true, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterRange(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterRange(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterRangeRev(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterRange(current, true, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterRangeIterate(lower, upper avltree.Key, callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterRangeIterate(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (tree *AVLTree) AlterRangeIterateRev(lower, upper avltree.Key, callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	tree.modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterRangeIterate(current, true, lower, upper, callBack)
+		return
+	})
+	return
+}
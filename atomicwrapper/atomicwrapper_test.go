@@ -0,0 +1,196 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package atomicwrapper
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/simplewrapper"
+)
+
+// go test -race で実行することを想定したテストを含む
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+func getAllAscKeyAndValues(iterate func(callBack avltree.IterateCallBack)) (result []int) {
+	iterate(func(node avltree.Node) (breakIteration bool) {
+		result = append(result, int(node.Key().(IntKey)), node.Value().(int))
+		return
+	})
+	return
+}
+
+func TestSameAsSimpleWrapper(t *testing.T) {
+	f := func(list []keyAndValue, k1, k2 int) []int {
+		w := New(false)
+		for i, kv := range list {
+			switch i % 4 {
+			case 3:
+				w.Delete(IntKey(kv.Key))
+			default:
+				w.Insert(IntKey(kv.Key), kv.Value)
+			}
+		}
+		w.UpdateRange(IntKey(k1), IntKey(k2), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+			newValue = oldValue.(int) / 2
+			return
+		})
+		result := getAllAscKeyAndValues(func(callBack avltree.IterateCallBack) {
+			for _, node := range w.Range(IntKey(k1), IntKey(k2)) {
+				callBack(node)
+			}
+		})
+		result = append(result, w.CountRange(IntKey(k1), IntKey(k2)))
+		return append(result, getAllAscKeyAndValues(w.Iterate)...)
+	}
+
+	g := func(list []keyAndValue, k1, k2 int) []int {
+		w := simplewrapper.New(simpletree.New(false))
+		for i, kv := range list {
+			switch i % 4 {
+			case 3:
+				w.Delete(IntKey(kv.Key))
+			default:
+				w.Insert(IntKey(kv.Key), kv.Value)
+			}
+		}
+		w.UpdateRange(IntKey(k1), IntKey(k2), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+			newValue = oldValue.(int) / 2
+			return
+		})
+		result := getAllAscKeyAndValues(func(callBack avltree.IterateCallBack) {
+			for _, node := range w.Range(IntKey(k1), IntKey(k2)) {
+				callBack(node)
+			}
+		})
+		result = append(result, w.CountRange(IntKey(k1), IntKey(k2)))
+		return append(result, getAllAscKeyAndValues(w.Iterate)...)
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotIsConsistent(t *testing.T) {
+	const size = 1000
+	w := New(false)
+	for k := 0; k < size; k++ {
+		w.Insert(IntKey(k), 0)
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// 全てのキーの値を揃えて更新し続ける
+		for round := 1; ; round++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			w.ReplaceRange(nil, nil, round)
+			w.Delete(IntKey(size))
+			w.Insert(IntKey(size), round)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		snapshot := w.Snapshot()
+		count := avltree.Count(snapshot)
+		value := avltree.Min(snapshot).Value()
+		n := 0
+		avltree.Iterate(snapshot, false, func(node avltree.Node) (breakIteration bool) {
+			n++
+			if int(node.Key().(IntKey)) < size && node.Value() != value {
+				t.Error("snapshot is not consistent")
+				return true
+			}
+			return
+		})
+		if n != count {
+			t.Error("snapshot count changed")
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestConcurrentSwap(t *testing.T) {
+	const workers = 8
+	const times = 200
+	w := New(false)
+	w.Insert(IntKey(0), 0)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < times; k++ {
+				w.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+					modified, _ = avltree.Update(current, IntKey(0), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+						newValue = oldValue.(int) + 1
+						return
+					})
+					return
+				})
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for k := 0; k < times; k++ {
+				w.Update(IntKey(0), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+					newValue = oldValue.(int) + 1
+					return
+				})
+				w.Insert(IntKey(1+i*times+k), k)
+				w.Find(IntKey(k))
+			}
+		}(i)
+	}
+	wg.Wait()
+	// 競合しても更新が失われない
+	if node := w.Find(IntKey(0)); node == nil || node.Value() != 2*workers*times {
+		t.Fatalf("want %d but %v", 2*workers*times, node.Value())
+	}
+	if w.Count() != 1+workers*times {
+		t.Fatalf("want %d but %d", 1+workers*times, w.Count())
+	}
+}
+
+func TestSwapWithoutChange(t *testing.T) {
+	w := New(false)
+	w.Insert(IntKey(1), 1)
+	before := w.Snapshot()
+	oldTree, newTree := w.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, _ = avltree.Delete(current, IntKey(2))
+		return
+	})
+	if oldTree != before || newTree != before || w.Snapshot() != before {
+		t.Fatal("tree is replaced without change")
+	}
+	oldTree, newTree = w.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, _ = avltree.Delete(current, IntKey(1))
+		return
+	})
+	if oldTree != before || newTree != w.Snapshot() || w.Count() != 0 {
+		t.Fatal("tree is not replaced")
+	}
+	if !reflect.DeepEqual(getAllAscKeyAndValues(func(callBack avltree.IterateCallBack) {
+		avltree.Iterate(before, false, callBack)
+	}), []int{1, 1}) {
+		t.Fatal("old tree is modified")
+	}
+}
@@ -0,0 +1,282 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package atomicwrapper
+
+import (
+	"reflect"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 複数の変更をまとめて反映するトランザクション
+//
+// Beginした時点の木を元に変更を積み上げていき、トランザクション内の読み取りは積み上げた変更を反映した木に対して行われる
+// Commitで積み上げた変更を反映した木を公開し、Rollbackで全ての変更を破棄する
+// Commitするまで他のゴルーチンからは変更は一切見えない
+//
+// Beginで開始した場合はCommitの時点の最新の木に対して変更の操作を同じ順に適用し直す(コールバックも再び呼び出される)
+// 適用し直した操作の結果(okや削除された値)がトランザクション内で返した結果と異なる場合はCommitは失敗する
+// (削除された値はキーと値をreflect.DeepEqualで比較する)
+// BeginOptimisticで開始した場合は開始から後に他の変更が反映されているとCommitは失敗する(楽観的な競合検出)
+//
+// トランザクション自体は並行に使用することはできない
+// CommitかRollbackをしたあとのトランザクションを使用するとpanicになる
+type Txn struct {
+	owner      *AVLTree
+	base       *version
+	tree       avltree.Tree
+	optimistic bool
+	operations []stagedOperation
+	finished   bool
+}
+
+// 変更の操作は変更後の木と呼び出し元に返した結果を返す
+type operation = func(current avltree.Tree) (modified avltree.Tree, result interface{})
+
+// Commit時に適用し直すための操作とトランザクション内で返した結果
+type stagedOperation struct {
+	operation operation
+	result    interface{}
+}
+
+// 削除された値の結果としての比較用(ノードそのものは木ごとに異なるためキーと値だけを比較する)
+type deletedValue struct {
+	key   avltree.Key
+	value interface{}
+}
+
+func deleted(kv avltree.KeyAndValue) interface{} {
+	if kv == nil {
+		return nil
+	}
+	return deletedValue{kv.Key(), kv.Value()}
+}
+
+func deletedAll(kvs []avltree.KeyAndValue) interface{} {
+	result := make([]interface{}, len(kvs))
+	for i, kv := range kvs {
+		result[i] = deleted(kv)
+	}
+	return result
+}
+
+// Commit時に変更を適用し直すトランザクションを開始する
+func (tree *AVLTree) Begin() *Txn {
+	return tree.begin(false)
+}
+
+// Commit時に競合を検出するトランザクションを開始する
+func (tree *AVLTree) BeginOptimistic() *Txn {
+	return tree.begin(true)
+}
+
+func (tree *AVLTree) begin(optimistic bool) *Txn {
+	base := tree.load()
+	return &Txn{
+		owner:      tree,
+		base:       base,
+		tree:       base.tree,
+		optimistic: optimistic,
+	}
+}
+
+func (txn *Txn) checkFinished() {
+	if txn.finished {
+		panic("finished transaction")
+	}
+}
+
+func (txn *Txn) apply(operation operation) {
+	txn.checkFinished()
+	var result interface{}
+	txn.tree, result = operation(txn.tree)
+	if !txn.optimistic {
+		txn.operations = append(txn.operations, stagedOperation{operation, result})
+	}
+}
+
+// トランザクション内の変更を反映した木を返す
+func (txn *Txn) Tree() avltree.Tree {
+	txn.checkFinished()
+	return txn.tree
+}
+
+// 変更を公開する
+// BeginOptimisticで開始したトランザクションで競合を検出した場合は何も反映せずfalseを返す
+// Beginで開始したトランザクションで適用し直した操作の結果が異なる場合も何も反映せずfalseを返す
+// (失敗した場合もトランザクションは終了する)
+// 変更が無い場合は競合の有無に関わらずtrueを返す
+func (txn *Txn) Commit() (ok bool) {
+	txn.checkFinished()
+	txn.finished = true
+	if txn.tree == txn.base.tree {
+		return true
+	}
+	owner := txn.owner
+	owner.mutex.Lock()
+	defer owner.mutex.Unlock()
+	if txn.optimistic {
+		return owner.current.CompareAndSwap(txn.base, &version{txn.tree})
+	}
+	owner.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+		if current == txn.base.tree {
+			ok = true
+			return txn.tree
+		}
+		modified = current
+		for _, staged := range txn.operations {
+			var result interface{}
+			modified, result = staged.operation(modified)
+			if !reflect.DeepEqual(result, staged.result) {
+				ok = false
+				return current
+			}
+		}
+		ok = true
+		return
+	})
+	return
+}
+
+// 変更を全て破棄する
+func (txn *Txn) Rollback() {
+	txn.checkFinished()
+	txn.finished = true
+	txn.tree = nil
+	txn.operations = nil
+}
+
+func (txn *Txn) Insert(key avltree.Key, value interface{}) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.Insert(current, false, key, value)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) InsertOrReplace(key avltree.Key, value interface{}) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.Insert(current, true, key, value)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) Delete(key avltree.Key) (deletedValue avltree.KeyAndValue) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, deletedValue = avltree.Delete(current, key)
+		return modified, deleted(deletedValue)
+	})
+	return
+}
+
+func (txn *Txn) Update(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.Update(current, key, callBack)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) Replace(key avltree.Key, value interface{}) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.Replace(current, key, value)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) Alter(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValue avltree.KeyAndValue, ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, deletedValue, ok = avltree.Alter(current, key, callBack)
+		return modified, []interface{}{deleted(deletedValue), ok}
+	})
+	return
+}
+
+func (txn *Txn) Clear() {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		return avltree.Clear(current), nil
+	})
+}
+
+func (txn *Txn) DeleteAll(key avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, deletedValues = avltree.DeleteAll(current, key)
+		return modified, deletedAll(deletedValues)
+	})
+	return
+}
+
+func (txn *Txn) ReplaceAll(key avltree.Key, value interface{}) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.ReplaceAll(current, key, value)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) DeleteRange(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, deletedValues = avltree.DeleteRange(current, false, lower, upper)
+		return modified, deletedAll(deletedValues)
+	})
+	return
+}
+
+func (txn *Txn) UpdateRange(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.UpdateRange(current, false, lower, upper, callBack)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) ReplaceRange(lower, upper avltree.Key, value interface{}) (ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, ok = avltree.ReplaceRange(current, lower, upper, value)
+		return modified, ok
+	})
+	return
+}
+
+func (txn *Txn) AlterRange(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	txn.apply(func(current avltree.Tree) (modified avltree.Tree, result interface{}) {
+		modified, deletedValues, ok = avltree.AlterRange(current, false, lower, upper, callBack)
+		return modified, []interface{}{deletedAll(deletedValues), ok}
+	})
+	return
+}
+
+func (txn *Txn) Find(key avltree.Key) (node avltree.Node) {
+	return avltree.Find(txn.Tree(), key)
+}
+
+func (txn *Txn) Iterate(callBack avltree.IterateCallBack) {
+	avltree.Iterate(txn.Tree(), false, callBack)
+}
+
+func (txn *Txn) Range(lower, upper avltree.Key) (nodes []avltree.Node) {
+	return avltree.Range(txn.Tree(), false, lower, upper)
+}
+
+func (txn *Txn) RangeIterate(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	avltree.RangeIterate(txn.Tree(), false, lower, upper, callBack)
+}
+
+func (txn *Txn) Count() int {
+	return avltree.Count(txn.Tree())
+}
+
+func (txn *Txn) CountRange(lower, upper avltree.Key) int {
+	return avltree.CountRange(txn.Tree(), lower, upper)
+}
+
+func (txn *Txn) Min() (node avltree.Node) {
+	return avltree.Min(txn.Tree())
+}
+
+func (txn *Txn) Max() (node avltree.Node) {
+	return avltree.Max(txn.Tree())
+}
@@ -0,0 +1,226 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package atomicwrapper
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func TestTxnReadsStagedWrites(t *testing.T) {
+	f := func(list []keyAndValue, k1, k2 int) bool {
+		w := New(false)
+		for _, kv := range list {
+			w.Insert(IntKey(kv.Key), kv.Value)
+		}
+		before := getAllAscKeyAndValues(w.Iterate)
+		txn := w.Begin()
+		txn.InsertOrReplace(IntKey(k1), k2)
+		txn.Delete(IntKey(k2))
+		model := New(false)
+		for _, kv := range list {
+			model.Insert(IntKey(kv.Key), kv.Value)
+		}
+		model.InsertOrReplace(IntKey(k1), k2)
+		model.Delete(IntKey(k2))
+		if !reflect.DeepEqual(getAllAscKeyAndValues(txn.Iterate), getAllAscKeyAndValues(model.Iterate)) {
+			return false
+		}
+		if txn.Count() != model.Count() {
+			return false
+		}
+		// Commitするまで外からは見えない
+		if !reflect.DeepEqual(getAllAscKeyAndValues(w.Iterate), before) {
+			return false
+		}
+		if !txn.Commit() {
+			return false
+		}
+		return reflect.DeepEqual(getAllAscKeyAndValues(w.Iterate), getAllAscKeyAndValues(model.Iterate))
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	w := New(false)
+	w.Insert(IntKey(1), 10)
+	w.Insert(IntKey(2), 20)
+	txn := w.Begin()
+	txn.Delete(IntKey(1))
+	txn.Replace(IntKey(2), 200)
+	txn.Insert(IntKey(3), 30)
+	txn.Rollback()
+	if got := getAllAscKeyAndValues(w.Iterate); !reflect.DeepEqual(got, []int{1, 10, 2, 20}) {
+		t.Fatal(got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on finished transaction")
+		}
+	}()
+	txn.Insert(IntKey(4), 40)
+}
+
+func increment(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+	newValue = oldValue.(int) + 1
+	return
+}
+
+func TestTxnConflict(t *testing.T) {
+	w := New(false)
+	w.Insert(IntKey(1), 0)
+
+	txn1 := w.BeginOptimistic()
+	txn2 := w.BeginOptimistic()
+	txn1.Update(IntKey(1), increment)
+	txn2.Update(IntKey(1), increment)
+	if !txn1.Commit() {
+		t.Fatal("first commit must succeed")
+	}
+	if txn2.Commit() {
+		t.Fatal("conflict is not detected")
+	}
+	if node := w.Find(IntKey(1)); node.Value() != 1 {
+		t.Fatalf("want 1 but %v", node.Value())
+	}
+
+	// 読み取りのみのトランザクションは競合しない
+	txn3 := w.BeginOptimistic()
+	txn3.Find(IntKey(1))
+	w.Update(IntKey(1), increment)
+	if !txn3.Commit() {
+		t.Fatal("read only transaction must succeed")
+	}
+
+	// Beginで開始した場合は最新の木に適用し直される
+	txn4 := w.Begin()
+	txn5 := w.Begin()
+	txn4.Update(IntKey(1), increment)
+	txn5.Update(IntKey(1), increment)
+	txn5.Insert(IntKey(2), 0)
+	if !txn4.Commit() || !txn5.Commit() {
+		t.Fatal("commit must succeed")
+	}
+	if got := getAllAscKeyAndValues(w.Iterate); !reflect.DeepEqual(got, []int{1, 4, 2, 0}) {
+		t.Fatal(got)
+	}
+}
+
+// Beginで開始した場合も適用し直した操作の結果が異なればCommitは失敗する
+func TestTxnReplayMismatch(t *testing.T) {
+	w := New(false)
+	w.Insert(IntKey(1), 10)
+
+	txn1 := w.Begin()
+	if !txn1.Insert(IntKey(2), 20) {
+		t.Fatal("insert must succeed in transaction")
+	}
+	w.Insert(IntKey(2), 200)
+	if txn1.Commit() {
+		t.Fatal("insert of existing key is committed")
+	}
+
+	txn2 := w.Begin()
+	if deleted := txn2.Delete(IntKey(1)); deleted == nil || deleted.Value() != 10 {
+		t.Fatal("wrong deleted value", deleted)
+	}
+	w.Replace(IntKey(1), 100)
+	if txn2.Commit() {
+		t.Fatal("delete of replaced value is committed")
+	}
+	if got := getAllAscKeyAndValues(w.Iterate); !reflect.DeepEqual(got, []int{1, 100, 2, 200}) {
+		t.Fatal(got)
+	}
+
+	// 結果が同じであれば他の変更があっても適用し直される
+	txn3 := w.Begin()
+	txn3.Delete(IntKey(2))
+	txn3.Insert(IntKey(3), 30)
+	w.Replace(IntKey(1), 1000)
+	if !txn3.Commit() {
+		t.Fatal("commit must succeed")
+	}
+	if got := getAllAscKeyAndValues(w.Iterate); !reflect.DeepEqual(got, []int{1, 1000, 3, 30}) {
+		t.Fatal(got)
+	}
+}
+
+// 口座間の送金を並行にトランザクションで行っても合計は変わらず、途中の状態も外からは見えない
+func TestTxnConcurrentTransfer(t *testing.T) {
+	const accounts = 8
+	const workers = 8
+	const times = 100
+	for _, optimistic := range []bool{false, true} {
+		w := New(false)
+		for k := 0; k < accounts; k++ {
+			w.Insert(IntKey(k), 1000)
+		}
+		total := func(tree avltree.Tree) (sum int) {
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				sum += node.Value().(int)
+				return
+			})
+			return
+		}
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		errs := make(chan int, 1)
+		go func() {
+			for {
+				select {
+				case <-stop:
+					close(errs)
+					return
+				default:
+				}
+				if sum := total(w.Snapshot()); sum != accounts*1000 {
+					errs <- sum
+					close(errs)
+					return
+				}
+			}
+		}()
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for k := 0; k < times; k++ {
+					from, to := IntKey((i+k)%accounts), IntKey((i+k*3+1)%accounts)
+					for {
+						var txn *Txn
+						if optimistic {
+							txn = w.BeginOptimistic()
+						} else {
+							txn = w.Begin()
+						}
+						txn.Update(from, func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+							newValue = oldValue.(int) - 1
+							return
+						})
+						txn.Update(to, increment)
+						if txn.Commit() {
+							break
+						}
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(stop)
+		for sum := range errs {
+			t.Fatal("inconsistent total", sum, optimistic)
+		}
+		if sum := total(w.Snapshot()); sum != accounts*1000 {
+			t.Fatal("inconsistent total", sum, optimistic)
+		}
+	}
+}
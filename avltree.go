@@ -20,15 +20,43 @@
 //  github.com/neetsdkasu/avltree/simpletree        最低限の実装のみ
 //  github.com/neetsdkasu/avltree/standardtree      ノード数の保持や親ノード参照などの機能がある
 //  github.com/neetsdkasu/avltree/immutabletree     木の構造の部分だけは不変性になるように実装されている(キーと値の不変性は取り扱わない)
+//  github.com/neetsdkasu/avltree/refcounttree      immutabletreeと同様の木でバージョンを解放すると参照されなくなったノードが再利用されるように実装
 //  github.com/neetsdkasu/avltree/intarraytree      int型の配列上に木が構築されるように実装(キーはintkeyの実装のみ、値もint型のみ)
+//  github.com/neetsdkasu/avltree/int64arraytree    int64型の配列上に木が構築されるように実装(キーはint64keyかuint64keyの実装のみ、値はint64型かfloat64型かuint64型のみ)
+//  github.com/neetsdkasu/avltree/bytesarraytree    int型の配列上に木が構築され、キーと値のバイト列は別の配列に保持されるように実装(キーはbyteskeyの実装のみ、値は[]byte型のみ)
+//  github.com/neetsdkasu/avltree/merkletree        immutabletreeと同様の木で各ノードに部分木の内容のハッシュ値を保持し、複製どうしの検証や差分の範囲の検出ができるように実装
 //
 // Keyの実装例を以下のサブパッケージに置いてある
 //  github.com/neetsdkasu/avltree/intkey            int型をKeyとして使えるよう実装
-//  github.com/neetsdkasu/avltree/stringkey         string型をKeyとして使えるよう実装
+//  github.com/neetsdkasu/avltree/stringkey         string型をKeyとして使えるよう実装(大文字と小文字を同一視する順序や数字の並びを数値として比べる順序もあり、前方一致の範囲指定ができる)
+//  github.com/neetsdkasu/avltree/int64key          int64型をKeyとして使えるよう実装
+//  github.com/neetsdkasu/avltree/uint64key         uint64型をKeyとして使えるよう実装
+//  github.com/neetsdkasu/avltree/float64key        float64型をKeyとして使えるよう実装(-0と+0を区別しNaNを最大とする全順序)
+//  github.com/neetsdkasu/avltree/timekey           time.Time型をKeyとして使えるよう実装(モノトニック時計の読みは比較に使わない)
+//  github.com/neetsdkasu/avltree/byteskey          []byte型をKeyとして使えるよう実装(前方一致でノードを巡ったり数えたり削除したりする関数がある)
+//  github.com/neetsdkasu/avltree/tuplekey          複数のKeyを要素とする組をKeyとして使えるよう実装(要素ごとに昇順か降順かを指定でき、先頭の要素での範囲指定ができる)
+//  github.com/neetsdkasu/avltree/mortonkey         2次元・3次元の整数座標をZ順序曲線(Morton順序)の位置にしてKeyとして使えるよう実装(矩形範囲の検索と最近傍の検索ができる)
+//  github.com/neetsdkasu/avltree/ipkey             net/netipのIPアドレスとCIDRの範囲をKeyとして使えるよう実装(最長一致の検索ができる、Go 1.18以降)
+//  github.com/neetsdkasu/avltree/semverkey         セマンティックバージョニングのバージョンをKeyとして使えるよう実装(バージョンの条件を満たす範囲の検索ができる)
+//  github.com/neetsdkasu/avltree/structkey         構造体のフィールドのタグから組のKeyを作れるよう実装(構造体のスライスからの木の構築と先頭のフィールドの値での範囲指定ができる)
 //
 // 木の実装を内包し本パッケージの関数をメソッド経由で呼び出す、所謂"ラッパー"の実装例を以下のサブパッケージにおいてある
 //  github.com/neetsdkasu/avltree/simplewrapper     簡易に実装したラッパー
 //  github.com/neetsdkasu/avltree/intwrapper        キーも値もint型に強制するラッパー
+//  github.com/neetsdkasu/avltree/int64wrapper      キーも値もint64型に強制するラッパー
+//  github.com/neetsdkasu/avltree/uint64wrapper     キーも値もuint64型に強制するラッパー
+//  github.com/neetsdkasu/avltree/int64float64wrapper キーをint64型に値をfloat64型に強制するラッパー
+//  github.com/neetsdkasu/avltree/syncwrapper       任意の木を読み書きロックで保護し並行に使用できるようにするラッパー
+//  github.com/neetsdkasu/avltree/comparatortree    任意の木に比較関数を持たせ、Keyを実装していない値をキーにできるようにするラッパー
+//  github.com/neetsdkasu/avltree/reversetree       任意の木を複製せずにキーの逆順の木として見せるラッパーと、キーを逆順で比較させるKey
+//  github.com/neetsdkasu/avltree/atomicwrapper     不変ぽい木の現在の木をアトミックに差し替え、読み取りをロック無しで行えるようにするラッパー
+//  github.com/neetsdkasu/avltree/shardedmap        キーの範囲ごとに木とロックを持つシャード分割された順序付きマップ
+//  github.com/neetsdkasu/avltree/parallel          木を部分木に分割し複数のゴルーチンで並行に巡ったり集約したりする関数群
+//  github.com/neetsdkasu/avltree/observabletree    任意の木をラップし木の変更(追加・置き換え・削除)をイベントとして通知するラッパー
+//  github.com/neetsdkasu/avltree/codec             木のキーと値をバイト列に変換するためのインターフェースと実装例
+//  github.com/neetsdkasu/avltree/wal               木の変更を先行書き込みログに記録しチェックポイントとログから木を復元する
+//  github.com/neetsdkasu/avltree/history           immutabletreeのバージョンを用いた取り消しとやり直しができる順序付きマップ
+//  github.com/neetsdkasu/avltree/sync              merkletreeの木どうしでキーの範囲のハッシュ値を比較し、異なる組だけを通信で送り合って内容を揃える
 //
 //
 // コード例
@@ -173,6 +201,41 @@ type TreeCleaner interface {
 	CleanUpTree()
 }
 
+// 木がこのインターフェースを実装している場合にInsertやDelete,Clearなど木を変更する操作の内部で変更内容を通知するメソッドが呼び出される
+// Range系やIterate系の操作では変更されたノードごとに変更した順に呼び出される
+// 通知は変更操作の途中(SetRootメソッドやReleaseNodeメソッドの呼び出し前)に行われるため、通知を受けたメソッドの中で木を参照したり変更したりしてはならない
+// Clearでは全てのノードの削除が通知されるが、Releaseでは通知されない(不変の木の古いバージョンのノードは新しいバージョンと共有されているため)
+// サブパッケージのobservabletreeでは任意の木をラップして変更をイベントとして通知する
+type MutationObserver interface {
+	Tree
+
+	// ノードが追加されたときに呼び出される
+	NodeInserted(key Key, value interface{})
+
+	// ノードの値がoldValueからnewValueに置き換えられたときに呼び出される
+	NodeReplaced(key Key, oldValue, newValue interface{})
+
+	// ノードが削除されたときに呼び出される
+	NodeDeleted(key Key, value interface{})
+}
+
+// 木がこのインターフェースを実装している場合にInsertの内部でKeyのCopyメソッドではなくCopyKeyメソッドで新しいノードのキーを複製する
+// NewNodeメソッドの中でキーの内容を木の側の領域に複製する場合に、二重に複製しないように木側で実装する(その場合CopyKeyメソッドはキーをそのまま返せばよい)
+// サブパッケージのbytesarraytreeではNewNodeでキーのバイト列をアリーナに複製するため、CopyKeyではキーを複製しない
+type KeyCopier interface {
+	RealTree
+	CopyKey(key Key) Key
+}
+
+// 木がこのインターフェースを実装している場合に本パッケージの関数はKeyのCompareToメソッドではなくCompareKeysメソッドでキーを比較する
+// キーの型ごとにCompareToメソッドを実装する代わりに木の側で比較方法を持たせたい場合に木側で実装する
+// CompareKeysメソッドはkey1.CompareTo(key2)と同じ意味の比較結果(key1がkey2より小さい場合はLessThanOtherKeyなど)を返す必要がある
+// サブパッケージのcomparatortreeでは比較関数を持つ木を実装している
+type KeyComparator interface {
+	Tree
+	CompareKeys(key1, key2 Key) KeyOrdering
+}
+
 // 木の公開用の基本的なインターフェース
 // デフォルトでアクセスできる範囲を制限するためだけの用途
 type Tree interface {
@@ -298,7 +361,9 @@ func Insert(tree Tree, replaceIfExists bool, key Key, value interface{}) (modifi
 		&realTree,
 		replaceIfExists,
 		&key,
+		compareBy(tree, key),
 		&value,
+		getObserver(tree),
 	}
 	if newRoot, ok := helper.insertTo(tree.Root()); ok {
 		return realTree.SetRoot(newRoot), true
@@ -313,11 +378,14 @@ func Insert(tree Tree, replaceIfExists bool, key Key, value interface{}) (modifi
 // 戻り値のmodifiedは木に変更があった場合はRealTreeのSetRootメソッドの戻り値となり、変更がない場合は引数のtreeがそのまま返却される
 // 戻り値のdeletedValueは削除したノードのキーと値を持っている
 func Delete(tree Tree, key Key) (modified Tree, deleteValue KeyAndValue) {
-	if newRoot, node, ok := removeNode(tree.Root(), key); ok {
+	if newRoot, node, ok := removeNode(tree.Root(), compareBy(tree, key)); ok {
 		deleteValue = &keyAndValue{
 			node.Key(),
 			node.Value(),
 		}
+		if observer := getObserver(tree); observer != nil {
+			observer.NodeDeleted(deleteValue.Key(), deleteValue.Value())
+		}
 		if releaser, ok := tree.(NodeReleaser); ok {
 			releaser.ReleaseNode(node.(RealNode))
 		}
@@ -340,7 +408,8 @@ func Delete(tree Tree, key Key) (modified Tree, deleteValue KeyAndValue) {
 // 変更があった場合とは、指定のキーを持つノードが存在し、かつコールバックの戻り値keepOldValueがfalseであったときのことを指す
 // 変更が無かった場合とは、指定のキーを持つノードが存在しなかった場合もしくはコールバックの戻り値keepOldValueがtrueであった場合
 func Update(tree Tree, key Key, callBack UpdateValueCallBack) (modified Tree, ok bool) {
-	if newRoot, ok := updateValue(tree.Root(), key, callBack); ok {
+	callBack = observeUpdateValue(getObserver(tree), callBack)
+	if newRoot, ok := updateValue(tree.Root(), compareBy(tree, key), callBack); ok {
 		return tree.(RealTree).SetRoot(newRoot), true
 	} else {
 		return tree, false
@@ -393,7 +462,8 @@ type AlterRequest struct {
 // 戻り値のdeletedValueは削除したノードのキーと値を持っている
 // 戻り値のokは対象のノードが存在しコールバックの戻り値で変更か削除を指定された場合にtrueとなり、それ以外の場合はfalseとなる
 func Alter(tree Tree, key Key, callBack AlterNodeCallBack) (modified Tree, deletedValue KeyAndValue, ok bool) {
-	if newRoot, deleted, ok := alter(tree.Root(), key, callBack); ok {
+	callBack = observeAlterNode(getObserver(tree), callBack)
+	if newRoot, deleted, ok := alter(tree.Root(), compareBy(tree, key), callBack); ok {
 		if deleted != nil {
 			deletedValue = &keyAndValue{
 				deleted.Key(),
@@ -416,6 +486,17 @@ func Alter(tree Tree, key Key, callBack AlterNodeCallBack) (modified Tree, delet
 // 木のインスタンスを再利用するために木が保持するノードを全て削除し空にする
 // 戻り値のmodifiedはRealTreeのSetRootメソッドの戻り値となる
 func Clear(tree Tree) (modified Tree) {
+	if observer := getObserver(tree); observer != nil {
+		ascIterateNode(tree.Root(), func(node Node) (breakIteration bool) {
+			observer.NodeDeleted(node.Key(), node.Value())
+			return
+		})
+	}
+	return clearTree(tree)
+}
+
+// Clearの変更の通知以外の処理
+func clearTree(tree Tree) (modified Tree) {
 	if releaser, ok := tree.(NodeReleaser); ok {
 		stack := []Node{tree.Root()}
 		for 0 < len(stack) {
@@ -438,8 +519,9 @@ func Clear(tree Tree) (modified Tree) {
 // 木のインスタンスを解放(または破棄)する
 // 木がインターフェースTreeReleaserを実装している場合にのみ機能する
 // 解放の全ての処理が終わったあと引数のtreeの参照先にはnilが代入される
+// 解放は木の内容の変更ではないため、木がMutationObserverを実装していてもノードの削除は通知されない
 func Release(tree *Tree) {
-	Clear(*tree)
+	clearTree(*tree)
 	if releaser, ok := (*tree).(TreeReleaser); ok {
 		releaser.ReleaseTree()
 	}
@@ -452,6 +534,7 @@ func Release(tree *Tree) {
 // 可変(mutable)の木の場合にはnodeの内容を変更する操作は木にも影響する
 // 不変(immutable)の木の場合にはnodeのインターフェスNodeやRealNodeのメソッド呼び出しでは木に影響がないことが期待される
 func Find(tree Tree, key Key) (node Node) {
+	key = compareBy(tree, key)
 	node = tree.Root()
 	for node != nil {
 		cmp := key.CompareTo(node.Key())
@@ -498,7 +581,7 @@ func RangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack IterateC
 	if lower == nil && upper == nil {
 		return Iterate(tree, descOrder, callBack)
 	}
-	bounds := newKeyBounds(lower, upper, tree.(RealTree).AllowDuplicateKeys())
+	bounds := newKeyBounds(compareBy(tree, lower), compareBy(tree, upper), tree.(RealTree).AllowDuplicateKeys())
 	if descOrder {
 		return descRangeNode(tree.Root(), bounds, callBack)
 	} else {
@@ -521,6 +604,7 @@ func CountRange(tree Tree, lower, upper Key) int {
 	if lower == nil && upper == nil {
 		return Count(tree)
 	}
+	lower, upper = compareBy(tree, lower), compareBy(tree, upper)
 	if tree.(RealTree).AllowDuplicateKeys() {
 		return countExtendedRange(tree.Root(), lower, upper)
 	} else {
@@ -669,11 +753,15 @@ func DeleteIterate(tree Tree, descOrder bool, callBack DeleteIterateCallBack) (m
 	if len(deleted) == 0 {
 		return tree, nil
 	}
+	observer := getObserver(tree)
 	for _, node := range deleted {
 		values = append(values, &keyAndValue{
 			node.Key(),
 			node.Value(),
 		})
+		if observer != nil {
+			observer.NodeDeleted(node.Key(), node.Value())
+		}
 		if releaser, ok := tree.(NodeReleaser); ok {
 			releaser.ReleaseNode(node.(RealNode))
 		}
@@ -703,7 +791,7 @@ func DeleteRangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack De
 	}
 	var newRoot Node
 	var deleted []Node
-	bounds := newKeyBounds(lower, upper, tree.(RealTree).AllowDuplicateKeys())
+	bounds := newKeyBounds(compareBy(tree, lower), compareBy(tree, upper), tree.(RealTree).AllowDuplicateKeys())
 	if descOrder {
 		newRoot, deleted, _ = descDeleteRange(tree.Root(), bounds, callBack)
 	} else {
@@ -712,11 +800,15 @@ func DeleteRangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack De
 	if len(deleted) == 0 {
 		return tree, nil
 	}
+	observer := getObserver(tree)
 	for _, node := range deleted {
 		values = append(values, &keyAndValue{
 			node.Key(),
 			node.Value(),
 		})
+		if observer != nil {
+			observer.NodeDeleted(node.Key(), node.Value())
+		}
 		if releaser, ok := tree.(NodeReleaser); ok {
 			releaser.ReleaseNode(node.(RealNode))
 		}
@@ -731,6 +823,7 @@ func DeleteRangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack De
 // UpdateとIterateを組み合わせた感じ
 // 巡っていく各ノードに対してコールバックが呼ばれる
 func UpdateIterate(tree Tree, descOrder bool, callBack UpdateIterateCallBack) (modified Tree, ok bool) {
+	callBack = observeUpdateIterate(getObserver(tree), callBack)
 	if descOrder {
 		if newRoot, updated, _ := descUpdateIterate(tree.Root(), callBack); updated {
 			return tree.(RealTree).SetRoot(newRoot), true
@@ -761,7 +854,8 @@ func UpdateRangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack Up
 	if lower == nil && upper == nil {
 		return UpdateIterate(tree, descOrder, callBack)
 	}
-	bounds := newKeyBounds(lower, upper, tree.(RealTree).AllowDuplicateKeys())
+	callBack = observeUpdateIterate(getObserver(tree), callBack)
+	bounds := newKeyBounds(compareBy(tree, lower), compareBy(tree, upper), tree.(RealTree).AllowDuplicateKeys())
 	if descOrder {
 		if newRoot, updated, _ := descUpdateRange(tree.Root(), bounds, callBack); updated {
 			return tree.(RealTree).SetRoot(newRoot), true
@@ -788,6 +882,7 @@ func ReplaceRange(tree Tree, lower, upper Key, newValue interface{}) (modified T
 // AlterとIterateを組み合わせた感じ
 // 巡っていく各ノードに対してコールバックが呼ばれる
 func AlterIterate(tree Tree, descOrder bool, callBack AlterIterateCallBack) (modified Tree, deletedValues []KeyAndValue, ok bool) {
+	callBack = observeAlterIterate(getObserver(tree), callBack)
 	var newRoot Node
 	var deleted []Node
 	var anyChanged bool
@@ -827,10 +922,11 @@ func AlterRangeIterate(tree Tree, descOrder bool, lower, upper Key, callBack Alt
 	if lower == nil && upper == nil {
 		return AlterIterate(tree, descOrder, callBack)
 	}
+	callBack = observeAlterIterate(getObserver(tree), callBack)
 	var newRoot Node
 	var deleted []Node
 	var anyChanged bool
-	bounds := newKeyBounds(lower, upper, tree.(RealTree).AllowDuplicateKeys())
+	bounds := newKeyBounds(compareBy(tree, lower), compareBy(tree, upper), tree.(RealTree).AllowDuplicateKeys())
 	if descOrder {
 		newRoot, deleted, anyChanged, _ = descAlterRange(tree.Root(), bounds, callBack)
 	} else {
@@ -945,7 +1041,7 @@ func countRange(node Node, lower, upper Key) int {
 			// lower == nil, upper == nil   ... all(leftChild) key all(rightChild)
 			return countNode(node)
 		}
-		cmp := node.Key().CompareTo(upper)
+		cmp := compareNodeKey(node.Key(), upper)
 		switch {
 		case cmp.GreaterThan():
 			// lower == nil, upper < key    ... leftChild
@@ -962,7 +1058,7 @@ func countRange(node Node, lower, upper Key) int {
 		}
 	}
 	if upper == nil {
-		cmp := node.Key().CompareTo(lower)
+		cmp := compareNodeKey(node.Key(), lower)
 		switch {
 		case cmp.GreaterThan():
 			// upper == nil, lower < key    ... leftChild key all(rightChild)
@@ -979,8 +1075,8 @@ func countRange(node Node, lower, upper Key) int {
 		}
 	}
 	key := node.Key()
-	cmpLower := key.CompareTo(lower)
-	cmpUpper := key.CompareTo(upper)
+	cmpLower := compareNodeKey(key, lower)
+	cmpUpper := compareNodeKey(key, upper)
 	switch {
 	case cmpUpper.GreaterThan():
 		// lower < upper < key      ... leftChild
@@ -1029,7 +1125,7 @@ func countExtendedRange(node Node, lower, upper Key) int {
 			// lower == nil, upper == nil   ... all(leftChild) key all(rightChild)
 			return countNode(node)
 		}
-		if node.Key().CompareTo(upper).GreaterThan() {
+		if compareNodeKey(node.Key(), upper).GreaterThan() {
 			// lower == nil, upper < key    ... leftChild
 			return countExtendedRange(node.LeftChild(), lower, upper)
 		} else {
@@ -1039,7 +1135,7 @@ func countExtendedRange(node Node, lower, upper Key) int {
 		}
 	}
 	if upper == nil {
-		if node.Key().CompareTo(lower).GreaterThanOrEqualTo() {
+		if compareNodeKey(node.Key(), lower).GreaterThanOrEqualTo() {
 			// upper == nil, lower < key    ... leftChild key all(rightChild)
 			// upper == nil, lower == key   ... leftChild key all(rightChild)
 			return countExtendedRange(node.LeftChild(), lower, upper) + 1 + countNode(node.RightChild())
@@ -1049,8 +1145,8 @@ func countExtendedRange(node Node, lower, upper Key) int {
 		}
 	}
 	key := node.Key()
-	cmpLower := key.CompareTo(lower)
-	cmpUpper := key.CompareTo(upper)
+	cmpLower := compareNodeKey(key, lower)
+	cmpUpper := compareNodeKey(key, upper)
 	switch {
 	case cmpUpper.GreaterThan():
 		// lower < upper < key      ... leftChild
@@ -1067,6 +1163,133 @@ func countExtendedRange(node Node, lower, upper Key) int {
 	}
 }
 
+// 木がKeyComparatorを実装している場合にCompareToメソッドで木の比較方法を使うキー
+type comparedKey struct {
+	key        Key
+	comparator KeyComparator
+}
+
+func (key *comparedKey) CompareTo(other Key) KeyOrdering {
+	return key.comparator.CompareKeys(key.key, other)
+}
+
+func (key *comparedKey) Copy() Key {
+	return &comparedKey{key.key.Copy(), key.comparator}
+}
+
+// 木がKeyComparatorを実装している場合は引数のキーを木の比較方法で比較するキーに包んで返す
+// 包んだキーは比較にのみ使い、ノードのキーやコールバックの引数には元のキーを使う
+// keyがnilの場合(範囲の境界の指定が無い場合)はnilのまま返す
+func compareBy(tree Tree, key Key) Key {
+	if comparator, ok := tree.(KeyComparator); ok && key != nil {
+		return &comparedKey{key, comparator}
+	} else {
+		return key
+	}
+}
+
+// ノードのキーと引数で渡されたキー(範囲の境界)とを比較する
+// 境界がcompareByで包まれている場合(木がKeyComparatorを実装している場合)のみ木の比較方法を使い、
+// それ以外はノードのキーのCompareToメソッドで比較する
+func compareNodeKey(nodeKey, bound Key) KeyOrdering {
+	if key, ok := bound.(*comparedKey); ok {
+		return key.comparator.CompareKeys(nodeKey, key.key)
+	} else {
+		return nodeKey.CompareTo(bound)
+	}
+}
+
+// 木の比較方法でキーを比較する
+// 木がKeyComparatorを実装している場合はCompareKeysメソッド、実装していない場合はkey1のCompareToメソッドで比較する
+// サブパッケージなどで本パッケージの関数を介さずにキーを比較する場合に使う
+func CompareKeys(tree Tree, key1, key2 Key) KeyOrdering {
+	if comparator, ok := tree.(KeyComparator); ok {
+		return comparator.CompareKeys(key1, key2)
+	} else {
+		return key1.CompareTo(key2)
+	}
+}
+
+// 木がMutationObserverを実装している場合はそれを返し、実装していない場合はnilを返す
+func getObserver(tree Tree) MutationObserver {
+	if observer, ok := tree.(MutationObserver); ok {
+		return observer
+	} else {
+		return nil
+	}
+}
+
+// 新しいノードに渡すキーを複製する
+// 木がKeyCopierを実装している場合は木の側で複製する
+func copyKey(tree RealTree, key Key) Key {
+	if copier, ok := tree.(KeyCopier); ok {
+		return copier.CopyKey(key)
+	} else {
+		return key.Copy()
+	}
+}
+
+// コールバックで値の変更が指定された場合に通知するようにコールバックをラップする
+// observerがnilの場合はコールバックをそのまま返す
+func observeUpdateValue(observer MutationObserver, callBack UpdateValueCallBack) UpdateValueCallBack {
+	if observer == nil {
+		return callBack
+	}
+	return func(key Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue, keepOldValue = callBack(key, oldValue)
+		if !keepOldValue {
+			observer.NodeReplaced(key, oldValue, newValue)
+		}
+		return
+	}
+}
+
+func observeUpdateIterate(observer MutationObserver, callBack UpdateIterateCallBack) UpdateIterateCallBack {
+	if observer == nil {
+		return callBack
+	}
+	return func(key Key, oldValue interface{}) (newValue interface{}, keepOldValue, breakIteration bool) {
+		newValue, keepOldValue, breakIteration = callBack(key, oldValue)
+		if !keepOldValue {
+			observer.NodeReplaced(key, oldValue, newValue)
+		}
+		return
+	}
+}
+
+// コールバックで値の置き換えかノードの削除が指定された場合に通知するようにコールバックをラップする
+// observerがnilの場合はコールバックをそのまま返す
+func observeAlterNode(observer MutationObserver, callBack AlterNodeCallBack) AlterNodeCallBack {
+	if observer == nil {
+		return callBack
+	}
+	return func(node AlterNode) (request AlterRequest) {
+		request = callBack(node)
+		notifyAlterRequest(observer, node, &request)
+		return
+	}
+}
+
+func observeAlterIterate(observer MutationObserver, callBack AlterIterateCallBack) AlterIterateCallBack {
+	if observer == nil {
+		return callBack
+	}
+	return func(node AlterNode) (request AlterRequest, breakIteration bool) {
+		request, breakIteration = callBack(node)
+		notifyAlterRequest(observer, node, &request)
+		return
+	}
+}
+
+func notifyAlterRequest(observer MutationObserver, node AlterNode, request *AlterRequest) {
+	switch {
+	case request.isReplaceRequest():
+		observer.NodeReplaced(node.Key(), node.Value(), request.newValue)
+	case request.isDeleteRequest():
+		observer.NodeDeleted(node.Key(), node.Value())
+	}
+}
+
 // ノードの高さ情報を取得する
 func getHeight(node Node) int {
 	if node == nil {
@@ -1186,17 +1409,22 @@ type insertHelper struct {
 	tree            *RealTree
 	replaceIfExists bool
 	key             *Key
+	compare         Key
 	value           *interface{}
+	observer        MutationObserver
 }
 
 // RealTreeのNewNodeを呼び出す
 func (helper *insertHelper) newNode() RealNode {
-	return (*helper.tree).NewNode(nil, nil, 1, (*helper.key).Copy(), *helper.value)
+	if helper.observer != nil {
+		helper.observer.NodeInserted(*helper.key, *helper.value)
+	}
+	return (*helper.tree).NewNode(nil, nil, 1, copyKey(*helper.tree, *helper.key), *helper.value)
 }
 
 // 挿入するキーと対象のノードのキーと比較する
 func (helper *insertHelper) compareKey(node Node) KeyOrdering {
-	return (*helper.key).CompareTo(node.Key())
+	return helper.compare.CompareTo(node.Key())
 }
 
 // 木が同一キーを許可するかを取得する
@@ -1225,6 +1453,9 @@ func (helper *insertHelper) insertTo(root Node) (newRoot RealNode, ok bool) {
 		}
 	default:
 		if helper.replaceIfExists {
+			if helper.observer != nil {
+				helper.observer.NodeReplaced(root.Key(), root.Value(), *helper.value)
+			}
 			newRoot = root.SetValue(*helper.value).(RealNode)
 			return newRoot, true
 		}
@@ -1450,11 +1681,11 @@ type bothBounds struct {
 }
 
 func (bounds *bothBounds) checkLower(key Key) boundsChecker {
-	return &lowerBoundsChecker{key.CompareTo(bounds.lower), bounds.ext}
+	return &lowerBoundsChecker{compareNodeKey(key, bounds.lower), bounds.ext}
 }
 
 func (bounds *bothBounds) checkUpper(key Key) boundsChecker {
-	return &upperBoundsChecker{key.CompareTo(bounds.upper), bounds.ext}
+	return &upperBoundsChecker{compareNodeKey(key, bounds.upper), bounds.ext}
 }
 
 // upperのみが指定された場合の範囲情報
@@ -1468,7 +1699,7 @@ func (bounds *upperBound) checkLower(key Key) boundsChecker {
 }
 
 func (bounds *upperBound) checkUpper(key Key) boundsChecker {
-	return &upperBoundsChecker{key.CompareTo(bounds.upper), bounds.ext}
+	return &upperBoundsChecker{compareNodeKey(key, bounds.upper), bounds.ext}
 }
 
 // lowerのみが指定された場合の範囲情報
@@ -1478,7 +1709,7 @@ type lowerBound struct {
 }
 
 func (bounds *lowerBound) checkLower(key Key) boundsChecker {
-	return &lowerBoundsChecker{key.CompareTo(bounds.lower), bounds.ext}
+	return &lowerBoundsChecker{compareNodeKey(key, bounds.lower), bounds.ext}
 }
 
 func (bounds *lowerBound) checkUpper(key Key) boundsChecker {
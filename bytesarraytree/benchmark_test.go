@@ -0,0 +1,78 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package bytesarraytree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/byteskey"
+)
+
+func genBytes() []byte {
+	buf := make([]byte, 4+rand.Intn(12))
+	rand.Read(buf)
+	return buf
+}
+
+func genKeyAndValues(n int) []*keyAndValue {
+	list := []*keyAndValue{}
+	for i := 0; i < n; i++ {
+		list = append(list, &keyAndValue{genBytes(), genBytes()})
+	}
+	return list
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list[:b.N] {
+		avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+	}
+	list = list[b.N:]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Delete(tree, BytesKey(kv.Key))
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, kv := range list {
+		tree.Find(kv.Key)
+	}
+}
+
+func BenchmarkCompact(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+	}
+	for _, kv := range list[:b.N] {
+		avltree.Delete(tree, BytesKey(kv.Key))
+	}
+	b.ResetTimer()
+	tree.Compact()
+}
@@ -0,0 +1,494 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのRealTree,RealNodeの実装例
+// int型の可変長配列(スライス)上にAVL木を構築し、キーと値のバイト列は別のbyte型の可変長配列(アリーナ)に保持する
+//
+// 扱えるキーはbyteskeyのBytesKeyのみ
+// 扱える値は[]byte型のみ
+//
+// アリーナは追記のみで、一度書き込んだバイト列が上書きされることはない
+// ノードの削除や値の変更で不要になったバイト列はCompactを呼び出すまでアリーナに残る
+//
+// ノードのKey()やValue()、FindやRangeIterateで得られるスライスはアリーナ上を直接指している
+// アリーナは上書きされないので得られたスライスの中身が後から書き換わることはないが、
+// 値の変更やCompactの結果はそのスライスには反映されない
+// また得られたスライスの中身を書き換えてはならない
+//
+// 配列の最初の４要素は以下の木に関する情報を保持
+// + ルートノードのインデックス
+// + 同一キーを許可するかどうかの値
+// + 再利用可能なノードのインデックス
+// + アリーナ中の不要になったバイト数
+//
+// １つのノードは９個分の要素で構成され、以下の情報を保持
+// + 左の子ノードのインデックス
+// + 右の子ノードのインデックス
+// + 木におけるノードの高さ
+// + 親ノードのインデックス
+// + 左右の子孫も合わせたノード総数
+// + アリーナにおけるキーの開始位置
+// + キーのバイト数
+// + アリーナにおける値の開始位置
+// + 値のバイト数
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/bytesarraytree"
+//			. "github.com/neetsdkasu/avltree/byteskey"
+//		)
+//		func Example_bytesarraytree() {
+//			tree := bytesarraytree.New(false)
+//			avltree.Insert(tree, false, BytesKey("banana"), []byte("yellow"))
+//			avltree.Insert(tree, false, BytesKey("cherry"), []byte("red"))
+//			avltree.Insert(tree, false, BytesKey("durian"), []byte("green"))
+//			avltree.Insert(tree, false, BytesKey("apple"), []byte("red"))
+//			avltree.Delete(tree, BytesKey("cherry"))
+//			tree.Compact()
+//			if value, ok := tree.Find([]byte("banana")); ok {
+//				fmt.Printf("Find! banana %s\n", value)
+//			}
+//			tree.RangeIterate(false, []byte("b"), nil, func(key, value []byte) (breakIteration bool) {
+//				fmt.Printf("RangeIterate! %s %s\n", key, value)
+//				return
+//			})
+//			// Output:
+//			// Find! banana yellow
+//			// RangeIterate! banana yellow
+//			// RangeIterate! durian green
+//		}
+//
+package bytesarraytree
+
+import (
+	"bytes"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+)
+
+const (
+	PositionRootPosition int = iota
+	PositionDuplicateKeysBehavior
+	PositionIdleNodePosition
+	PositionGarbageSize
+	HeaderSize
+)
+
+const (
+	OffsetLeftChildPosition int = iota
+	OffsetRightChildPosition
+	OffsetHeight
+	OffsetParentPosition
+	OffsetNodeCount
+	OffsetKeyPosition
+	OffsetKeyLength
+	OffsetValuePosition
+	OffsetValueLength
+	NodeSize
+)
+
+const NodeIsNothing int = 0
+
+const (
+	DisallowDuplicateKeys int = 0
+	AllowDuplicateKeys    int = 1
+)
+
+type BytesArrayTree struct {
+	Array []int
+	Arena []byte
+}
+
+type BytesArrayTreeNode struct {
+	Tree     *BytesArrayTree
+	Position int
+}
+
+func New(allowDuplicateKeys bool) *BytesArrayTree {
+	return NewWithInitialCapacity(HeaderSize, 0, allowDuplicateKeys)
+}
+
+func NewWithInitialCapacity(initialCapacity, initialArenaCapacity int, allowDuplicateKeys bool) *BytesArrayTree {
+	tree := &BytesArrayTree{Array: make([]int, initialCapacity)}
+	tree.Init(allowDuplicateKeys)
+	tree.Arena = make([]byte, 0, initialArenaCapacity)
+	return tree
+}
+
+func (tree *BytesArrayTree) Init(allowDuplicateKeys bool) {
+	array := tree.Array
+	if len(array) < HeaderSize {
+		var buf [HeaderSize]int
+		array = append(array, buf[:]...)
+	}
+	array = array[:HeaderSize]
+	array[PositionRootPosition] = NodeIsNothing
+	if allowDuplicateKeys {
+		array[PositionDuplicateKeysBehavior] = AllowDuplicateKeys
+	} else {
+		array[PositionDuplicateKeysBehavior] = DisallowDuplicateKeys
+	}
+	array[PositionIdleNodePosition] = NodeIsNothing
+	array[PositionGarbageSize] = 0
+	tree.Array = array
+	// 以前に渡したスライスの中身を上書きしないよう古いアリーナは再利用しない
+	tree.Arena = []byte{}
+}
+
+func unwrap(node avltree.Node) int {
+	if node == nil {
+		return NodeIsNothing
+	} else {
+		return node.(*BytesArrayTreeNode).Position
+	}
+}
+
+func (node *BytesArrayTreeNode) toNode() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node
+	}
+}
+
+func (tree *BytesArrayTree) getNode(position int) *BytesArrayTreeNode {
+	if position == NodeIsNothing {
+		return nil
+	} else {
+		return &BytesArrayTreeNode{
+			Tree:     tree,
+			Position: position,
+		}
+	}
+}
+
+func (tree *BytesArrayTree) getRoot() *BytesArrayTreeNode {
+	return tree.getNode(tree.Array[PositionRootPosition])
+}
+
+// bytesarraytree.New()以外でBytesArrayTreeが生成されたときの気休め保険
+func (tree *BytesArrayTree) init() bool {
+	if len(tree.Array) < HeaderSize {
+		tree.Init(true)
+		return true
+	} else {
+		return false
+	}
+}
+
+// アリーナにバイト列を追記し、その開始位置を返す
+func (tree *BytesArrayTree) appendToArena(data []byte) int {
+	position := len(tree.Arena)
+	tree.Arena = append(tree.Arena, data...)
+	return position
+}
+
+// アリーナ上のバイト列をスライスとして返す
+// 容量を長さに揃えておくことで、受け取った側でappendされてもアリーナが壊れないようにする
+func (tree *BytesArrayTree) slice(position, length int) []byte {
+	end := position + length
+	return tree.Arena[position:end:end]
+}
+
+func (tree *BytesArrayTree) keyAt(position int) []byte {
+	array := tree.Array
+	return tree.slice(array[position+OffsetKeyPosition], array[position+OffsetKeyLength])
+}
+
+func (tree *BytesArrayTree) valueAt(position int) []byte {
+	array := tree.Array
+	return tree.slice(array[position+OffsetValuePosition], array[position+OffsetValueLength])
+}
+
+func (tree *BytesArrayTree) Root() avltree.Node {
+	tree.init()
+	return tree.getRoot().toNode()
+}
+
+func (tree *BytesArrayTree) ReleaseNode(node avltree.RealNode) {
+	tree.init()
+	position := unwrap(node)
+	if position != NodeIsNothing {
+		array := tree.Array
+		array[PositionGarbageSize] += array[position+OffsetKeyLength] + array[position+OffsetValueLength]
+		array[position] = array[PositionIdleNodePosition]
+		array[PositionIdleNodePosition] = position
+	}
+}
+
+func (tree *BytesArrayTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	tree.init()
+	keyBytes := key.(byteskey.BytesKey)
+	valueBytes := value.([]byte)
+	array := tree.Array
+	newNodePosition := array[PositionIdleNodePosition]
+	if newNodePosition == NodeIsNothing {
+		var buf [NodeSize]int
+		newNodePosition = len(array)
+		array = append(array, buf[:]...)
+		tree.Array = array
+	} else {
+		nextIdleNodePosition := array[newNodePosition]
+		array[PositionIdleNodePosition] = nextIdleNodePosition
+	}
+	node := tree.getNode(newNodePosition)
+	node.set(OffsetLeftChildPosition, unwrap(leftChild))
+	node.set(OffsetRightChildPosition, unwrap(rightChild))
+	node.set(OffsetHeight, height)
+	node.set(OffsetParentPosition, NodeIsNothing)
+	node.set(OffsetNodeCount, 1)
+	node.set(OffsetKeyPosition, tree.appendToArena(keyBytes))
+	node.set(OffsetKeyLength, len(keyBytes))
+	node.set(OffsetValuePosition, tree.appendToArena(valueBytes))
+	node.set(OffsetValueLength, len(valueBytes))
+	node.resetNodeCount()
+	return node
+}
+
+// キーのバイト列はNewNodeでアリーナに複製するため、ここでは複製しない
+func (tree *BytesArrayTree) CopyKey(key avltree.Key) avltree.Key {
+	return key
+}
+
+func (tree *BytesArrayTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.init()
+	tree.Array[PositionRootPosition] = unwrap(newRoot)
+	tree.getRoot().setParent(NodeIsNothing)
+	return tree
+}
+
+func (tree *BytesArrayTree) AllowDuplicateKeys() bool {
+	tree.init()
+	return tree.Array[PositionDuplicateKeysBehavior] == AllowDuplicateKeys
+}
+
+func (tree *BytesArrayTree) NodeCount() int {
+	tree.init()
+	return tree.getRoot().NodeCount()
+}
+
+func (tree *BytesArrayTree) CleanUpTree() {
+	if tree.init() {
+		return
+	}
+	tree.Init(tree.AllowDuplicateKeys())
+}
+
+// アリーナ中の不要になったバイト数
+func (tree *BytesArrayTree) GarbageSize() int {
+	tree.init()
+	return tree.Array[PositionGarbageSize]
+}
+
+// 木に残っているキーと値のバイト列だけを新しいアリーナに詰め直す
+// 古いアリーナは書き換えないので、それまでに得たスライスは古いアリーナを指したまま残る
+func (tree *BytesArrayTree) Compact() {
+	tree.init()
+	array := tree.Array
+	oldArena := tree.Arena
+	tree.Arena = make([]byte, 0, len(oldArena)-array[PositionGarbageSize])
+	var compact func(position int)
+	compact = func(position int) {
+		if position == NodeIsNothing {
+			return
+		}
+		compact(array[position+OffsetLeftChildPosition])
+		keyPosition := array[position+OffsetKeyPosition]
+		keyLength := array[position+OffsetKeyLength]
+		array[position+OffsetKeyPosition] = tree.appendToArena(oldArena[keyPosition : keyPosition+keyLength])
+		valuePosition := array[position+OffsetValuePosition]
+		valueLength := array[position+OffsetValueLength]
+		array[position+OffsetValuePosition] = tree.appendToArena(oldArena[valuePosition : valuePosition+valueLength])
+		compact(array[position+OffsetRightChildPosition])
+	}
+	compact(array[PositionRootPosition])
+	array[PositionGarbageSize] = 0
+}
+
+// キーに一致するノードの値を返す
+// 同一キーが複数ある場合はいずれか１つの値を返す
+// 返すスライスはアリーナ上を直接指しており、メモリの割り当ては発生しない
+func (tree *BytesArrayTree) Find(key []byte) (value []byte, ok bool) {
+	tree.init()
+	array := tree.Array
+	position := array[PositionRootPosition]
+	for position != NodeIsNothing {
+		cmp := bytes.Compare(key, tree.keyAt(position))
+		switch {
+		case cmp < 0:
+			position = array[position+OffsetLeftChildPosition]
+		case cmp > 0:
+			position = array[position+OffsetRightChildPosition]
+		default:
+			return tree.valueAt(position), true
+		}
+	}
+	return nil, false
+}
+
+// lower <= key <= upper の範囲のキーと値を順番にcallBackに渡す
+// lowerやupperがnilの場合はその方向の範囲の制限はない
+// callBackに渡すスライスはアリーナ上を直接指しており、メモリの割り当ては発生しない
+// callBackの中で木を変更してはならない
+func (tree *BytesArrayTree) RangeIterate(descOrder bool, lower, upper []byte, callBack func(key, value []byte) (breakIteration bool)) {
+	tree.init()
+	root := tree.Array[PositionRootPosition]
+	allowDuplicateKeys := tree.AllowDuplicateKeys()
+	if descOrder {
+		tree.descRangeIterate(root, lower, upper, allowDuplicateKeys, callBack)
+	} else {
+		tree.ascRangeIterate(root, lower, upper, allowDuplicateKeys, callBack)
+	}
+}
+
+func (tree *BytesArrayTree) checkBounds(position int, lower, upper []byte, allowDuplicateKeys bool) (includeLower, includeKey, includeUpper bool) {
+	key := tree.keyAt(position)
+	includeKey = true
+	includeLower = true
+	if lower != nil {
+		cmp := bytes.Compare(key, lower)
+		includeKey = cmp >= 0
+		includeLower = cmp > 0 || (allowDuplicateKeys && cmp == 0)
+	}
+	includeUpper = true
+	if upper != nil {
+		cmp := bytes.Compare(key, upper)
+		includeKey = includeKey && cmp <= 0
+		includeUpper = cmp < 0 || (allowDuplicateKeys && cmp == 0)
+	}
+	return
+}
+
+func (tree *BytesArrayTree) ascRangeIterate(position int, lower, upper []byte, allowDuplicateKeys bool, callBack func(key, value []byte) (breakIteration bool)) (breakIteration bool) {
+	if position == NodeIsNothing {
+		return false
+	}
+	array := tree.Array
+	includeLower, includeKey, includeUpper := tree.checkBounds(position, lower, upper, allowDuplicateKeys)
+	if includeLower {
+		if tree.ascRangeIterate(array[position+OffsetLeftChildPosition], lower, upper, allowDuplicateKeys, callBack) {
+			return true
+		}
+	}
+	if includeKey {
+		if callBack(tree.keyAt(position), tree.valueAt(position)) {
+			return true
+		}
+	}
+	if includeUpper {
+		return tree.ascRangeIterate(array[position+OffsetRightChildPosition], lower, upper, allowDuplicateKeys, callBack)
+	}
+	return false
+}
+
+func (tree *BytesArrayTree) descRangeIterate(position int, lower, upper []byte, allowDuplicateKeys bool, callBack func(key, value []byte) (breakIteration bool)) (breakIteration bool) {
+	if position == NodeIsNothing {
+		return false
+	}
+	array := tree.Array
+	includeLower, includeKey, includeUpper := tree.checkBounds(position, lower, upper, allowDuplicateKeys)
+	if includeUpper {
+		if tree.descRangeIterate(array[position+OffsetRightChildPosition], lower, upper, allowDuplicateKeys, callBack) {
+			return true
+		}
+	}
+	if includeKey {
+		if callBack(tree.keyAt(position), tree.valueAt(position)) {
+			return true
+		}
+	}
+	if includeLower {
+		return tree.descRangeIterate(array[position+OffsetLeftChildPosition], lower, upper, allowDuplicateKeys, callBack)
+	}
+	return false
+}
+
+func (node *BytesArrayTreeNode) Key() avltree.Key {
+	return byteskey.BytesKey(node.Tree.keyAt(node.Position))
+}
+
+func (node *BytesArrayTreeNode) Value() interface{} {
+	return node.Tree.valueAt(node.Position)
+}
+
+func (node *BytesArrayTreeNode) get(offset int) int {
+	return node.Tree.Array[node.Position+offset]
+}
+func (node *BytesArrayTreeNode) set(offset, value int) {
+	node.Tree.Array[node.Position+offset] = value
+}
+
+func (node *BytesArrayTreeNode) getLeftChild() *BytesArrayTreeNode {
+	return node.Tree.getNode(node.get(OffsetLeftChildPosition))
+}
+
+func (node *BytesArrayTreeNode) LeftChild() avltree.Node {
+	return node.getLeftChild().toNode()
+}
+
+func (node *BytesArrayTreeNode) getRightChild() *BytesArrayTreeNode {
+	return node.Tree.getNode(node.get(OffsetRightChildPosition))
+}
+
+func (node *BytesArrayTreeNode) RightChild() avltree.Node {
+	return node.getRightChild().toNode()
+}
+
+// アリーナは追記のみなので、新しい値は常にアリーナの末尾に書き込まれる
+func (node *BytesArrayTreeNode) SetValue(newValue interface{}) avltree.Node {
+	valueBytes := newValue.([]byte)
+	tree := node.Tree
+	tree.Array[PositionGarbageSize] += node.get(OffsetValueLength)
+	node.set(OffsetValuePosition, tree.appendToArena(valueBytes))
+	node.set(OffsetValueLength, len(valueBytes))
+	return node
+}
+
+func (node *BytesArrayTreeNode) setParent(position int) {
+	if node != nil {
+		node.set(OffsetParentPosition, position)
+	}
+}
+
+func (node *BytesArrayTreeNode) Parent() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node.Tree.getNode(node.get(OffsetParentPosition)).toNode()
+	}
+}
+
+func (node *BytesArrayTreeNode) NodeCount() int {
+	if node == nil {
+		return 0
+	} else {
+		return node.get(OffsetNodeCount)
+	}
+}
+
+func (node *BytesArrayTreeNode) Height() int {
+	return node.get(OffsetHeight)
+}
+
+func (node *BytesArrayTreeNode) resetNodeCount() {
+	node.set(OffsetNodeCount, 1+node.getLeftChild().NodeCount()+node.getRightChild().NodeCount())
+}
+
+func (node *BytesArrayTreeNode) SetChildren(newLeftChild, newRightChild avltree.Node, newHeight int) avltree.RealNode {
+	node.set(OffsetLeftChildPosition, unwrap(newLeftChild))
+	node.set(OffsetRightChildPosition, unwrap(newRightChild))
+	node.set(OffsetHeight, newHeight)
+	node.getLeftChild().setParent(node.Position)
+	node.getRightChild().setParent(node.Position)
+	node.resetNodeCount()
+	return node
+}
+
+func (node *BytesArrayTreeNode) Set(newLeftChild, newRightChild avltree.Node, newHeight int, newValue interface{}) avltree.RealNode {
+	node.SetValue(newValue)
+	return node.SetChildren(newLeftChild, newRightChild, newHeight)
+}
@@ -0,0 +1,366 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package bytesarraytree
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/byteskey"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type (
+	Key  = avltree.Key
+	Node = avltree.Node
+)
+
+type keyAndValue struct {
+	Key   []byte
+	Value []byte
+}
+
+func omitDuplicates(list []keyAndValue) []*keyAndValue {
+	set := make(map[string]bool)
+	result := []*keyAndValue{}
+	for i := range list {
+		kv := &list[i]
+		if set[string(kv.Key)] {
+			continue
+		}
+		set[string(kv.Key)] = true
+		result = append(result, kv)
+	}
+	return result
+}
+
+func toAscSorted(list []*keyAndValue) []*keyAndValue {
+	sort.SliceStable(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Key, list[j].Key) < 0
+	})
+	return list
+}
+
+func toKeyValues(list []*keyAndValue) (result [][]byte) {
+	for _, kv := range list {
+		result = append(result, kv.Key, kv.Value)
+	}
+	return
+}
+
+func inRange(key, lower, upper []byte) bool {
+	return (lower == nil || bytes.Compare(lower, key) <= 0) &&
+		(upper == nil || bytes.Compare(key, upper) <= 0)
+}
+
+func getAllAscKeyAndValues(tree *BytesArrayTree) (result [][]byte) {
+	avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+		result = append(result, []byte(node.Key().(BytesKey)), node.Value().([]byte))
+		return
+	})
+	return
+}
+
+func TestInsertOneEntry(t *testing.T) {
+	tree := New(false)
+	avltree.Insert(tree, false, BytesKey("key"), []byte("value"))
+
+	want := &BytesArrayTree{
+		Array: []int{
+			// header
+			HeaderSize,            // root position
+			DisallowDuplicateKeys, // duplicate keys behavior
+			NodeIsNothing,         // idle node position
+			0,                     // garbage size
+			// node
+			NodeIsNothing, // left child position
+			NodeIsNothing, // right child position
+			1,             // height
+			NodeIsNothing, // parent position
+			1,             // node count
+			0,             // key position
+			3,             // key length
+			3,             // value position
+			5,             // value length
+		},
+		Arena: []byte("keyvalue"),
+	}
+
+	if !reflect.DeepEqual(tree, want) {
+		t.Fatalf("want %#v but %#v", want, tree)
+	}
+}
+
+func TestInsertAndIterate(t *testing.T) {
+	f := func(listBase []keyAndValue) [][]byte {
+		list := omitDuplicates(listBase)
+		tree := New(false)
+		for _, kv := range list {
+			avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	g := func(listBase []keyAndValue) [][]byte {
+		list := omitDuplicates(listBase)
+		return toKeyValues(toAscSorted(list))
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	f := func(listBase []keyAndValue, missingKey []byte) bool {
+		list := omitDuplicates(listBase)
+		tree := New(false)
+		for _, kv := range list {
+			avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+		}
+		missing := true
+		for _, kv := range list {
+			value, ok := tree.Find(kv.Key)
+			if !ok || !bytes.Equal(value, kv.Value) {
+				return false
+			}
+			missing = missing && !bytes.Equal(kv.Key, missingKey)
+		}
+		if missing {
+			if _, ok := tree.Find(missingKey); ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRangeIterate(t *testing.T) {
+	for _, allowDuplicateKeys := range []bool{false, true} {
+		for _, descOrder := range []bool{false, true} {
+			f := func(listBase []keyAndValue, lower, upper []byte, useLower, useUpper bool) [][]byte {
+				if !useLower {
+					lower = nil
+				} else if lower == nil {
+					lower = []byte{}
+				}
+				if !useUpper {
+					upper = nil
+				} else if upper == nil {
+					upper = []byte{}
+				}
+				tree := New(allowDuplicateKeys)
+				for _, kv := range listBase {
+					avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+				}
+				var result [][]byte
+				tree.RangeIterate(descOrder, lower, upper, func(key, value []byte) (breakIteration bool) {
+					result = append(result, key, value)
+					return
+				})
+				return result
+			}
+
+			g := func(listBase []keyAndValue, lower, upper []byte, useLower, useUpper bool) [][]byte {
+				if !useLower {
+					lower = nil
+				} else if lower == nil {
+					lower = []byte{}
+				}
+				if !useUpper {
+					upper = nil
+				} else if upper == nil {
+					upper = []byte{}
+				}
+				var list []*keyAndValue
+				if allowDuplicateKeys {
+					for i := range listBase {
+						list = append(list, &listBase[i])
+					}
+				} else {
+					list = omitDuplicates(listBase)
+				}
+				list = toAscSorted(list)
+				var result [][]byte
+				if descOrder {
+					for i := len(list) - 1; i >= 0; i-- {
+						if inRange(list[i].Key, lower, upper) {
+							result = append(result, list[i].Key, list[i].Value)
+						}
+					}
+				} else {
+					for _, kv := range list {
+						if inRange(kv.Key, lower, upper) {
+							result = append(result, kv.Key, kv.Value)
+						}
+					}
+				}
+				return result
+			}
+
+			if allowDuplicateKeys {
+				// 同一キーの値の順序は挿入順に依らないので値は比較しない
+				f0, g0 := f, g
+				f = func(listBase []keyAndValue, lower, upper []byte, useLower, useUpper bool) [][]byte {
+					return keysOnly(f0(listBase, lower, upper, useLower, useUpper))
+				}
+				g = func(listBase []keyAndValue, lower, upper []byte, useLower, useUpper bool) [][]byte {
+					return keysOnly(g0(listBase, lower, upper, useLower, useUpper))
+				}
+			}
+
+			if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+				t.Fatal(allowDuplicateKeys, descOrder, err)
+			}
+		}
+	}
+}
+
+func keysOnly(keyValues [][]byte) (result [][]byte) {
+	for i := 0; i < len(keyValues); i += 2 {
+		result = append(result, keyValues[i])
+	}
+	return
+}
+
+func TestRangeIterateBreak(t *testing.T) {
+	tree := New(false)
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		avltree.Insert(tree, false, BytesKey(k), []byte(k))
+	}
+	var result []string
+	tree.RangeIterate(false, []byte("b"), nil, func(key, value []byte) (breakIteration bool) {
+		result = append(result, string(key))
+		return len(result) == 2
+	})
+	if !reflect.DeepEqual(result, []string{"b", "c"}) {
+		t.Fatal(result)
+	}
+}
+
+func TestDeleteAndCompact(t *testing.T) {
+	f := func(listBase []keyAndValue) bool {
+		list := omitDuplicates(listBase)
+		tree := New(false)
+		for _, kv := range list {
+			avltree.Insert(tree, false, BytesKey(kv.Key), kv.Value)
+		}
+		garbage := 0
+		rest := []*keyAndValue{}
+		for i, kv := range list {
+			switch i % 3 {
+			case 0:
+				avltree.Delete(tree, BytesKey(kv.Key))
+				garbage += len(kv.Key) + len(kv.Value)
+			case 1:
+				newValue := append(kv.Value, 'x')
+				avltree.Replace(tree, BytesKey(kv.Key), newValue)
+				garbage += len(kv.Value)
+				rest = append(rest, &keyAndValue{kv.Key, newValue})
+			default:
+				rest = append(rest, kv)
+			}
+		}
+		if tree.GarbageSize() != garbage {
+			return false
+		}
+		want := toKeyValues(toAscSorted(rest))
+		oldArena := tree.Arena
+		if !reflect.DeepEqual(getAllAscKeyAndValues(tree), want) {
+			return false
+		}
+		tree.Compact()
+		if tree.GarbageSize() != 0 || len(tree.Arena) != len(oldArena)-garbage {
+			return false
+		}
+		return reflect.DeepEqual(getAllAscKeyAndValues(tree), want)
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExposedSlicesAreNotOverwritten(t *testing.T) {
+	tree := New(false)
+	avltree.Insert(tree, false, BytesKey("a"), []byte("first"))
+	value, _ := tree.Find([]byte("a"))
+	avltree.Replace(tree, BytesKey("a"), []byte("second"))
+	tree.Compact()
+	avltree.Insert(tree, false, BytesKey("b"), []byte("third"))
+	avltree.Clear(tree)
+	avltree.Insert(tree, false, BytesKey("c"), []byte("fourth"))
+	if string(value) != "first" {
+		t.Fatalf("want first but %s", value)
+	}
+
+	// 渡したスライスにappendしてもアリーナは壊れない
+	value, _ = tree.Find([]byte("c"))
+	_ = append(value, "!!!!!!!!"...)
+	avltree.Insert(tree, false, BytesKey("d"), []byte("fifth"))
+	if value, _ := tree.Find([]byte("d")); string(value) != "fifth" {
+		t.Fatalf("want fifth but %s", value)
+	}
+
+	// キーはCopyメソッドでは複製されないが、NewNodeでアリーナに複製される
+	key := BytesKey("e")
+	avltree.Insert(tree, false, key, []byte("sixth"))
+	key[0] = 'z'
+	if value, ok := tree.Find([]byte("e")); !ok || string(value) != "sixth" {
+		t.Fatalf("want sixth but %s", value)
+	}
+}
+
+func TestZeroAllocation(t *testing.T) {
+	tree := New(true)
+	for _, k := range []string{"apple", "banana", "cherry", "durian", "elderberry", "fig", "grape"} {
+		avltree.Insert(tree, false, BytesKey(k), []byte(k))
+	}
+	key := []byte("durian")
+	lower := []byte("b")
+	upper := []byte("f")
+	count := 0
+	callBack := func(key, value []byte) (breakIteration bool) {
+		count += len(value)
+		return
+	}
+	if allocs := testing.AllocsPerRun(100, func() { tree.Find(key) }); allocs != 0 {
+		t.Fatalf("Find allocates %v", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { tree.RangeIterate(false, lower, upper, callBack) }); allocs != 0 {
+		t.Fatalf("RangeIterate allocates %v", allocs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { tree.RangeIterate(true, nil, nil, callBack) }); allocs != 0 {
+		t.Fatalf("RangeIterate allocates %v", allocs)
+	}
+}
+
+func TestWrongTypesPanic(t *testing.T) {
+	tree := New(false)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("no panic on wrong value type")
+			}
+		}()
+		avltree.Insert(tree, false, BytesKey("a"), "string value")
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("no panic on wrong key type")
+			}
+		}()
+		avltree.Insert(tree, false, Key(nil), []byte("value"))
+	}()
+}
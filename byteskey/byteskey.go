@@ -0,0 +1,33 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// []byte型をそのままキーにしてある
+// 標準パッケージのbytes.Compareの結果(辞書順)をそのままキーの比較の値として使っている
+//
+// []byte型は可変なのでCopyメソッドでは中身を複製した新しいスライスを返す
+//
+// BytesPrefixは指定のバイト列で始まる全てのキーを範囲とする下限と上限を返す
+// PrefixIterate,PrefixCount,PrefixDeleteはその範囲でノードを巡ったり数えたり削除したりする
+package byteskey
+
+import (
+	"bytes"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type BytesKey []byte
+
+func (key BytesKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	if bound, ok := other.(*prefixBound); ok {
+		return -bound.CompareTo(key)
+	}
+	return avltree.KeyOrdering(bytes.Compare(key, other.(BytesKey)))
+}
+
+func (key BytesKey) Copy() avltree.Key {
+	newKey := make(BytesKey, len(key))
+	copy(newKey, key)
+	return newKey
+}
@@ -0,0 +1,81 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package byteskey
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+func TestBytesKey(t *testing.T) {
+	f := func(k1, k2 []byte) bool {
+		var key1 avltree.Key = BytesKey(k1)
+		var key2 avltree.Key = BytesKey(k2)
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return bytes.Compare(k1, k2) < 0
+		case avltree.EqualToOtherKey:
+			return bytes.Equal(k1, k2)
+		case avltree.GreaterThanOtherKey:
+			return bytes.Compare(k1, k2) > 0
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBytesKeyCopy(t *testing.T) {
+	f := func(k []byte) bool {
+		key := BytesKey(k)
+		copied := key.Copy().(BytesKey)
+		if !bytes.Equal(key, copied) {
+			return false
+		}
+		if len(k) == 0 {
+			return true
+		}
+		k[0]++
+		return !bytes.Equal(key, copied)
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	avltree.Insert(tree, false, BytesKey("banana"), 345)
+	avltree.Insert(tree, false, BytesKey("cherry"), 890)
+	avltree.Insert(tree, false, BytesKey("durian"), 666)
+	avltree.Insert(tree, false, BytesKey("apple"), 12345)
+	avltree.Delete(tree, BytesKey("cherry"))
+	avltree.Update(tree, BytesKey("durian"), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) * 3
+		return
+	})
+	if node := avltree.Find(tree, BytesKey("banana")); node != nil {
+		fmt.Printf("Find! %s %v\n", node.Key(), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Printf("Iterate! %s %v\n", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! banana 345
+	// Iterate! apple 12345
+	// Iterate! banana 345
+	// Iterate! durian 1998
+}
@@ -0,0 +1,67 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package byteskey
+
+import (
+	"bytes"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 前方一致の範囲の下限または上限
+// prefixで始まる全てのキーより小さい(大きい)ものとして比較される
+// 範囲の指定にのみ使い、木のキーにしたり下限と上限どうしを比較したりしてはならない
+type prefixBound struct {
+	prefix []byte
+
+	// prefixで始まるキーと比較したときの結果
+	ordering avltree.KeyOrdering
+}
+
+// prefixで始まる全てのBytesKeyを範囲とする下限と上限を返す
+func BytesPrefix(prefix []byte) (lower, upper avltree.Key) {
+	prefix = append([]byte(nil), prefix...)
+	lower = &prefixBound{prefix, avltree.LessThanOtherKey}
+	upper = &prefixBound{prefix, avltree.GreaterThanOtherKey}
+	return
+}
+
+func (bound *prefixBound) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	key := other.(BytesKey)
+	if len(key) < len(bound.prefix) {
+		if c := bytes.Compare(bound.prefix[:len(key)], key); c != 0 {
+			return avltree.KeyOrdering(c)
+		}
+		// keyはprefixの先頭の一部なのでprefixで始まるキーより小さい
+		return avltree.GreaterThanOtherKey
+	}
+	if c := bytes.Compare(bound.prefix, key[:len(bound.prefix)]); c != 0 {
+		return avltree.KeyOrdering(c)
+	}
+	return bound.ordering
+}
+
+func (bound *prefixBound) Copy() avltree.Key {
+	return bound
+}
+
+// BytesKeyをキーとする木でprefixで始まるキーを持つノードを昇順に巡ってコールバックを呼び出す
+// 範囲はBytesPrefixで求める
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func PrefixIterate(tree avltree.Tree, prefix []byte, callBack avltree.IterateCallBack) (ok bool) {
+	lower, upper := BytesPrefix(prefix)
+	return avltree.RangeIterate(tree, false, lower, upper, callBack)
+}
+
+// BytesKeyをキーとする木でprefixで始まるキーを持つノードの総数を求める
+func PrefixCount(tree avltree.Tree, prefix []byte) int {
+	lower, upper := BytesPrefix(prefix)
+	return avltree.CountRange(tree, lower, upper)
+}
+
+// BytesKeyをキーとする木でprefixで始まるキーを持つノード全てを削除する
+func PrefixDelete(tree avltree.Tree, prefix []byte) (modified avltree.Tree, values []avltree.KeyAndValue) {
+	lower, upper := BytesPrefix(prefix)
+	return avltree.DeleteRange(tree, false, lower, upper)
+}
@@ -0,0 +1,78 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package byteskey
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// 0xFFのバイトの多い短いバイト列にする
+func toBytes(b []byte) []byte {
+	const letters = "\x00\x01a\xfe\xff\xff"
+	s := make([]byte, len(b)%6)
+	for i := range s {
+		s[i] = letters[b[i]%uint8(len(letters))]
+	}
+	return s
+}
+
+func TestPrefixScan(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(list [][]byte, p []byte) bool {
+			tree := simpletree.New(dup)
+			for _, b := range list {
+				avltree.Insert(tree, false, BytesKey(toBytes(b)), len(b))
+			}
+			prefix := toBytes(p)
+			prefix = prefix[:len(prefix)/2]
+			var want, rest [][]byte
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				if key := node.Key().(BytesKey); bytes.HasPrefix(key, prefix) {
+					want = append(want, key)
+				} else {
+					rest = append(rest, key)
+				}
+				return
+			})
+			var got [][]byte
+			ok := PrefixIterate(tree, prefix, func(node avltree.Node) (breakIteration bool) {
+				got = append(got, node.Key().(BytesKey))
+				return
+			})
+			if !ok || !reflect.DeepEqual(got, want) || PrefixCount(tree, prefix) != len(want) {
+				return false
+			}
+			if len(want) > 0 {
+				// 中断を要求した場合はfalseを返す
+				got = nil
+				ok = PrefixIterate(tree, prefix, func(node avltree.Node) (breakIteration bool) {
+					got = append(got, node.Key().(BytesKey))
+					return true
+				})
+				if ok || !reflect.DeepEqual(got, want[:1]) {
+					return false
+				}
+			}
+			_, values := PrefixDelete(tree, prefix)
+			var deleted, remaining [][]byte
+			for _, kv := range values {
+				deleted = append(deleted, kv.Key().(BytesKey))
+			}
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				remaining = append(remaining, node.Key().(BytesKey))
+				return
+			})
+			return reflect.DeepEqual(deleted, want) && reflect.DeepEqual(remaining, rest) && PrefixCount(tree, prefix) == 0
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの木のキーと値をバイト列に変換するためのインターフェースと実装例
+//
+// walなど木の内容をファイルや通信に書き出すパッケージで使用する
+// KeyCodecとValueCodecを組み合わせてCodecを作る
+//
+// 整数のキーは符号ビットを反転したビッグエンディアンで表すため、変換後のバイト列の辞書順とキーの順序が一致する
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree/codec"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//		)
+//		func Example_codec() {
+//			c := codec.New(codec.IntKey, codec.StringValue)
+//			data, _ := c.EncodeKey(IntKey(-2))
+//			fmt.Printf("EncodeKey! %x\n", data)
+//			key, _ := c.DecodeKey(data)
+//			fmt.Println("DecodeKey!", key)
+//			data, _ = c.EncodeValue("hello")
+//			value, _ := c.DecodeValue(data)
+//			fmt.Println("DecodeValue!", value)
+//			_, err := c.DecodeKey([]byte{1, 2, 3})
+//			fmt.Println("Error!", err)
+//			// Output:
+//			// EncodeKey! 7ffffffffffffffe
+//			// DecodeKey! -2
+//			// DecodeValue! hello
+//			// Error! codec: invalid data length 3 (expected 8)
+//		}
+//
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+	"github.com/neetsdkasu/avltree/int64key"
+	"github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/stringkey"
+	"github.com/neetsdkasu/avltree/uint64key"
+)
+
+// キーとバイト列を相互に変換する
+// EncodeKeyの戻り値のバイト列はDecodeKeyの引数以外で保持されることがあるため、呼び出し後に書き換えてはならない
+type KeyCodec interface {
+	EncodeKey(key avltree.Key) (data []byte, err error)
+	DecodeKey(data []byte) (key avltree.Key, err error)
+}
+
+// 値とバイト列を相互に変換する
+type ValueCodec interface {
+	EncodeValue(value interface{}) (data []byte, err error)
+	DecodeValue(data []byte) (value interface{}, err error)
+}
+
+type Codec interface {
+	KeyCodec
+	ValueCodec
+}
+
+type codec struct {
+	KeyCodec
+	ValueCodec
+}
+
+// keyCodecとvalueCodecを組み合わせたCodecを返す
+func New(keyCodec KeyCodec, valueCodec ValueCodec) Codec {
+	return &codec{keyCodec, valueCodec}
+}
+
+// 関数の組でKeyCodecを実装する
+type KeyFuncs struct {
+	Encode func(key avltree.Key) (data []byte, err error)
+	Decode func(data []byte) (key avltree.Key, err error)
+}
+
+// 関数の組でValueCodecを実装する
+type ValueFuncs struct {
+	Encode func(value interface{}) (data []byte, err error)
+	Decode func(data []byte) (value interface{}, err error)
+}
+
+func (funcs *KeyFuncs) EncodeKey(key avltree.Key) ([]byte, error) {
+	return funcs.Encode(key)
+}
+
+func (funcs *KeyFuncs) DecodeKey(data []byte) (avltree.Key, error) {
+	return funcs.Decode(data)
+}
+
+func (funcs *ValueFuncs) EncodeValue(value interface{}) ([]byte, error) {
+	return funcs.Encode(value)
+}
+
+func (funcs *ValueFuncs) DecodeValue(data []byte) (interface{}, error) {
+	return funcs.Decode(data)
+}
+
+func checkLength(data []byte, length int) error {
+	if len(data) != length {
+		return fmt.Errorf("codec: invalid data length %d (expected %d)", len(data), length)
+	}
+	return nil
+}
+
+func typeError(expected string, actual interface{}) error {
+	return fmt.Errorf("codec: %T is not %s", actual, expected)
+}
+
+const signBit = uint64(1) << 63
+
+func encodeUint64(x uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, x)
+	return data
+}
+
+func decodeUint64(data []byte) (uint64, error) {
+	if err := checkLength(data, 8); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// intkey.IntKeyを8バイトで表す
+var IntKey KeyCodec = &KeyFuncs{
+	Encode: func(key avltree.Key) ([]byte, error) {
+		k, ok := key.(intkey.IntKey)
+		if !ok {
+			return nil, typeError("intkey.IntKey", key)
+		}
+		return encodeUint64(uint64(k) ^ signBit), nil
+	},
+	Decode: func(data []byte) (avltree.Key, error) {
+		x, err := decodeUint64(data)
+		if err != nil {
+			return nil, err
+		}
+		return intkey.IntKey(int64(x ^ signBit)), nil
+	},
+}
+
+// int64key.Int64Keyを8バイトで表す
+var Int64Key KeyCodec = &KeyFuncs{
+	Encode: func(key avltree.Key) ([]byte, error) {
+		k, ok := key.(int64key.Int64Key)
+		if !ok {
+			return nil, typeError("int64key.Int64Key", key)
+		}
+		return encodeUint64(uint64(k) ^ signBit), nil
+	},
+	Decode: func(data []byte) (avltree.Key, error) {
+		x, err := decodeUint64(data)
+		if err != nil {
+			return nil, err
+		}
+		return int64key.Int64Key(int64(x ^ signBit)), nil
+	},
+}
+
+// uint64key.Uint64Keyを8バイトで表す
+var Uint64Key KeyCodec = &KeyFuncs{
+	Encode: func(key avltree.Key) ([]byte, error) {
+		k, ok := key.(uint64key.Uint64Key)
+		if !ok {
+			return nil, typeError("uint64key.Uint64Key", key)
+		}
+		return encodeUint64(uint64(k)), nil
+	},
+	Decode: func(data []byte) (avltree.Key, error) {
+		x, err := decodeUint64(data)
+		if err != nil {
+			return nil, err
+		}
+		return uint64key.Uint64Key(x), nil
+	},
+}
+
+// stringkey.StringKeyをそのままのバイト列で表す
+var StringKey KeyCodec = &KeyFuncs{
+	Encode: func(key avltree.Key) ([]byte, error) {
+		k, ok := key.(stringkey.StringKey)
+		if !ok {
+			return nil, typeError("stringkey.StringKey", key)
+		}
+		return []byte(k), nil
+	},
+	Decode: func(data []byte) (avltree.Key, error) {
+		return stringkey.StringKey(data), nil
+	},
+}
+
+// byteskey.BytesKeyをそのままのバイト列で表す
+// DecodeKeyは引数のバイト列の複製を持つキーを返す
+var BytesKey KeyCodec = &KeyFuncs{
+	Encode: func(key avltree.Key) ([]byte, error) {
+		k, ok := key.(byteskey.BytesKey)
+		if !ok {
+			return nil, typeError("byteskey.BytesKey", key)
+		}
+		return []byte(k), nil
+	},
+	Decode: func(data []byte) (avltree.Key, error) {
+		return byteskey.BytesKey(data).Copy(), nil
+	},
+}
+
+// int型の値を8バイトで表す
+var IntValue ValueCodec = &ValueFuncs{
+	Encode: func(value interface{}) ([]byte, error) {
+		v, ok := value.(int)
+		if !ok {
+			return nil, typeError("int", value)
+		}
+		return encodeUint64(uint64(v)), nil
+	},
+	Decode: func(data []byte) (interface{}, error) {
+		x, err := decodeUint64(data)
+		if err != nil {
+			return nil, err
+		}
+		return int(int64(x)), nil
+	},
+}
+
+// string型の値をそのままのバイト列で表す
+var StringValue ValueCodec = &ValueFuncs{
+	Encode: func(value interface{}) ([]byte, error) {
+		v, ok := value.(string)
+		if !ok {
+			return nil, typeError("string", value)
+		}
+		return []byte(v), nil
+	},
+	Decode: func(data []byte) (interface{}, error) {
+		return string(data), nil
+	},
+}
+
+// []byte型の値をそのままのバイト列で表す
+// DecodeValueは引数のバイト列の複製を返す
+var BytesValue ValueCodec = &ValueFuncs{
+	Encode: func(value interface{}) ([]byte, error) {
+		v, ok := value.([]byte)
+		if !ok {
+			return nil, typeError("[]byte", value)
+		}
+		return v, nil
+	},
+	Decode: func(data []byte) (interface{}, error) {
+		return append([]byte{}, data...), nil
+	},
+}
+
+// 任意の値をencoding/gobで表す
+// 値の具体的な型はgob.Registerで登録しておく必要がある
+// nilの値は扱えない
+var GobValue ValueCodec = &ValueFuncs{
+	Encode: func(value interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+	Decode: func(data []byte) (interface{}, error) {
+		var value interface{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	},
+}
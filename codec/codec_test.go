@@ -0,0 +1,123 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+	"github.com/neetsdkasu/avltree/int64key"
+	"github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/stringkey"
+	"github.com/neetsdkasu/avltree/uint64key"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+func roundTripKey(t *testing.T, c KeyCodec, key avltree.Key) []byte {
+	data, err := c.EncodeKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := c.DecodeKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.CompareTo(key).EqualTo() {
+		t.Fatal("wrong key", key, decoded)
+	}
+	return data
+}
+
+// 変換後のバイト列の辞書順とキーの順序が一致する
+func checkOrder(t *testing.T, c KeyCodec, key1, key2 avltree.Key) bool {
+	data1 := roundTripKey(t, c, key1)
+	data2 := roundTripKey(t, c, key2)
+	return bytes.Compare(data1, data2) == int(key1.CompareTo(key2))
+}
+
+func TestKeyCodecs(t *testing.T) {
+	checks := map[string]interface{}{
+		"IntKey": func(k1, k2 int) bool {
+			return checkOrder(t, IntKey, intkey.IntKey(k1), intkey.IntKey(k2))
+		},
+		"Int64Key": func(k1, k2 int64) bool {
+			return checkOrder(t, Int64Key, int64key.Int64Key(k1), int64key.Int64Key(k2))
+		},
+		"Uint64Key": func(k1, k2 uint64) bool {
+			return checkOrder(t, Uint64Key, uint64key.Uint64Key(k1), uint64key.Uint64Key(k2))
+		},
+		"StringKey": func(k1, k2 string) bool {
+			return checkOrder(t, StringKey, stringkey.StringKey(k1), stringkey.StringKey(k2))
+		},
+		"BytesKey": func(k1, k2 []byte) bool {
+			return checkOrder(t, BytesKey, byteskey.BytesKey(k1), byteskey.BytesKey(k2))
+		},
+	}
+	for name, f := range checks {
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+func TestValueCodecs(t *testing.T) {
+	type point struct{ X, Y int }
+	values := []struct {
+		codec ValueCodec
+		value interface{}
+	}{
+		{IntValue, -12345},
+		{StringValue, "hello"},
+		{BytesValue, []byte{1, 2, 3}},
+		{GobValue, 42},
+		{GobValue, "gob"},
+		{GobValue, []string{"a", "b"}},
+	}
+	for _, v := range values {
+		data, err := v.codec.EncodeValue(v.value)
+		if err != nil {
+			t.Fatal(v.value, err)
+		}
+		decoded, err := v.codec.DecodeValue(data)
+		if err != nil {
+			t.Fatal(v.value, err)
+		}
+		if !reflect.DeepEqual(decoded, v.value) {
+			t.Fatal("wrong value", v.value, decoded)
+		}
+	}
+	if _, err := GobValue.EncodeValue(point{1, 2}); err == nil {
+		t.Fatal("unregistered type is encoded")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := New(IntKey, IntValue)
+	if _, err := c.EncodeKey(stringkey.StringKey("x")); err == nil {
+		t.Fatal("wrong key type is encoded")
+	}
+	if _, err := c.EncodeValue("x"); err == nil {
+		t.Fatal("wrong value type is encoded")
+	}
+	if _, err := c.DecodeKey([]byte{1}); err == nil {
+		t.Fatal("short data is decoded")
+	}
+	if _, err := c.DecodeValue(make([]byte, 9)); err == nil {
+		t.Fatal("long data is decoded")
+	}
+}
+
+func TestBytesKeyIsCopied(t *testing.T) {
+	data := []byte{1, 2, 3}
+	key, _ := BytesKey.DecodeKey(data)
+	data[0] = 9
+	if key.(byteskey.BytesKey)[0] != 1 {
+		t.Fatal("key shares data")
+	}
+}
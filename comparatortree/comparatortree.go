@@ -0,0 +1,186 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの任意の木に比較関数を持たせるラッパーの実装例
+//
+// avltree.KeyComparatorを実装しているため、avltreeの関数はキーのCompareToメソッドではなく比較関数でキーを比較する
+// キーの型ごとにCompareToメソッドとCopyメソッドを実装しなくても、int型やstring型や構造体などの値をKeyでそのままキーにできる
+// Keyで包んだ値は比較関数に渡される前に取り出される(Keyで包まずにavltree.Keyの実装を直接キーにした場合はそのまま比較関数に渡される)
+// ノードのキーから値を取り出すにはValueを使う
+//
+// 比較関数は異なる型の値どうしの比較にも使えるので、型ごとの順序を決めておけば異なる型の値を同じ木のキーにできる
+// 既存のavltree.Keyの実装の順序を使う場合はByKeyやByKeyOfを比較関数にする
+//
+// Keyで包んだ値はCopyメソッドで複製されないため、可変な値をキーにする場合は利用者側で不変性を確保する必要がある
+// observabletreeなどのラッパーでラップする場合はこの木を内側にする(ラッパー側がavltree.KeyComparatorを委譲する)
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"strings"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/comparatortree"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_comparatortree() {
+//			// 大文字と小文字を区別しない
+//			tree := comparatortree.New(simpletree.New(false), func(a, b interface{}) int {
+//				return strings.Compare(strings.ToLower(a.(string)), strings.ToLower(b.(string)))
+//			})
+//			tree, _ = avltree.Insert(tree, false, comparatortree.Key("banana"), 1)
+//			tree, _ = avltree.Insert(tree, false, comparatortree.Key("Apple"), 2)
+//			tree, _ = avltree.Insert(tree, false, comparatortree.Key("cherry"), 3)
+//			tree, _ = avltree.Insert(tree, false, comparatortree.Key("APPLE"), 4)
+//			if node := avltree.Find(tree, comparatortree.Key("BANANA")); node != nil {
+//				fmt.Println("Find!", comparatortree.Value(node.Key()), node.Value())
+//			}
+//			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Iterate!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Find! banana 1
+//			// Iterate! Apple 2
+//			// Iterate! banana 1
+//			// Iterate! cherry 3
+//		}
+//
+package comparatortree
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// aがbより小さい場合は負の値、等しい場合は0、大きい場合は正の値を返す
+type Comparator = func(a, b interface{}) int
+
+// 任意の値を比較関数で比較するキーとして木に渡すためのavltree.Keyの実装
+type PlainKey struct {
+	Value interface{}
+}
+
+type ComparatorTree struct {
+	Inner      avltree.RealTree
+	Comparator Comparator
+}
+
+// treeをラップし、キーをcomparatorで比較する木を生成する
+func New(tree avltree.Tree, comparator Comparator) avltree.Tree {
+	return &ComparatorTree{
+		Inner:      tree.(avltree.RealTree),
+		Comparator: comparator,
+	}
+}
+
+// 値をキーとして木に渡すために包む
+func Key(value interface{}) avltree.Key {
+	return PlainKey{value}
+}
+
+// Keyで包んだ値を取り出す
+// Keyで包んでいないキーはそのまま返す
+func Value(key avltree.Key) interface{} {
+	if plain, ok := key.(PlainKey); ok {
+		return plain.Value
+	}
+	return key
+}
+
+// 比較関数を持つ木でのみ比較できるため、CompareToメソッドを直接呼び出すとpanicする
+func (key PlainKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	panic("comparatortree: PlainKey can only be compared by ComparatorTree")
+}
+
+// 値は複製しない
+func (key PlainKey) Copy() avltree.Key {
+	return key
+}
+
+func (key PlainKey) String() string {
+	return fmt.Sprint(key.Value)
+}
+
+func (tree *ComparatorTree) CompareKeys(key1, key2 avltree.Key) avltree.KeyOrdering {
+	switch cmp := tree.Comparator(Value(key1), Value(key2)); {
+	case cmp < 0:
+		return avltree.LessThanOtherKey
+	case cmp > 0:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (tree *ComparatorTree) Root() avltree.Node {
+	return tree.Inner.Root()
+}
+
+func (tree *ComparatorTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	return tree.Inner.NewNode(leftChild, rightChild, height, key, value)
+}
+
+// ラップしている木のSetRootが新しい木を返した場合は同じ比較関数を持つ新しいComparatorTreeでラップして返す
+func (tree *ComparatorTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	newTree := tree.Inner.SetRoot(newRoot)
+	if newTree == tree.Inner {
+		return tree
+	}
+	return &ComparatorTree{
+		Inner:      newTree,
+		Comparator: tree.Comparator,
+	}
+}
+
+func (tree *ComparatorTree) AllowDuplicateKeys() bool {
+	return tree.Inner.AllowDuplicateKeys()
+}
+
+func (tree *ComparatorTree) NodeCount() int {
+	return avltree.Count(tree.Inner)
+}
+
+// ラップしている木がavltree.NodeReleaserを実装している場合のみ処理を委譲する
+func (tree *ComparatorTree) ReleaseNode(node avltree.RealNode) {
+	if releaser, ok := tree.Inner.(avltree.NodeReleaser); ok {
+		releaser.ReleaseNode(node)
+	}
+}
+
+// ラップしている木がavltree.TreeCleanerを実装している場合のみ処理を委譲する
+func (tree *ComparatorTree) CleanUpTree() {
+	if cleaner, ok := tree.Inner.(avltree.TreeCleaner); ok {
+		cleaner.CleanUpTree()
+	}
+}
+
+// ラップしている木がavltree.TreeReleaserを実装している場合のみ処理を委譲する
+func (tree *ComparatorTree) ReleaseTree() {
+	if releaser, ok := tree.Inner.(avltree.TreeReleaser); ok {
+		releaser.ReleaseTree()
+	}
+}
+
+// 値がavltree.Keyの実装である場合にそのCompareToメソッドの順序で比較する
+// KeyでキーをPlainKeyに包まずにavltree.Keyの実装を直接キーにする場合に使う
+func ByKey(a, b interface{}) int {
+	return int(a.(avltree.Key).CompareTo(b.(avltree.Key)))
+}
+
+// 値をconvertでavltree.Keyの実装に変換し、そのCompareToメソッドの順序で比較する比較関数を返す
+// 例えばint型の値をintkey.IntKeyの順序で比較する場合は
+// ByKeyOf(func(v interface{}) avltree.Key { return intkey.IntKey(v.(int)) })
+func ByKeyOf(convert func(value interface{}) avltree.Key) Comparator {
+	return func(a, b interface{}) int {
+		return int(convert(a).CompareTo(convert(b)))
+	}
+}
+
+// 逆順で比較する比較関数を返す
+func Reverse(comparator Comparator) Comparator {
+	return func(a, b interface{}) int {
+		return comparator(b, a)
+	}
+}
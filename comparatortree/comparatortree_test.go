@@ -0,0 +1,216 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package comparatortree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/observabletree"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/standardtree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type operation struct {
+	Kind  uint8
+	Key   int8
+	Upper int8
+	Value int
+}
+
+var treeMakers = map[string]func(dup bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"standardtree":  standardtree.New,
+	"immutabletree": immutabletree.New,
+}
+
+func intComparator(a, b interface{}) int {
+	return a.(int) - b.(int)
+}
+
+// 木の内容をキーと値の並びにする
+func toList(tree avltree.Tree, descOrder bool, lower, upper avltree.Key) (list []int) {
+	avltree.RangeIterate(tree, descOrder, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		switch key := Value(node.Key()).(type) {
+		case int:
+			list = append(list, key)
+		case IntKey:
+			list = append(list, int(key))
+		}
+		list = append(list, node.Value().(int))
+		return
+	})
+	return
+}
+
+func listOf(kvs []avltree.KeyAndValue) (list []int) {
+	for _, kv := range kvs {
+		switch key := Value(kv.Key()).(type) {
+		case int:
+			list = append(list, key)
+		case IntKey:
+			list = append(list, int(key))
+		}
+		list = append(list, kv.Value().(int))
+	}
+	return
+}
+
+// IntKeyを使った木と同じ操作をして同じ結果になる
+func TestSameAsKeyTree(t *testing.T) {
+	for name, newTree := range treeMakers {
+		for _, dup := range []bool{false, true} {
+			f := func(ops []operation) bool {
+				tree := New(newTree(dup), intComparator)
+				model := newTree(dup)
+				for _, op := range ops {
+					k, u := int(op.Key%16), int(op.Upper%16)
+					if u < k {
+						k, u = u, k
+					}
+					key, modelKey := Key(k), IntKey(k)
+					upper, modelUpper := Key(u), IntKey(u)
+					var ok1, ok2 bool
+					var list1, list2 []int
+					switch op.Kind % 8 {
+					case 0, 1:
+						tree, ok1 = avltree.Insert(tree, op.Kind%8 == 1, key, op.Value)
+						model, ok2 = avltree.Insert(model, op.Kind%8 == 1, modelKey, op.Value)
+					case 2:
+						var kv1, kv2 avltree.KeyAndValue
+						tree, kv1 = avltree.Delete(tree, key)
+						model, kv2 = avltree.Delete(model, modelKey)
+						ok1, ok2 = kv1 != nil, kv2 != nil
+					case 3:
+						tree, ok1 = avltree.Replace(tree, key, op.Value)
+						model, ok2 = avltree.Replace(model, modelKey, op.Value)
+					case 4:
+						var kvs1, kvs2 []avltree.KeyAndValue
+						tree, kvs1 = avltree.DeleteRange(tree, op.Value%2 == 0, key, upper)
+						model, kvs2 = avltree.DeleteRange(model, op.Value%2 == 0, modelKey, modelUpper)
+						list1, list2 = listOf(kvs1), listOf(kvs2)
+					case 5:
+						alter := func(node avltree.AlterNode) (request avltree.AlterRequest) {
+							if node.Value().(int)%2 == 0 {
+								return node.Delete()
+							}
+							return node.Replace(op.Value)
+						}
+						var kvs1, kvs2 []avltree.KeyAndValue
+						tree, kvs1, ok1 = avltree.AlterRange(tree, false, key, upper, alter)
+						model, kvs2, ok2 = avltree.AlterRange(model, false, modelKey, modelUpper, alter)
+						list1, list2 = listOf(kvs1), listOf(kvs2)
+					case 6:
+						ok1 = avltree.Find(tree, key) != nil
+						ok2 = avltree.Find(model, modelKey) != nil
+						if avltree.CountRange(tree, key, upper) != avltree.CountRange(model, modelKey, modelUpper) {
+							return false
+						}
+						list1, list2 = toList(tree, true, key, upper), toList(model, true, modelKey, modelUpper)
+					case 7:
+						list1 = toList(tree, false, nil, upper)
+						list2 = toList(model, false, nil, modelUpper)
+						if len(avltree.FindAll(tree, key)) != len(avltree.FindAll(model, modelKey)) {
+							return false
+						}
+					}
+					if ok1 != ok2 || !reflect.DeepEqual(list1, list2) {
+						return false
+					}
+				}
+				return reflect.DeepEqual(toList(tree, false, nil, nil), toList(model, false, nil, nil))
+			}
+			if err := quick.Check(f, cfg1000); err != nil {
+				t.Fatal(name, dup, err)
+			}
+		}
+	}
+}
+
+// 型ごとの順序を決めれば異なる型の値を同じ木のキーにできる
+func TestMixedTypes(t *testing.T) {
+	// 数は文字列より小さい
+	comparator := func(a, b interface{}) int {
+		switch a := a.(type) {
+		case int:
+			if b, ok := b.(int); ok {
+				return a - b
+			}
+			return -1
+		case string:
+			if b, ok := b.(string); ok {
+				return strings.Compare(a, b)
+			}
+			return 1
+		}
+		panic("unknown type")
+	}
+	tree := New(simpletree.New(false), comparator)
+	for _, v := range []interface{}{"b", 3, "a", 1, 2} {
+		tree, _ = avltree.Insert(tree, false, Key(v), 0)
+	}
+	var keys []interface{}
+	avltree.RangeIterate(tree, false, Key(2), Key("a"), func(node avltree.Node) (breakIteration bool) {
+		keys = append(keys, Value(node.Key()))
+		return
+	})
+	if !reflect.DeepEqual(keys, []interface{}{2, 3, "a"}) {
+		t.Fatal("wrong order", keys)
+	}
+}
+
+func TestAdapters(t *testing.T) {
+	tree := New(simpletree.New(false), Reverse(ByKey))
+	for k := 0; k < 5; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	if got := toList(tree, false, IntKey(3), IntKey(1)); !reflect.DeepEqual(got, []int{3, 3, 2, 2, 1, 1}) {
+		t.Fatal("wrong order", got)
+	}
+	tree = New(simpletree.New(false), ByKeyOf(func(value interface{}) avltree.Key {
+		return IntKey(value.(int))
+	}))
+	for k := 5; k > 0; k-- {
+		tree, _ = avltree.Insert(tree, false, Key(k), k)
+	}
+	if got := toList(tree, false, Key(2), Key(3)); !reflect.DeepEqual(got, []int{2, 2, 3, 3}) {
+		t.Fatal("wrong order", got)
+	}
+	if !avltree.CompareKeys(tree, Key(1), Key(2)).LessThan() || !avltree.CompareKeys(simpletree.New(false), IntKey(2), IntKey(1)).GreaterThan() {
+		t.Fatal("wrong CompareKeys")
+	}
+}
+
+// ラッパーの内側にしても比較関数が使われる
+func TestWrapped(t *testing.T) {
+	count := 0
+	tree := observabletree.New(New(immutabletree.New(false), intComparator), func(event observabletree.Event) {
+		count++
+	})
+	for k := 0; k < 5; k++ {
+		tree, _ = avltree.Insert(tree, false, Key(k), k)
+	}
+	tree, _ = avltree.Delete(tree, Key(2))
+	if count != 6 || avltree.Find(tree, Key(3)) == nil || avltree.CountRange(tree, Key(1), Key(3)) != 2 {
+		t.Fatal("comparator is not used", count)
+	}
+}
+
+func TestPlainKeyCompareTo(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	tree := simpletree.New(false)
+	tree, _ = avltree.Insert(tree, false, Key(1), 1)
+	avltree.Insert(tree, false, Key(2), 2)
+}
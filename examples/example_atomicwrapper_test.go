@@ -0,0 +1,50 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/atomicwrapper"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func Example_atomicwrapper() {
+	w := atomicwrapper.New(false)
+	w.Insert(IntKey(1), 100)
+	w.Insert(IntKey(2), 200)
+	snapshot := w.Snapshot()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 10; k++ {
+				w.Swap(func(current avltree.Tree) (modified avltree.Tree) {
+					modified, _ = avltree.Update(current, IntKey(1), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+						newValue = oldValue.(int) + 1
+						return
+					})
+					return
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	w.Delete(IntKey(2))
+	avltree.Iterate(snapshot, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Snapshot!", node.Key(), node.Value())
+		return
+	})
+	w.Iterate(func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Current!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Snapshot! 1 100
+	// Snapshot! 2 200
+	// Current! 1 140
+}
@@ -0,0 +1,41 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/atomicwrapper"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func Example_atomicwrapperTxn() {
+	w := atomicwrapper.New(false)
+	w.Insert(IntKey(1), 100)
+	w.Insert(IntKey(2), 50)
+	txn := w.BeginOptimistic()
+	txn.Update(IntKey(1), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) - 30
+		return
+	})
+	txn.Update(IntKey(2), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) + 30
+		return
+	})
+	fmt.Println("In Txn!", txn.Find(IntKey(1)).Value(), txn.Find(IntKey(2)).Value())
+	fmt.Println("Before Commit!", w.Find(IntKey(1)).Value(), w.Find(IntKey(2)).Value())
+	if txn.Commit() {
+		fmt.Println("After Commit!", w.Find(IntKey(1)).Value(), w.Find(IntKey(2)).Value())
+	}
+	txn = w.Begin()
+	txn.Delete(IntKey(1))
+	txn.Rollback()
+	fmt.Println("After Rollback!", w.Count())
+	// Output:
+	// In Txn! 70 80
+	// Before Commit! 100 50
+	// After Commit! 70 80
+	// After Rollback! 2
+}
@@ -0,0 +1,33 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/bytesarraytree"
+	. "github.com/neetsdkasu/avltree/byteskey"
+)
+
+func Example_bytesarraytree() {
+	tree := bytesarraytree.New(false)
+	avltree.Insert(tree, false, BytesKey("banana"), []byte("yellow"))
+	avltree.Insert(tree, false, BytesKey("cherry"), []byte("red"))
+	avltree.Insert(tree, false, BytesKey("durian"), []byte("green"))
+	avltree.Insert(tree, false, BytesKey("apple"), []byte("red"))
+	avltree.Delete(tree, BytesKey("cherry"))
+	tree.Compact()
+	if value, ok := tree.Find([]byte("banana")); ok {
+		fmt.Printf("Find! banana %s\n", value)
+	}
+	tree.RangeIterate(false, []byte("b"), nil, func(key, value []byte) (breakIteration bool) {
+		fmt.Printf("RangeIterate! %s %s\n", key, value)
+		return
+	})
+	// Output:
+	// Find! banana yellow
+	// RangeIterate! banana yellow
+	// RangeIterate! durian green
+}
@@ -0,0 +1,29 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func Example_codec() {
+	c := codec.New(codec.IntKey, codec.StringValue)
+	data, _ := c.EncodeKey(IntKey(-2))
+	fmt.Printf("EncodeKey! %x\n", data)
+	key, _ := c.DecodeKey(data)
+	fmt.Println("DecodeKey!", key)
+	data, _ = c.EncodeValue("hello")
+	value, _ := c.DecodeValue(data)
+	fmt.Println("DecodeValue!", value)
+	_, err := c.DecodeKey([]byte{1, 2, 3})
+	fmt.Println("Error!", err)
+	// Output:
+	// EncodeKey! 7ffffffffffffffe
+	// DecodeKey! -2
+	// DecodeValue! hello
+	// Error! codec: invalid data length 3 (expected 8)
+}
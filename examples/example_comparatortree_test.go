@@ -0,0 +1,36 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/comparatortree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func Example_comparatortree() {
+	// 大文字と小文字を区別しない
+	tree := comparatortree.New(simpletree.New(false), func(a, b interface{}) int {
+		return strings.Compare(strings.ToLower(a.(string)), strings.ToLower(b.(string)))
+	})
+	tree, _ = avltree.Insert(tree, false, comparatortree.Key("banana"), 1)
+	tree, _ = avltree.Insert(tree, false, comparatortree.Key("Apple"), 2)
+	tree, _ = avltree.Insert(tree, false, comparatortree.Key("cherry"), 3)
+	tree, _ = avltree.Insert(tree, false, comparatortree.Key("APPLE"), 4)
+	if node := avltree.Find(tree, comparatortree.Key("BANANA")); node != nil {
+		fmt.Println("Find!", comparatortree.Value(node.Key()), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! banana 1
+	// Iterate! Apple 2
+	// Iterate! banana 1
+	// Iterate! cherry 3
+}
@@ -0,0 +1,45 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/history"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func Example_history() {
+	h := history.New(false, 10)
+	h.Insert(IntKey(1), "a")
+	h.Insert(IntKey(2), "b")
+	h.Checkpoint("saved")
+	h.Replace(IntKey(1), "A")
+	h.Delete(IntKey(2))
+	fmt.Println("Current!", h.Version(), h.Count())
+	h.Undo()
+	h.Undo()
+	fmt.Println("Undo!", h.Version(), h.Find(IntKey(1)).Value())
+	h.Redo()
+	fmt.Println("Redo!", h.Version(), h.Find(IntKey(1)).Value())
+	h.Insert(IntKey(3), "c")
+	fmt.Println("Redo after insert!", h.Redo())
+	version, _ := h.Lookup("saved")
+	old, _ := h.At(version)
+	avltree.Iterate(old, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("At!", version, node.Key(), node.Value())
+		return
+	})
+	h.Revert("saved")
+	fmt.Println("Revert!", h.Version(), h.Count())
+	// Output:
+	// Current! 4 1
+	// Undo! 2 a
+	// Redo! 3 A
+	// Redo after insert! false
+	// At! 2 1 a
+	// At! 2 2 b
+	// Revert! 6 2
+}
@@ -0,0 +1,31 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	. "github.com/neetsdkasu/avltree/int64key"
+)
+
+func Example_int64arraytree() {
+	tree := int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeFloat64, false)
+	avltree.Insert(tree, false, Int64Key(1600000000000), 12.5)
+	avltree.Insert(tree, false, Int64Key(1600000001000), 13.25)
+	avltree.Insert(tree, false, Int64Key(1600000002000), 11.0)
+	avltree.Delete(tree, Int64Key(1600000001000))
+	if node := avltree.Find(tree, Int64Key(1600000000000)); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 1600000000000 12.5
+	// Iterate! 1600000000000 12.5
+	// Iterate! 1600000002000 11
+}
@@ -0,0 +1,37 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	"github.com/neetsdkasu/avltree/int64float64wrapper"
+)
+
+func Example_int64float64wrapper() {
+	tree := int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeFloat64, false)
+	w := int64float64wrapper.New(tree)
+	w.Insert(1600000000000, 12.5)
+	w.Insert(1600000001000, 13.25)
+	w.Insert(1600000002000, 11.0)
+	w.Insert(1600000003000, 10.75)
+	w.Delete(1600000001000)
+	w.Update(1600000002000, func(key int64, oldValue float64) (newValue float64, keepOldValue bool) {
+		newValue = oldValue * 1.5
+		return
+	})
+	if node := w.Find(1600000000000); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	w.Iterate(func(node int64float64wrapper.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 1600000000000 12.5
+	// Iterate! 1600000000000 12.5
+	// Iterate! 1600000002000 16.5
+	// Iterate! 1600000003000 10.75
+}
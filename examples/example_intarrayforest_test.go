@@ -0,0 +1,36 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/intarraytree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func Example_intarrayforest() {
+	forest := intarraytree.NewForest()
+	alice := forest.NewTree(false)
+	bob := forest.NewTree(false)
+	avltree.Insert(alice, false, IntKey(3), 30)
+	avltree.Insert(alice, false, IntKey(1), 10)
+	avltree.Insert(bob, false, IntKey(2), 20)
+	avltree.Release(&bob)
+	carol := forest.NewTree(false)
+	avltree.Insert(carol, false, IntKey(5), 50)
+	avltree.Iterate(alice, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("alice", node.Key(), node.Value())
+		return
+	})
+	avltree.Iterate(carol, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("carol", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// alice 1 10
+	// alice 3 30
+	// carol 5 50
+}
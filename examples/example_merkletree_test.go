@@ -0,0 +1,42 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/merkletree"
+)
+
+func Example_merkletree() {
+	c := codec.New(codec.IntKey, codec.StringValue)
+	tree1 := merkletree.New(false, c)
+	tree2 := merkletree.New(false, c)
+	for k := 1; k <= 5; k++ {
+		tree1, _ = avltree.Insert(tree1, false, IntKey(k), "v")
+		tree2, _ = avltree.Insert(tree2, false, IntKey(6-k), "v")
+	}
+	hash1 := tree1.(*merkletree.MerkleTree).RootHash()
+	hash2 := tree2.(*merkletree.MerkleTree).RootHash()
+	fmt.Println("RootHash!", hash1 == hash2)
+	tree2, _ = avltree.Replace(tree2, IntKey(4), "w")
+	hash2 = tree2.(*merkletree.MerkleTree).RootHash()
+	fmt.Println("Replaced!", hash1 == hash2)
+	hash1, count1 := tree1.(*merkletree.MerkleTree).RangeHash(IntKey(1), IntKey(3))
+	hash2, count2 := tree2.(*merkletree.MerkleTree).RangeHash(IntKey(1), IntKey(3))
+	fmt.Println("RangeHash!", hash1 == hash2, count1, count2)
+	hash1, _ = tree1.(*merkletree.MerkleTree).RangeHash(IntKey(4), nil)
+	hash2, _ = tree2.(*merkletree.MerkleTree).RangeHash(IntKey(4), nil)
+	fmt.Println("RangeHash!", hash1 == hash2)
+	fmt.Println("NodeAt!", tree1.(*merkletree.MerkleTree).NodeAt(2).Key())
+	// Output:
+	// RootHash! true
+	// Replaced! false
+	// RangeHash! true 3 3
+	// RangeHash! false
+	// NodeAt! 3
+}
@@ -0,0 +1,39 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/observabletree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func Example_observabletree() {
+	tree := observabletree.New(simpletree.New(false), func(event observabletree.Event) {
+		fmt.Println(event.Kind, event.Key, event.OldValue, event.NewValue)
+	})
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 100)
+	tree, _ = avltree.Insert(tree, false, IntKey(2), 200)
+	tree, _ = avltree.Insert(tree, false, IntKey(3), 300)
+	tree, _ = avltree.Insert(tree, true, IntKey(2), 222)
+	tree, _ = avltree.UpdateRange(tree, false, IntKey(2), IntKey(3), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) + 1
+		return
+	})
+	tree, _ = avltree.DeleteRange(tree, false, IntKey(1), IntKey(2))
+	tree = avltree.Clear(tree)
+	// Output:
+	// Inserted 1 <nil> 100
+	// Inserted 2 <nil> 200
+	// Inserted 3 <nil> 300
+	// Replaced 2 200 222
+	// Replaced 2 222 223
+	// Replaced 3 300 301
+	// Deleted 1 100 <nil>
+	// Deleted 2 223 <nil>
+	// Deleted 3 301 <nil>
+}
@@ -0,0 +1,43 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/parallel"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func Example_parallel() {
+	tree := simpletree.New(false)
+	for k := 1; k <= 1000; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k*k)
+	}
+	sum, _ := parallel.ParallelReduce(tree, 4, func(key avltree.Key, value interface{}) interface{} {
+		return value.(int)
+	}, func(left, right interface{}) interface{} {
+		return left.(int) + right.(int)
+	})
+	fmt.Println("Sum!", sum)
+	keys, _ := parallel.ParallelOrderedReduce(tree, 4, func(key avltree.Key, value interface{}) interface{} {
+		return []avltree.Key{key}
+	}, func(left, right interface{}) interface{} {
+		return append(left.([]avltree.Key), right.([]avltree.Key)...)
+	})
+	fmt.Println("Keys!", keys.([]avltree.Key)[:5])
+	count := int64(0)
+	parallel.ParallelRangeIterate(tree, 4, IntKey(100), IntKey(199), func(node avltree.Node) (breakIteration bool) {
+		atomic.AddInt64(&count, 1)
+		return
+	})
+	fmt.Println("Count!", count)
+	// Output:
+	// Sum! 333833500
+	// Keys! [1 2 3 4 5]
+	// Count! 100
+}
@@ -0,0 +1,41 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/refcounttree"
+)
+
+func Example_refcounttree() {
+	tree := refcounttree.NewWithFinalizer(false, func(key avltree.Key, value interface{}) {
+		fmt.Println("Finalize!", key, value)
+	})
+	newTree, _ := avltree.Insert(tree, false, IntKey(12), 345)
+	tree = refcounttree.Advance(tree, newTree)
+	newTree, _ = avltree.Insert(tree, false, IntKey(67), 890)
+	tree = refcounttree.Advance(tree, newTree)
+	saved := tree
+	tree, _ = avltree.Delete(tree, IntKey(67))
+	newTree, _ = avltree.Replace(tree, IntKey(12), 999)
+	tree = refcounttree.Advance(tree, newTree)
+	fmt.Println("Release saved")
+	avltree.Release(&saved)
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	fmt.Println("Release tree")
+	avltree.Release(&tree)
+	// Output:
+	// Release saved
+	// Finalize! 67 890
+	// Finalize! 12 345
+	// Iterate! 12 999
+	// Release tree
+	// Finalize! 12 999
+}
@@ -0,0 +1,40 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/reversetree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func Example_reversetree() {
+	tree := simpletree.New(false)
+	for _, k := range []int{5, 1, 9, 3, 7} {
+		avltree.Insert(tree, false, IntKey(k), k*10)
+	}
+	desc := reversetree.Descending(tree)
+	fmt.Println("Min!", avltree.Min(desc).Key(), avltree.Max(desc).Key())
+	avltree.RangeIterate(desc, false, IntKey(8), IntKey(3), func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Range!", node.Key(), node.Value())
+		return
+	})
+	avltree.Insert(desc, false, IntKey(4), 40)
+	fmt.Println("Count!", avltree.Count(tree), avltree.CountRange(desc, IntKey(5), IntKey(3)))
+	keys := simpletree.New(false)
+	for _, k := range []int{5, 1, 9} {
+		avltree.Insert(keys, false, reversetree.Reverse(IntKey(k)), k)
+	}
+	fmt.Println("Reverse!", avltree.Min(keys).Key(), avltree.Max(keys).Key())
+	// Output:
+	// Min! 9 1
+	// Range! 7 70
+	// Range! 5 50
+	// Range! 3 30
+	// Count! 6 3
+	// Reverse! 9 1
+}
@@ -0,0 +1,38 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/shardedmap"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func Example_shardedmap() {
+	m := shardedmap.New(simpletree.New, false, IntKey(100), IntKey(200))
+	for k := 0; k < 300; k += 25 {
+		m.Insert(IntKey(k), k*2)
+	}
+	fmt.Println("Counts!", m.ShardCounts())
+	m.RangeIterate(IntKey(80), IntKey(210), func(key avltree.Key, value interface{}) (breakIteration bool) {
+		fmt.Println("RangeIterate!", key, value)
+		return
+	})
+	m.Merge(IntKey(100))
+	m.Split(IntKey(250))
+	fmt.Println("Boundaries!", m.Boundaries())
+	fmt.Println("Counts!", m.ShardCounts())
+	// Output:
+	// Counts! [4 4 4]
+	// RangeIterate! 100 200
+	// RangeIterate! 125 250
+	// RangeIterate! 150 300
+	// RangeIterate! 175 350
+	// RangeIterate! 200 400
+	// Boundaries! [200 250]
+	// Counts! [8 2 2]
+}
@@ -0,0 +1,51 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/merkletree"
+	"github.com/neetsdkasu/avltree/sync"
+)
+
+func Example_sync() {
+	c := codec.New(codec.IntKey, codec.StringValue)
+	tree1 := merkletree.New(false, c)
+	tree2 := merkletree.New(false, c)
+	tree1, _ = avltree.Insert(tree1, false, IntKey(1), "a")
+	tree1, _ = avltree.Insert(tree1, false, IntKey(2), "b")
+	tree2, _ = avltree.Insert(tree2, false, IntKey(2), "B")
+	tree2, _ = avltree.Insert(tree2, false, IntKey(3), "c")
+	conn1, conn2 := net.Pipe()
+	done := make(chan avltree.Tree)
+	go func() {
+		tree, _, _ := sync.Respond(conn2, tree2)
+		done <- tree
+	}()
+	resolver := func(key avltree.Key, local, remote interface{}) (resolved interface{}) {
+		return local.(string) + remote.(string)
+	}
+	tree1, _, _ = sync.Initiate(conn1, tree1, resolver)
+	tree2 = <-done
+	avltree.Iterate(tree1, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Initiate!", node.Key(), node.Value())
+		return
+	})
+	avltree.Iterate(tree2, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Respond!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Initiate! 1 a
+	// Initiate! 2 bB
+	// Initiate! 3 c
+	// Respond! 1 a
+	// Respond! 2 bB
+	// Respond! 3 c
+}
@@ -0,0 +1,43 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/syncwrapper"
+)
+
+func Example_syncwrapper() {
+	w := syncwrapper.New(simpletree.New(false))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for k := i; k < 100; k += 4 {
+				w.Insert(IntKey(k), k*k)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if kv := w.Find(IntKey(12)); kv != nil {
+		fmt.Println("Find!", kv.Key(), kv.Value())
+	}
+	fmt.Println("Count!", w.Count())
+	w.RangeIterate(IntKey(97), nil, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("RangeIterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 12 144
+	// Count! 100
+	// RangeIterate! 97 9409
+	// RangeIterate! 98 9604
+	// RangeIterate! 99 9801
+}
@@ -0,0 +1,39 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package examples
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/wal"
+)
+
+func Example_wal() {
+	dir, _ := os.MkdirTemp("", "wal")
+	defer os.RemoveAll(dir)
+	c := codec.New(codec.IntKey, codec.StringValue)
+	log, tree, _ := wal.Open(dir, simpletree.New(false), c)
+	tree, _ = avltree.Insert(tree, false, IntKey(1), "one")
+	tree, _ = avltree.Insert(tree, false, IntKey(2), "two")
+	log.Checkpoint()
+	tree, _ = avltree.Insert(tree, false, IntKey(3), "three")
+	tree, _ = avltree.Delete(tree, IntKey(1))
+	fmt.Println("LSN!", log.LSN())
+	log.Close()
+	log, tree, _ = wal.Open(dir, simpletree.New(false), c)
+	defer log.Close()
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Recovered!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// LSN! 8
+	// Recovered! 2 two
+	// Recovered! 3 three
+}
@@ -0,0 +1,52 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// float64型をそのままキーにしてある
+// float64型の大小関係に以下を加えた全順序をキーの順序としてある
+//
+//	-0は+0より小さい
+//	NaNはどの数(+Infを含む)よりも大きく、NaNどうしは(符号やペイロードに関わらず)等しい
+package float64key
+
+import (
+	"math"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type Float64Key float64
+
+func (key Float64Key) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	v1 := float64(key)
+	v2 := float64(other.(Float64Key))
+	switch {
+	case v1 < v2:
+		return avltree.LessThanOtherKey
+	case v1 > v2:
+		return avltree.GreaterThanOtherKey
+	case v1 == v2:
+		// -0 と +0 は == で等しくなるので符号で区別する
+		switch s1, s2 := math.Signbit(v1), math.Signbit(v2); {
+		case s1 && !s2:
+			return avltree.LessThanOtherKey
+		case !s1 && s2:
+			return avltree.GreaterThanOtherKey
+		default:
+			return avltree.EqualToOtherKey
+		}
+	}
+	// 少なくとも一方がNaN
+	switch n1, n2 := math.IsNaN(v1), math.IsNaN(v2); {
+	case n1 && n2:
+		return avltree.EqualToOtherKey
+	case n1:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.LessThanOtherKey
+	}
+}
+
+func (key Float64Key) Copy() avltree.Key {
+	return key
+}
@@ -0,0 +1,116 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package float64key
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+// キーの順序と同じ順序になる整数に変換する
+func orderBits(v float64) int64 {
+	if math.IsNaN(v) {
+		return math.MaxInt64
+	}
+	bits := int64(math.Float64bits(v))
+	if bits < 0 {
+		// 負の数は符号ビット以外を反転すると大小が逆になる
+		bits ^= math.MaxInt64
+	}
+	return bits
+}
+
+var specials = []float64{
+	math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, math.Copysign(0, -1),
+	0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1),
+	math.NaN(), math.Copysign(math.NaN(), -1), math.Float64frombits(0x7FF0000000000001),
+}
+
+func TestFloat64Key(t *testing.T) {
+	f := func(k1, k2 float64, i1, i2 uint8) bool {
+		if i1%2 == 0 {
+			k1 = specials[int(i1/2)%len(specials)]
+		}
+		if i2%2 == 0 {
+			k2 = specials[int(i2/2)%len(specials)]
+		}
+		var key1 avltree.Key = Float64Key(k1)
+		var key2 avltree.Key = Float64Key(k2)
+		b1, b2 := orderBits(k1), orderBits(k2)
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return b1 < b2
+		case avltree.EqualToOtherKey:
+			return b1 == b2
+		case avltree.GreaterThanOtherKey:
+			return b1 > b2
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpecials(t *testing.T) {
+	for i := range specials {
+		for j := range specials {
+			cmp := Float64Key(specials[i]).CompareTo(Float64Key(specials[j]))
+			switch {
+			case i >= len(specials)-3 && j >= len(specials)-3:
+				if !cmp.EqualTo() {
+					t.Fatal("NaNs are not equal", i, j)
+				}
+			case i < j:
+				if !cmp.LessThan() {
+					t.Fatal("not less", specials[i], specials[j])
+				}
+			case i > j:
+				if !cmp.GreaterThan() {
+					t.Fatal("not greater", specials[i], specials[j])
+				}
+			default:
+				if !cmp.EqualTo() {
+					t.Fatal("not equal", specials[i])
+				}
+			}
+		}
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	avltree.Insert(tree, false, Float64Key(1.5), 345)
+	avltree.Insert(tree, false, Float64Key(math.NaN()), 890)
+	avltree.Insert(tree, false, Float64Key(0), 666)
+	avltree.Insert(tree, false, Float64Key(math.Copysign(0, -1)), 12345)
+	avltree.Insert(tree, false, Float64Key(math.Inf(-1)), 777)
+	avltree.Delete(tree, Float64Key(1.5))
+	avltree.Update(tree, Float64Key(0), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) * 3
+		return
+	})
+	if node := avltree.Find(tree, Float64Key(math.NaN())); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! NaN 890
+	// Iterate! -Inf 777
+	// Iterate! -0 12345
+	// Iterate! 0 1998
+	// Iterate! NaN 890
+}
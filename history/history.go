@@ -0,0 +1,335 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのimmutabletreeを用いた取り消し(Undo)とやり直し(Redo)ができる順序付きマップの実装例
+//
+// 変更の操作ごとにimmutabletreeが返す新しい木をバージョンとして履歴に積む
+// 各バージョンは構造を共有しているため、履歴を保持するコストは変更されたノードの分だけで済む
+//
+// バージョンには変更の度に1ずつ増える番号が振られ、At(番号)でそのバージョンの木を読み取ることができる
+// 取り消しのあとに変更した場合はやり直しのための履歴(取り消したバージョン)は破棄される(破棄された番号は再利用されない)
+// 複数の変更を１つのバージョンにまとめる場合はModifyを使う
+//
+// Checkpointで現在のバージョンに名前を付けておき、Revertでそのバージョンの内容に戻すことができる
+// (Revertも１つの変更として履歴に積まれるので取り消すことができる)
+//
+// maxLengthに正の値を指定した場合は保持するバージョンの数がmaxLengthを超えたときに古いバージョンから破棄する
+// 破棄されたバージョンやそのバージョンに付けた名前は使用できなくなる
+//
+// At,Treeなどで得た木は不変なので、そのまま保持したり読み取ったりしてよい
+// 並行に使用することはできない
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/history"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//		)
+//		func Example_history() {
+//			h := history.New(false, 10)
+//			h.Insert(IntKey(1), "a")
+//			h.Insert(IntKey(2), "b")
+//			h.Checkpoint("saved")
+//			h.Replace(IntKey(1), "A")
+//			h.Delete(IntKey(2))
+//			fmt.Println("Current!", h.Version(), h.Count())
+//			h.Undo()
+//			h.Undo()
+//			fmt.Println("Undo!", h.Version(), h.Find(IntKey(1)).Value())
+//			h.Redo()
+//			fmt.Println("Redo!", h.Version(), h.Find(IntKey(1)).Value())
+//			h.Insert(IntKey(3), "c")
+//			fmt.Println("Redo after insert!", h.Redo())
+//			version, _ := h.Lookup("saved")
+//			old, _ := h.At(version)
+//			avltree.Iterate(old, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("At!", version, node.Key(), node.Value())
+//				return
+//			})
+//			h.Revert("saved")
+//			fmt.Println("Revert!", h.Version(), h.Count())
+//			// Output:
+//			// Current! 4 1
+//			// Undo! 2 a
+//			// Redo! 3 A
+//			// Redo after insert! false
+//			// At! 2 1 a
+//			// At! 2 2 b
+//			// Revert! 6 2
+//		}
+//
+package history
+
+import (
+	"sort"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+)
+
+type version struct {
+	number int
+	tree   avltree.Tree
+}
+
+type History struct {
+	// 番号の昇順に並んだバージョン
+	versions []version
+
+	// 現在のバージョンのversionsでのインデックス
+	current int
+
+	// 最後に振った番号
+	lastNumber int
+
+	checkpoints map[string]int
+	maxLength   int
+}
+
+// 空の木をバージョン0として持つHistoryを生成する
+// maxLengthが0以下の場合は履歴の長さを制限しない
+func New(allowDuplicateKeys bool, maxLength int) *History {
+	return &History{
+		versions:    []version{{0, immutabletree.New(allowDuplicateKeys)}},
+		checkpoints: map[string]int{},
+		maxLength:   maxLength,
+	}
+}
+
+// 現在の木を返す
+func (h *History) Tree() avltree.Tree {
+	return h.versions[h.current].tree
+}
+
+// 現在のバージョンの番号を返す
+func (h *History) Version() int {
+	return h.versions[h.current].number
+}
+
+// 保持している最も古いバージョンと最も新しいバージョンの番号を返す
+func (h *History) Versions() (oldest, newest int) {
+	return h.versions[0].number, h.versions[len(h.versions)-1].number
+}
+
+func (h *History) indexOf(number int) (index int, ok bool) {
+	index = sort.Search(len(h.versions), func(i int) bool {
+		return h.versions[i].number >= number
+	})
+	return index, index < len(h.versions) && h.versions[index].number == number
+}
+
+// 指定の番号のバージョンの木を返す
+// 破棄されたバージョンの場合はokがfalseになる
+func (h *History) At(number int) (tree avltree.Tree, ok bool) {
+	if index, ok := h.indexOf(number); ok {
+		return h.versions[index].tree, true
+	}
+	return nil, false
+}
+
+// １つ前のバージョンに戻す
+// 戻せない場合はfalseを返す
+func (h *History) Undo() (ok bool) {
+	if h.current == 0 {
+		return false
+	}
+	h.current--
+	return true
+}
+
+// Undoで戻したバージョンを１つ進める
+// 進められない場合はfalseを返す
+func (h *History) Redo() (ok bool) {
+	if h.current+1 >= len(h.versions) {
+		return false
+	}
+	h.current++
+	return true
+}
+
+// 現在のバージョンに名前を付ける
+// 既に同じ名前がある場合は付け替える
+func (h *History) Checkpoint(name string) {
+	h.checkpoints[name] = h.Version()
+}
+
+// 名前を付けたバージョンの番号を返す
+func (h *History) Lookup(name string) (number int, ok bool) {
+	number, ok = h.checkpoints[name]
+	return
+}
+
+// 名前を付けたバージョンの内容を新しいバージョンとして履歴に積む
+// 名前が無い場合や現在と同じ内容の場合はfalseを返す
+func (h *History) Revert(name string) (ok bool) {
+	number, ok := h.checkpoints[name]
+	if !ok {
+		return false
+	}
+	tree, _ := h.At(number)
+	return h.push(tree)
+}
+
+// 新しい木を現在のバージョンの次に積む
+// やり直しのための履歴と、それに付けられた名前は破棄する
+func (h *History) push(tree avltree.Tree) (ok bool) {
+	if tree == h.Tree() {
+		return false
+	}
+	h.versions = h.versions[:h.current+1]
+	h.removeCheckpoints(func(number int) bool {
+		return number > h.Version()
+	})
+	h.lastNumber++
+	h.versions = append(h.versions, version{h.lastNumber, tree})
+	h.current++
+	if h.maxLength > 0 && len(h.versions) > h.maxLength {
+		drop := len(h.versions) - h.maxLength
+		oldest := h.versions[drop].number
+		h.versions = append(h.versions[:0], h.versions[drop:]...)
+		h.current -= drop
+		h.removeCheckpoints(func(number int) bool {
+			return number < oldest
+		})
+	}
+	return true
+}
+
+func (h *History) removeCheckpoints(remove func(number int) bool) {
+	for name, number := range h.checkpoints {
+		if remove(number) {
+			delete(h.checkpoints, name)
+		}
+	}
+}
+
+// 現在の木をmodifyに渡し、その戻り値の木を新しいバージョンとして履歴に積む
+// modifyの中で複数の変更を行った場合も１つのバージョンになる
+// modifyが受け取った木をそのまま返した場合は何もせずfalseを返す
+func (h *History) Modify(modify func(current avltree.Tree) (modified avltree.Tree)) (ok bool) {
+	return h.push(modify(h.Tree()))
+}
+
+func (h *History) Insert(key avltree.Key, value interface{}) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(current, false, key, value)
+		return
+	})
+	return
+}
+
+func (h *History) InsertOrReplace(key avltree.Key, value interface{}) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(current, true, key, value)
+		return
+	})
+	return
+}
+
+func (h *History) Delete(key avltree.Key) (deletedValue avltree.KeyAndValue) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue = avltree.Delete(current, key)
+		return
+	})
+	return
+}
+
+func (h *History) Update(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Update(current, key, callBack)
+		return
+	})
+	return
+}
+
+func (h *History) Replace(key avltree.Key, value interface{}) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Replace(current, key, value)
+		return
+	})
+	return
+}
+
+func (h *History) Alter(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValue avltree.KeyAndValue, ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue, ok = avltree.Alter(current, key, callBack)
+		return
+	})
+	return
+}
+
+// 木が空の場合は何もしない
+func (h *History) Clear() {
+	h.Modify(func(current avltree.Tree) avltree.Tree {
+		if current.Root() == nil {
+			return current
+		}
+		return avltree.Clear(current)
+	})
+}
+
+func (h *History) DeleteRange(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues = avltree.DeleteRange(current, false, lower, upper)
+		return
+	})
+	return
+}
+
+func (h *History) UpdateRange(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.UpdateRange(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (h *History) ReplaceRange(lower, upper avltree.Key, value interface{}) (ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.ReplaceRange(current, lower, upper, value)
+		return
+	})
+	return
+}
+
+func (h *History) AlterRange(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	h.Modify(func(current avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValues, ok = avltree.AlterRange(current, false, lower, upper, callBack)
+		return
+	})
+	return
+}
+
+func (h *History) Find(key avltree.Key) (node avltree.Node) {
+	return avltree.Find(h.Tree(), key)
+}
+
+func (h *History) Iterate(callBack avltree.IterateCallBack) {
+	avltree.Iterate(h.Tree(), false, callBack)
+}
+
+func (h *History) Range(lower, upper avltree.Key) (nodes []avltree.Node) {
+	return avltree.Range(h.Tree(), false, lower, upper)
+}
+
+func (h *History) RangeIterate(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	avltree.RangeIterate(h.Tree(), false, lower, upper, callBack)
+}
+
+func (h *History) Count() int {
+	return avltree.Count(h.Tree())
+}
+
+func (h *History) CountRange(lower, upper avltree.Key) int {
+	return avltree.CountRange(h.Tree(), lower, upper)
+}
+
+func (h *History) Min() (node avltree.Node) {
+	return avltree.Min(h.Tree())
+}
+
+func (h *History) Max() (node avltree.Node) {
+	return avltree.Max(h.Tree())
+}
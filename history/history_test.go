@@ -0,0 +1,211 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package history
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type operation struct {
+	Kind  uint8
+	Key   int8
+	Value int
+}
+
+func toMap(tree avltree.Tree) map[int]int {
+	m := map[int]int{}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		m[int(node.Key().(IntKey))] = node.Value().(int)
+		return
+	})
+	return m
+}
+
+func copyMap(m map[int]int) map[int]int {
+	c := map[int]int{}
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// 全てのバージョンの内容をmapで保持する単純な履歴
+type model struct {
+	numbers  []int
+	contents []map[int]int
+	current  int
+	last     int
+}
+
+func (m *model) push(content map[int]int, maxLength int) {
+	m.numbers = append(m.numbers[:m.current+1], m.last+1)
+	m.contents = append(m.contents[:m.current+1], content)
+	m.last++
+	m.current++
+	if maxLength > 0 && len(m.numbers) > maxLength {
+		drop := len(m.numbers) - maxLength
+		m.numbers = m.numbers[drop:]
+		m.contents = m.contents[drop:]
+		m.current -= drop
+	}
+}
+
+func TestSameAsModel(t *testing.T) {
+	f := func(ops []operation, maxLength uint8) bool {
+		limit := int(maxLength % 8)
+		h := New(false, limit)
+		m := &model{
+			numbers:  []int{0},
+			contents: []map[int]int{{}},
+		}
+		names := []string{"x", "y"}
+		for _, op := range ops {
+			key := IntKey(op.Key % 8)
+			content := m.contents[m.current]
+			switch op.Kind % 7 {
+			case 0, 1:
+				if h.InsertOrReplace(key, op.Value) {
+					next := copyMap(content)
+					next[int(key)] = op.Value
+					m.push(next, limit)
+				}
+			case 2:
+				if h.Delete(key) != nil {
+					next := copyMap(content)
+					delete(next, int(key))
+					m.push(next, limit)
+				}
+			case 3:
+				if h.Undo() != (m.current > 0) {
+					return false
+				}
+				if m.current > 0 {
+					m.current--
+				}
+			case 4:
+				if h.Redo() != (m.current+1 < len(m.numbers)) {
+					return false
+				}
+				if m.current+1 < len(m.numbers) {
+					m.current++
+				}
+			case 5:
+				name := names[op.Value&1]
+				h.Checkpoint(name)
+			case 6:
+				name := names[op.Value&1]
+				if h.Revert(name) {
+					tree, _ := h.At(h.Version())
+					m.push(toMap(tree), limit)
+				}
+			}
+			if h.Version() != m.numbers[m.current] {
+				t.Log("wrong version", h.Version(), m.numbers[m.current])
+				return false
+			}
+			if !reflect.DeepEqual(toMap(h.Tree()), m.contents[m.current]) {
+				return false
+			}
+			oldest, newest := h.Versions()
+			if oldest != m.numbers[0] || newest != m.numbers[len(m.numbers)-1] {
+				return false
+			}
+			for i, n := range m.numbers {
+				tree, ok := h.At(n)
+				if !ok || !reflect.DeepEqual(toMap(tree), m.contents[i]) {
+					return false
+				}
+			}
+			if _, ok := h.At(m.numbers[0] - 1); ok && m.numbers[0] > 0 {
+				return false
+			}
+			for _, name := range names {
+				if number, ok := h.Lookup(name); ok {
+					if _, ok := h.At(number); !ok {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevertRestoresCheckpoint(t *testing.T) {
+	h := New(false, 0)
+	h.Insert(IntKey(1), 1)
+	h.Checkpoint("a")
+	h.Insert(IntKey(2), 2)
+	h.Delete(IntKey(1))
+	if !h.Revert("a") {
+		t.Fatal("failed to revert")
+	}
+	if !reflect.DeepEqual(toMap(h.Tree()), map[int]int{1: 1}) {
+		t.Fatal("wrong content", toMap(h.Tree()))
+	}
+	if h.Revert("a") {
+		t.Fatal("reverted to the same content")
+	}
+	if !h.Undo() || !reflect.DeepEqual(toMap(h.Tree()), map[int]int{2: 2}) {
+		t.Fatal("failed to undo revert")
+	}
+	if h.Revert("b") {
+		t.Fatal("reverted to unknown name")
+	}
+}
+
+func TestDiscardedCheckpoints(t *testing.T) {
+	h := New(false, 3)
+	h.Insert(IntKey(1), 1)
+	h.Checkpoint("old")
+	h.Insert(IntKey(2), 2)
+	h.Checkpoint("redo")
+	h.Undo()
+	h.Insert(IntKey(3), 3)
+	if _, ok := h.Lookup("redo"); ok {
+		t.Fatal("checkpoint of discarded redo version remains")
+	}
+	h.Insert(IntKey(4), 4)
+	h.Insert(IntKey(5), 5)
+	if _, ok := h.Lookup("old"); ok {
+		t.Fatal("checkpoint of dropped version remains")
+	}
+	if oldest, newest := h.Versions(); oldest != 3 || newest != 5 {
+		t.Fatal("wrong versions", oldest, newest)
+	}
+	if h.Undo() && h.Undo() && h.Undo() {
+		t.Fatal("undo beyond the oldest version")
+	}
+}
+
+func TestModifyBatch(t *testing.T) {
+	h := New(false, 0)
+	h.Modify(func(current avltree.Tree) avltree.Tree {
+		for k := 0; k < 5; k++ {
+			current, _ = avltree.Insert(current, false, IntKey(k), k)
+		}
+		return current
+	})
+	if h.Version() != 1 || h.Count() != 5 {
+		t.Fatal("not batched", h.Version(), h.Count())
+	}
+	if h.Modify(func(current avltree.Tree) avltree.Tree { return current }) {
+		t.Fatal("unchanged tree is pushed")
+	}
+	h.Clear()
+	h.Clear()
+	if h.Version() != 2 {
+		t.Fatal("clearing empty tree is pushed", h.Version())
+	}
+}
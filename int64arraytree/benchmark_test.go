@@ -0,0 +1,225 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package int64arraytree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/int64key"
+)
+
+func genKeyAndValues(n int) []*keyAndValue {
+	list := []*keyAndValue{}
+	for i := 0; i < n; i++ {
+		key := rand.Int63()
+		value := rand.Int63()
+		list = append(list, &keyAndValue{key, value})
+	}
+	return list
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list[:b.N] {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[b.N:]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Delete(tree, Int64Key(kv.Key))
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Update(tree, Int64Key(kv.Key), func(key Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+			newValue = oldValue.(int64) >> 1
+			return
+		})
+	}
+}
+
+func BenchmarkReplace(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	const value int64 = 12345
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Replace(tree, Int64Key(kv.Key), value)
+	}
+}
+
+func BenchmarkDeleteByAlter(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Alter(tree, Int64Key(kv.Key), func(node avltree.AlterNode) (request avltree.AlterRequest) {
+			request.Delete()
+			return
+		})
+	}
+}
+
+func BenchmarkUpdateByAlter(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Alter(tree, Int64Key(kv.Key), func(node avltree.AlterNode) (request avltree.AlterRequest) {
+			request.Replace(node.Value().(int64) >> 1)
+			return
+		})
+	}
+}
+
+func BenchmarkReplaceByAlter(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	const value int64 = 12345
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Alter(tree, Int64Key(kv.Key), func(node avltree.AlterNode) (request avltree.AlterRequest) {
+			request.Replace(value)
+			return
+		})
+	}
+}
+
+func BenchmarkFind1(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for i := 0; i < 10; i++ {
+		for _, kv := range list {
+			avltree.Find(tree, Int64Key(kv.Key))
+		}
+	}
+}
+
+func BenchmarkFind2(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(4 * b.N)
+	for i, kv := range list {
+		if (i & 1) == 0 {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for i := 0; i < 10; i++ {
+		for _, kv := range list {
+			avltree.Find(tree, Int64Key(kv.Key))
+		}
+	}
+}
+
+func BenchmarkAscIterate(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	b.ResetTimer()
+	for i := 0; i < 100; i++ {
+		avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+			return
+		})
+	}
+}
+
+func BenchmarkDescIterate(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	b.ResetTimer()
+	for i := 0; i < 100; i++ {
+		avltree.Iterate(tree, true, func(node Node) (breakIteration bool) {
+			return
+		})
+	}
+}
+
+func BenchmarkAscRangeIterate(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	b.ResetTimer()
+	for i := 0; i < 100; i++ {
+		k1, k2 := rand.Int63(), rand.Int63()
+		if k2 < k1 {
+			k1, k2 = k2, k1
+		}
+		lower, upper := Int64Key(k1), Int64Key(k2)
+		avltree.RangeIterate(tree, false, lower, upper, func(node Node) (breakIteration bool) {
+			return
+		})
+	}
+}
+
+func BenchmarkDescRangeIterate(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+	}
+	b.ResetTimer()
+	for i := 0; i < 100; i++ {
+		k1, k2 := rand.Int63(), rand.Int63()
+		if k2 < k1 {
+			k1, k2 = k2, k1
+		}
+		lower, upper := Int64Key(k1), Int64Key(k2)
+		avltree.RangeIterate(tree, true, lower, upper, func(node Node) (breakIteration bool) {
+			return
+		})
+	}
+}
@@ -0,0 +1,408 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのRealTree,RealNodeの実装例
+// int64型の可変長配列(スライス)上にAVL木を構築する
+//
+// intarraytreeのint64版で、配列の構成(ヘッダとノードの各要素の位置)はintarraytreeと同じ
+// intarraytreeと異なり実行環境によらず1要素は8bytesになる
+//
+// 扱えるキーは以下のどちらか(木の生成時に指定する)
+// + int64keyのInt64Key
+// + uint64keyのUint64Key
+//
+// 扱える値は以下のいずれか(木の生成時に指定する)
+// + int64型
+// + float64型
+// + uint64型
+//
+// uint64型やfloat64型のキーや値はビット列をそのままint64型として配列に保持している
+//
+// 配列の最初の３要素は以下の木に関する情報を保持
+// + ルートノードのインデックス
+// + 同一キーを許可するかどうかの値
+// + 再利用可能なノードのインデックス
+//
+// １つのノードは７個分の要素で構成され、以下の情報を保持
+// + 左の子ノードのインデックス
+// + 右の子ノードのインデックス
+// + 木におけるノードの高さ
+// + 親ノードのインデックス
+// + 左右の子孫も合わせたノード総数
+// + ノードのキー
+// + ノードの値
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/int64arraytree"
+//			. "github.com/neetsdkasu/avltree/int64key"
+//		)
+//		func Example_int64arraytree() {
+//			tree := int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeFloat64, false)
+//			avltree.Insert(tree, false, Int64Key(1600000000000), 12.5)
+//			avltree.Insert(tree, false, Int64Key(1600000001000), 13.25)
+//			avltree.Insert(tree, false, Int64Key(1600000002000), 11.0)
+//			avltree.Delete(tree, Int64Key(1600000001000))
+//			if node := avltree.Find(tree, Int64Key(1600000000000)); node != nil {
+//				fmt.Println("Find!", node.Key(), node.Value())
+//			}
+//			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Iterate!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Find! 1600000000000 12.5
+//			// Iterate! 1600000000000 12.5
+//			// Iterate! 1600000002000 11
+//		}
+//
+package int64arraytree
+
+import (
+	"math"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64key"
+	"github.com/neetsdkasu/avltree/uint64key"
+)
+
+const (
+	PositionRootPosition int = iota
+	PositionDuplicateKeysBehavior
+	PositionIdleNodePosition
+	HeaderSize
+)
+
+const (
+	OffsetLeftChildPosition int = iota
+	OffsetRightChildPosition
+	OffsetHeight
+	OffsetParentPosition
+	OffsetNodeCount
+	OffsetKey
+	OffsetValue
+	NodeSize
+)
+
+const NodeIsNothing int = 0
+
+const (
+	DisallowDuplicateKeys int64 = 0
+	AllowDuplicateKeys    int64 = 1
+)
+
+// 木が扱うキーの型
+type KeyType int
+
+const (
+	// int64keyのInt64Keyをキーとして扱う
+	KeyTypeInt64 KeyType = iota
+
+	// uint64keyのUint64Keyをキーとして扱う
+	KeyTypeUint64
+)
+
+// 木が扱う値の型
+type ValueType int
+
+const (
+	// int64型を値として扱う
+	ValueTypeInt64 ValueType = iota
+
+	// float64型を値として扱う
+	ValueTypeFloat64
+
+	// uint64型を値として扱う
+	ValueTypeUint64
+)
+
+type Int64ArrayTree struct {
+	Array     []int64
+	KeyType   KeyType
+	ValueType ValueType
+}
+
+type Int64ArrayTreeNode struct {
+	Tree     *Int64ArrayTree
+	Position int
+}
+
+// キーがInt64Key、値がint64型の木を生成する
+func New(allowDuplicateKeys bool) avltree.Tree {
+	return NewWithInitialCapacity(HeaderSize, allowDuplicateKeys)
+}
+
+// キーがInt64Key、値がint64型の木を生成する
+func NewWithInitialCapacity(initialCapacity int, allowDuplicateKeys bool) avltree.Tree {
+	return NewWithTypesAndInitialCapacity(initialCapacity, KeyTypeInt64, ValueTypeInt64, allowDuplicateKeys)
+}
+
+// 指定したキーの型と値の型を扱う木を生成する
+func NewWithTypes(keyType KeyType, valueType ValueType, allowDuplicateKeys bool) avltree.Tree {
+	return NewWithTypesAndInitialCapacity(HeaderSize, keyType, valueType, allowDuplicateKeys)
+}
+
+// 指定したキーの型と値の型を扱う木を生成する
+func NewWithTypesAndInitialCapacity(initialCapacity int, keyType KeyType, valueType ValueType, allowDuplicateKeys bool) avltree.Tree {
+	tree := &Int64ArrayTree{make([]int64, initialCapacity), keyType, valueType}
+	tree.Init(allowDuplicateKeys)
+	return tree
+}
+
+func (tree *Int64ArrayTree) Init(allowDuplicateKeys bool) {
+	array := tree.Array
+	if len(array) < HeaderSize {
+		var buf [HeaderSize]int64
+		array = append(array, buf[:]...)
+	}
+	array = array[:HeaderSize]
+	array[PositionRootPosition] = int64(NodeIsNothing)
+	if allowDuplicateKeys {
+		array[PositionDuplicateKeysBehavior] = AllowDuplicateKeys
+	} else {
+		array[PositionDuplicateKeysBehavior] = DisallowDuplicateKeys
+	}
+	array[PositionIdleNodePosition] = int64(NodeIsNothing)
+	tree.Array = array
+}
+
+func unwrap(node avltree.Node) int {
+	if node == nil {
+		return NodeIsNothing
+	} else {
+		return node.(*Int64ArrayTreeNode).Position
+	}
+}
+
+func (node *Int64ArrayTreeNode) toNode() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node
+	}
+}
+
+func (tree *Int64ArrayTree) getNode(position int) *Int64ArrayTreeNode {
+	if position == NodeIsNothing {
+		return nil
+	} else {
+		return &Int64ArrayTreeNode{
+			Tree:     tree,
+			Position: position,
+		}
+	}
+}
+
+func (tree *Int64ArrayTree) getRoot() *Int64ArrayTreeNode {
+	return tree.getNode(int(tree.Array[PositionRootPosition]))
+}
+
+// int64arraytree.New()以外でInt64ArrayTreeが生成されたときの気休め保険
+func (tree *Int64ArrayTree) init() bool {
+	if len(tree.Array) < HeaderSize {
+		tree.Init(true)
+		return true
+	} else {
+		return false
+	}
+}
+
+// キーを配列に保持する値に変換する
+func (tree *Int64ArrayTree) encodeKey(key avltree.Key) int64 {
+	switch tree.KeyType {
+	case KeyTypeInt64:
+		return int64(key.(int64key.Int64Key))
+	case KeyTypeUint64:
+		return int64(uint64(key.(uint64key.Uint64Key)))
+	default:
+		panic("unknown KeyType")
+	}
+}
+
+// 配列に保持している値をキーに戻す
+func (tree *Int64ArrayTree) decodeKey(data int64) avltree.Key {
+	switch tree.KeyType {
+	case KeyTypeInt64:
+		return int64key.Int64Key(data)
+	case KeyTypeUint64:
+		return uint64key.Uint64Key(uint64(data))
+	default:
+		panic("unknown KeyType")
+	}
+}
+
+// 値を配列に保持する値に変換する
+func (tree *Int64ArrayTree) encodeValue(value interface{}) int64 {
+	switch tree.ValueType {
+	case ValueTypeInt64:
+		return value.(int64)
+	case ValueTypeFloat64:
+		return int64(math.Float64bits(value.(float64)))
+	case ValueTypeUint64:
+		return int64(value.(uint64))
+	default:
+		panic("unknown ValueType")
+	}
+}
+
+// 配列に保持している値を元の型の値に戻す
+func (tree *Int64ArrayTree) decodeValue(data int64) interface{} {
+	switch tree.ValueType {
+	case ValueTypeInt64:
+		return data
+	case ValueTypeFloat64:
+		return math.Float64frombits(uint64(data))
+	case ValueTypeUint64:
+		return uint64(data)
+	default:
+		panic("unknown ValueType")
+	}
+}
+
+func (tree *Int64ArrayTree) Root() avltree.Node {
+	tree.init()
+	return tree.getRoot().toNode()
+}
+
+func (tree *Int64ArrayTree) ReleaseNode(node avltree.RealNode) {
+	tree.init()
+	position := unwrap(node)
+	if position != NodeIsNothing {
+		tree.Array[position] = tree.Array[PositionIdleNodePosition]
+		tree.Array[PositionIdleNodePosition] = int64(position)
+	}
+}
+
+func (tree *Int64ArrayTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	tree.init()
+	array := tree.Array
+	newNodePosition := int(array[PositionIdleNodePosition])
+	if newNodePosition == NodeIsNothing {
+		var buf [NodeSize]int64
+		newNodePosition = len(array)
+		array = append(array, buf[:]...)
+		tree.Array = array
+	} else {
+		nextIdleNodePosition := array[newNodePosition]
+		array[PositionIdleNodePosition] = nextIdleNodePosition
+	}
+	node := tree.getNode(newNodePosition)
+	node.set(OffsetLeftChildPosition, int64(unwrap(leftChild)))
+	node.set(OffsetRightChildPosition, int64(unwrap(rightChild)))
+	node.set(OffsetHeight, int64(height))
+	node.set(OffsetParentPosition, int64(NodeIsNothing))
+	node.set(OffsetNodeCount, 1)
+	node.set(OffsetKey, tree.encodeKey(key))
+	node.set(OffsetValue, tree.encodeValue(value))
+	node.resetNodeCount()
+	return node
+}
+
+func (tree *Int64ArrayTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.init()
+	tree.Array[PositionRootPosition] = int64(unwrap(newRoot))
+	tree.getRoot().setParent(NodeIsNothing)
+	return tree
+}
+
+func (tree *Int64ArrayTree) AllowDuplicateKeys() bool {
+	tree.init()
+	return tree.Array[PositionDuplicateKeysBehavior] == AllowDuplicateKeys
+}
+
+func (tree *Int64ArrayTree) NodeCount() int {
+	tree.init()
+	return tree.getRoot().NodeCount()
+}
+
+func (tree *Int64ArrayTree) CleanUpTree() {
+	if tree.init() {
+		return
+	}
+	tree.Init(tree.AllowDuplicateKeys())
+}
+
+func (node *Int64ArrayTreeNode) Key() avltree.Key {
+	return node.Tree.decodeKey(node.get(OffsetKey))
+}
+
+func (node *Int64ArrayTreeNode) Value() interface{} {
+	return node.Tree.decodeValue(node.get(OffsetValue))
+}
+
+func (node *Int64ArrayTreeNode) get(offset int) int64 {
+	return node.Tree.Array[node.Position+offset]
+}
+
+func (node *Int64ArrayTreeNode) set(offset int, value int64) {
+	node.Tree.Array[node.Position+offset] = value
+}
+
+func (node *Int64ArrayTreeNode) getLeftChild() *Int64ArrayTreeNode {
+	return node.Tree.getNode(int(node.get(OffsetLeftChildPosition)))
+}
+
+func (node *Int64ArrayTreeNode) LeftChild() avltree.Node {
+	return node.getLeftChild().toNode()
+}
+
+func (node *Int64ArrayTreeNode) getRightChild() *Int64ArrayTreeNode {
+	return node.Tree.getNode(int(node.get(OffsetRightChildPosition)))
+}
+
+func (node *Int64ArrayTreeNode) RightChild() avltree.Node {
+	return node.getRightChild().toNode()
+}
+
+func (node *Int64ArrayTreeNode) SetValue(newValue interface{}) avltree.Node {
+	node.set(OffsetValue, node.Tree.encodeValue(newValue))
+	return node
+}
+
+func (node *Int64ArrayTreeNode) setParent(position int) {
+	if node != nil {
+		node.set(OffsetParentPosition, int64(position))
+	}
+}
+
+func (node *Int64ArrayTreeNode) Parent() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node.Tree.getNode(int(node.get(OffsetParentPosition))).toNode()
+	}
+}
+
+func (node *Int64ArrayTreeNode) NodeCount() int {
+	if node == nil {
+		return 0
+	} else {
+		return int(node.get(OffsetNodeCount))
+	}
+}
+
+func (node *Int64ArrayTreeNode) Height() int {
+	return int(node.get(OffsetHeight))
+}
+
+func (node *Int64ArrayTreeNode) resetNodeCount() {
+	node.set(OffsetNodeCount, int64(1+node.getLeftChild().NodeCount()+node.getRightChild().NodeCount()))
+}
+
+func (node *Int64ArrayTreeNode) SetChildren(newLeftChild, newRightChild avltree.Node, newHeight int) avltree.RealNode {
+	node.set(OffsetLeftChildPosition, int64(unwrap(newLeftChild)))
+	node.set(OffsetRightChildPosition, int64(unwrap(newRightChild)))
+	node.set(OffsetHeight, int64(newHeight))
+	node.getLeftChild().setParent(node.Position)
+	node.getRightChild().setParent(node.Position)
+	node.resetNodeCount()
+	return node
+}
+
+func (node *Int64ArrayTreeNode) Set(newLeftChild, newRightChild avltree.Node, newHeight int, newValue interface{}) avltree.RealNode {
+	node.SetValue(newValue)
+	return node.SetChildren(newLeftChild, newRightChild, newHeight)
+}
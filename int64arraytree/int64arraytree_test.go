@@ -0,0 +1,411 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package int64arraytree
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/int64key"
+	. "github.com/neetsdkasu/avltree/uint64key"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type (
+	Key      = avltree.Key
+	Node     = avltree.Node
+	RealNode = avltree.RealNode
+	Tree     = avltree.Tree
+)
+
+type keyAndValue struct {
+	Key   int64
+	Value int64
+}
+
+func equals(node1, node2 Node) bool {
+	if node1 == nil || node2 == nil {
+		return node1 == nil && node2 == nil
+	}
+	realNode1 := node1.(*Int64ArrayTreeNode)
+	realNode2 := node2.(*Int64ArrayTreeNode)
+	return realNode1.Tree == realNode2.Tree &&
+		realNode1.Position == realNode2.Position
+}
+
+func omitDuplicates(list []keyAndValue) []*keyAndValue {
+	set := make(map[int64]bool)
+	result := []*keyAndValue{}
+	for i := range list {
+		kv := &list[i]
+		if set[kv.Key] {
+			continue
+		}
+		set[kv.Key] = true
+		result = append(result, kv)
+	}
+	return result
+}
+
+func toAscSorted(list []*keyAndValue) []*keyAndValue {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+func toKeyValueInt64s(list []*keyAndValue) (result []int64) {
+	for _, kv := range list {
+		result = append(result, kv.Key, kv.Value)
+	}
+	return
+}
+
+func getAllAscKeyAndValues(tree Tree) (result []int64) {
+	avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+		result = append(result, int64(node.Key().(Int64Key)))
+		result = append(result, node.Value().(int64))
+		return
+	})
+	return
+}
+
+func checkHeight(node Node) (ok bool) {
+	if node == nil {
+		return true
+	}
+	height := node.(RealNode).Height()
+	var hLeft, hRight int
+	if lChild, ok := node.LeftChild().(RealNode); ok {
+		hLeft = lChild.Height()
+	}
+	if rChild, ok := node.RightChild().(RealNode); ok {
+		hRight = rChild.Height()
+	}
+	hMin, hMax := hLeft, hRight
+	if hMax < hMin {
+		hMin, hMax = hMax, hMin
+	}
+	return hMax-hMin <= 1 && height-hMax == 1
+}
+
+func checkParent(tree Tree, node Node) (ok bool) {
+	if parent := node.(avltree.ParentGetter).Parent(); parent != nil {
+		if !equals(parent.LeftChild(), node) && !equals(parent.RightChild(), node) {
+			return false
+		}
+	} else if !equals(tree.Root(), node) {
+		return false
+	}
+	return true
+}
+
+func checkNodeCount(node Node) (ok bool) {
+	count := node.(avltree.NodeCounter).NodeCount()
+	var cLeft, cRight int
+	if leftChild, ok := node.LeftChild().(avltree.NodeCounter); ok {
+		cLeft = leftChild.NodeCount()
+	}
+	if rightChild, ok := node.RightChild().(avltree.NodeCounter); ok {
+		cRight = rightChild.NodeCount()
+	}
+	return count == 1+cLeft+cRight
+}
+
+func TestInsertOneEntry(t *testing.T) {
+
+	f := func(k, v int64) Tree {
+		tree := New(false)
+		avltree.Insert(tree, false, Int64Key(k), v)
+		return tree
+	}
+
+	g := func(k, v int64) Tree {
+		array := make([]int64, HeaderSize+NodeSize)
+		node := HeaderSize
+		array[PositionRootPosition] = int64(node)
+		array[PositionDuplicateKeysBehavior] = DisallowDuplicateKeys
+		array[PositionIdleNodePosition] = int64(NodeIsNothing)
+		array[node+OffsetLeftChildPosition] = int64(NodeIsNothing)
+		array[node+OffsetRightChildPosition] = int64(NodeIsNothing)
+		array[node+OffsetHeight] = 1
+		array[node+OffsetParentPosition] = int64(NodeIsNothing)
+		array[node+OffsetNodeCount] = 1
+		array[node+OffsetKey] = k
+		array[node+OffsetValue] = v
+		return &Int64ArrayTree{array, KeyTypeInt64, ValueTypeInt64}
+	}
+
+	if err := quick.CheckEqual(f, g, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsertTwoEntries(t *testing.T) {
+
+	f := func(k1, v1, k2, v2 int64) Tree {
+		if k1 == k2 {
+			return nil
+		}
+		tree := New(false)
+		avltree.Insert(tree, false, Int64Key(k1), v1)
+		avltree.Insert(tree, false, Int64Key(k2), v2)
+		return tree
+	}
+
+	g := func(k1, v1, k2, v2 int64) Tree {
+		if k1 == k2 {
+			return nil
+		}
+		array := make([]int64, HeaderSize+NodeSize*2)
+		node1 := HeaderSize
+		node2 := HeaderSize + NodeSize
+		array[PositionRootPosition] = int64(node1)
+		array[PositionDuplicateKeysBehavior] = DisallowDuplicateKeys
+		array[PositionIdleNodePosition] = int64(NodeIsNothing)
+		if k2 < k1 {
+			array[node1+OffsetLeftChildPosition] = int64(node2)
+			array[node1+OffsetRightChildPosition] = int64(NodeIsNothing)
+		} else {
+			array[node1+OffsetLeftChildPosition] = int64(NodeIsNothing)
+			array[node1+OffsetRightChildPosition] = int64(node2)
+		}
+		array[node1+OffsetHeight] = 2
+		array[node1+OffsetParentPosition] = int64(NodeIsNothing)
+		array[node1+OffsetNodeCount] = 2
+		array[node1+OffsetKey] = k1
+		array[node1+OffsetValue] = v1
+		array[node2+OffsetLeftChildPosition] = int64(NodeIsNothing)
+		array[node2+OffsetRightChildPosition] = int64(NodeIsNothing)
+		array[node2+OffsetHeight] = 1
+		array[node2+OffsetParentPosition] = int64(node1)
+		array[node2+OffsetNodeCount] = 1
+		array[node2+OffsetKey] = k2
+		array[node2+OffsetValue] = v2
+		return &Int64ArrayTree{array, KeyTypeInt64, ValueTypeInt64}
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAscIterate(t *testing.T) {
+
+	f := func(listBase []keyAndValue) []int64 {
+		list := omitDuplicates(listBase)
+		tree := New(false)
+		for _, kv := range list {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	g := func(listBase []keyAndValue) []int64 {
+		list := omitDuplicates(listBase)
+		return toKeyValueInt64s(toAscSorted(list))
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsertAndDelete(t *testing.T) {
+
+	f := func(listBase []keyAndValue, deleteFlags []bool) []int64 {
+		list := omitDuplicates(listBase)
+		tree := New(false)
+		for _, kv := range list {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+		for i, kv := range list {
+			if i < len(deleteFlags) && deleteFlags[i] {
+				avltree.Delete(tree, Int64Key(kv.Key))
+			}
+		}
+		var invalid bool
+		avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+			invalid = !checkHeight(node) || !checkParent(tree, node) || !checkNodeCount(node)
+			return invalid
+		})
+		if invalid {
+			return nil
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	g := func(listBase []keyAndValue, deleteFlags []bool) []int64 {
+		list := omitDuplicates(listBase)
+		rest := []*keyAndValue{}
+		for i, kv := range list {
+			if i < len(deleteFlags) && deleteFlags[i] {
+				continue
+			}
+			rest = append(rest, kv)
+		}
+		return toKeyValueInt64s(toAscSorted(rest))
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReuseReleasedNode(t *testing.T) {
+
+	f := func(listBase []keyAndValue) bool {
+		list := omitDuplicates(listBase)
+		tree := New(false).(*Int64ArrayTree)
+		for _, kv := range list {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+		size := len(tree.Array)
+		for _, kv := range list {
+			avltree.Delete(tree, Int64Key(kv.Key))
+		}
+		if tree.Root() != nil {
+			return false
+		}
+		for _, kv := range list {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+		return size == len(tree.Array) && avltree.Count(tree) == len(list)
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanUpTree(t *testing.T) {
+
+	f := func(listBase []keyAndValue) Tree {
+		list := omitDuplicates(listBase)
+		tree := New(true)
+		for _, kv := range list {
+			avltree.Insert(tree, false, Int64Key(kv.Key), kv.Value)
+		}
+		return avltree.Clear(tree)
+	}
+
+	g := func(listBase []keyAndValue) Tree {
+		array := make([]int64, HeaderSize)
+		array[PositionRootPosition] = int64(NodeIsNothing)
+		array[PositionDuplicateKeysBehavior] = AllowDuplicateKeys
+		array[PositionIdleNodePosition] = int64(NodeIsNothing)
+		return &Int64ArrayTree{array, KeyTypeInt64, ValueTypeInt64}
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUint64KeyAndValue(t *testing.T) {
+
+	f := func(keys []uint64) []uint64 {
+		tree := NewWithTypes(KeyTypeUint64, ValueTypeUint64, false)
+		for _, k := range keys {
+			avltree.Insert(tree, true, Uint64Key(k), ^k)
+		}
+		result := []uint64{}
+		avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+			result = append(result, uint64(node.Key().(Uint64Key)), node.Value().(uint64))
+			return
+		})
+		return result
+	}
+
+	g := func(keys []uint64) []uint64 {
+		set := make(map[uint64]bool)
+		list := []uint64{}
+		for _, k := range keys {
+			if !set[k] {
+				set[k] = true
+				list = append(list, k)
+			}
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i] < list[j]
+		})
+		result := []uint64{}
+		for _, k := range list {
+			result = append(result, k, ^k)
+		}
+		return result
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := NewWithTypes(KeyTypeUint64, ValueTypeUint64, false)
+	avltree.Insert(tree, false, Uint64Key(math.MaxUint64), uint64(1))
+	avltree.Insert(tree, false, Uint64Key(1), uint64(2))
+	avltree.Insert(tree, false, Uint64Key(1<<63), uint64(3))
+	if min := avltree.Min(tree); min == nil || min.Key() != Uint64Key(1) {
+		t.Fatal("wrong min", min)
+	}
+	if max := avltree.Max(tree); max == nil || max.Key() != Uint64Key(math.MaxUint64) {
+		t.Fatal("wrong max", max)
+	}
+}
+
+func TestFloat64Value(t *testing.T) {
+
+	f := func(k int64, v float64) bool {
+		tree := NewWithTypes(KeyTypeInt64, ValueTypeFloat64, false)
+		avltree.Insert(tree, false, Int64Key(k), v)
+		node := avltree.Find(tree, Int64Key(k))
+		if node == nil || node.Value().(float64) != v {
+			return false
+		}
+		avltree.Replace(tree, Int64Key(k), v*2)
+		node = avltree.Find(tree, Int64Key(k))
+		return node != nil && node.Value().(float64) == v*2
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := NewWithTypes(KeyTypeInt64, ValueTypeFloat64, false)
+	avltree.Insert(tree, false, Int64Key(1), math.Inf(-1))
+	avltree.Insert(tree, false, Int64Key(2), math.NaN())
+	avltree.Insert(tree, false, Int64Key(3), math.Copysign(0, -1))
+	if v := avltree.Find(tree, Int64Key(1)).Value().(float64); !math.IsInf(v, -1) {
+		t.Fatal("wrong value", v)
+	}
+	if v := avltree.Find(tree, Int64Key(2)).Value().(float64); !math.IsNaN(v) {
+		t.Fatal("wrong value", v)
+	}
+	if v := avltree.Find(tree, Int64Key(3)).Value().(float64); v != 0 || !math.Signbit(v) {
+		t.Fatal("wrong value", v)
+	}
+}
+
+func TestWrongValueType(t *testing.T) {
+	tree := NewWithTypes(KeyTypeInt64, ValueTypeFloat64, false)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	avltree.Insert(tree, false, Int64Key(1), int64(1))
+}
+
+func TestWrongKeyType(t *testing.T) {
+	tree := NewWithTypes(KeyTypeUint64, ValueTypeInt64, false)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	avltree.Insert(tree, false, Int64Key(1), int64(1))
+}
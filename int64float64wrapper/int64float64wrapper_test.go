@@ -0,0 +1,60 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package int64float64wrapper
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// ラッパーの実装はintwrapperと同じテンプレートから生成しているため
+// ここではキーと値の型が異なることによる値の受け渡しだけを確認する
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree": simpletree.New,
+	"int64arraytree": func(allowDuplicateKeys bool) avltree.Tree {
+		return int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeFloat64, allowDuplicateKeys)
+	},
+}
+
+func sameFloat(a, b float64) bool {
+	return math.Float64bits(a) == math.Float64bits(b)
+}
+
+func TestFloat64Values(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(values map[int64]float64) bool {
+			values[-1] = math.Inf(-1)
+			values[0] = math.Copysign(0, -1)
+			values[1] = math.NaN()
+			tree := New(newTree(false))
+			for key, value := range values {
+				tree.Insert(key, value)
+			}
+			ok := true
+			tree.UpdateIterate(func(key int64, oldValue float64) (newValue float64, keepOldValue, breakIteration bool) {
+				ok = ok && sameFloat(oldValue, values[key])
+				newValue = oldValue / 2
+				return
+			})
+			tree.Iterate(func(node Node) (breakIteration bool) {
+				ok = ok && sameFloat(node.Value(), values[node.Key()]/2)
+				return
+			})
+			deleted := tree.Delete(1)
+			return ok && deleted != nil && math.IsNaN(deleted.Value()) && tree.Count() == len(values)-1
+		}
+
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// int64型をそのままキーにしてある
+// int64型の自然順序をそのままキーの順序としてある
+package int64key
+
+import "github.com/neetsdkasu/avltree"
+
+type Int64Key int64
+
+func (key Int64Key) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	v1 := int64(key)
+	v2 := int64(other.(Int64Key))
+	switch {
+	case v1 < v2:
+		return avltree.LessThanOtherKey
+	case v1 > v2:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (key Int64Key) Copy() avltree.Key {
+	return key
+}
@@ -0,0 +1,61 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package int64key
+
+import (
+	"fmt"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+func TestInt64Key(t *testing.T) {
+	f := func(k1, k2 int64) bool {
+		var key1 avltree.Key = Int64Key(k1)
+		var key2 avltree.Key = Int64Key(k2)
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return k1 < k2
+		case avltree.EqualToOtherKey:
+			return k1 == k2
+		case avltree.GreaterThanOtherKey:
+			return k1 > k2
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	avltree.Insert(tree, false, Int64Key(1<<40), 345)
+	avltree.Insert(tree, false, Int64Key(67), 890)
+	avltree.Insert(tree, false, Int64Key(333), 666)
+	avltree.Insert(tree, false, Int64Key(-1<<40), 12345)
+	avltree.Delete(tree, Int64Key(67))
+	avltree.Update(tree, Int64Key(333), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) * 3
+		return
+	})
+	if node := avltree.Find(tree, Int64Key(1<<40)); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 1099511627776 345
+	// Iterate! -1099511627776 12345
+	// Iterate! 333 1998
+	// Iterate! 1099511627776 345
+}
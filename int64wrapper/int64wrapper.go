@@ -0,0 +1,499 @@
+// Code generated by internal/wrappergen from wrapper.go.tmpl; DO NOT EDIT.
+
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのためのラッパーの実装例
+// キーと値のやりとりをint64型に制限する
+// 内部での実際のキーはint64keyのInt64Keyを使用している
+//
+// int64型に制限するのはラッパーのメソッドの引数や戻り値だけではなく
+// KeyAndValue,Node,AlterNode,AlterRequestもint64wrapper独自でint64型用に定義しなおされている
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree/int64wrapper"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_int64wrapper() {
+//			tree := simpletree.New(false)
+//			w := int64wrapper.New(tree)
+//			w.Insert(12, 345)
+//			w.Insert(67, 890)
+//			w.Insert(333, 666)
+//			w.Insert(-5, 12345)
+//			w.Delete(67)
+//			w.Update(333, func(key, oldValue int64) (newValue int64, keepOldValue bool) {
+//				newValue = oldValue * 3
+//				return
+//			})
+//			if node := w.Find(12); node != nil {
+//				fmt.Println("Find!", node.Key(), node.Value())
+//			}
+//			w.Iterate(func(node int64wrapper.Node) (breakIteration bool) {
+//				fmt.Println("Iterate!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Find! 12 345
+//			// Iterate! -5 12345
+//			// Iterate! 12 345
+//			// Iterate! 333 1998
+//		}
+//
+package int64wrapper
+
+import (
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64key"
+)
+
+type IterateCallBack = func(node Node) (breakIteration bool)
+type UpdateValueCallBack = func(key, oldValue int64) (newValue int64, keepOldValue bool)
+type UpdateIterateCallBack = func(key, oldValue int64) (newValue int64, keepOldValue, breakIteration bool)
+type DeleteIterateCallBack = func(key, value int64) (deleteNode, breakIteration bool)
+type AlterNodeCallBack = func(node AlterNode) (request AlterRequest)
+type AlterIterateCallBack = func(node AlterNode) (request AlterRequest, breakIteration bool)
+
+type Int64AVLTree struct {
+	Tree avltree.Tree
+}
+
+type KeyAndValue interface {
+	Key() int64
+	Value() int64
+}
+
+type keyAndValueWrapper struct {
+	inner avltree.KeyAndValue
+}
+
+type Node interface {
+	KeyAndValue
+	LeftChild() Node
+	RightChild() Node
+	SetValue(newValue int64)
+}
+
+type nodeWrapper struct {
+	inner avltree.Node
+}
+
+type AlterNode interface {
+	KeyAndValue
+	Keep() AlterRequest
+	Replace(newValue int64) AlterRequest
+	Delete() AlterRequest
+}
+
+type alterNodeWrapper struct {
+	inner avltree.AlterNode
+}
+
+type AlterRequest struct {
+	inner avltree.AlterRequest
+}
+
+func New(tree avltree.Tree) *Int64AVLTree {
+	return &Int64AVLTree{tree}
+}
+
+func (tree *Int64AVLTree) Insert(key, value int64) (ok bool) {
+	tree.Tree, ok = avltree.Insert(tree.Tree, false, int64key.Int64Key(key), value)
+	return
+}
+
+func (tree *Int64AVLTree) InsertOrReplace(key, value int64) (ok bool) {
+	tree.Tree, ok = avltree.Insert(tree.Tree, true, int64key.Int64Key(key), value)
+	return
+}
+
+func (tree *Int64AVLTree) Delete(key int64) (deletedValue KeyAndValue) {
+	var tempDeletedValue avltree.KeyAndValue
+	tree.Tree, tempDeletedValue = avltree.Delete(tree.Tree, int64key.Int64Key(key))
+	deletedValue = wrapKeyAndValue(tempDeletedValue)
+	return
+}
+
+func (tree *Int64AVLTree) Update(key int64, callBack UpdateValueCallBack) (ok bool) {
+	tree.Tree, ok = avltree.Update(tree.Tree, int64key.Int64Key(key), wrapUpdateValueCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) Replace(key int64, value int64) (ok bool) {
+	tree.Tree, ok = avltree.Replace(tree.Tree, int64key.Int64Key(key), value)
+	return
+}
+
+func (tree *Int64AVLTree) Alter(key int64, callBack AlterNodeCallBack) (deletedValue KeyAndValue, ok bool) {
+	var tempDeletedValue avltree.KeyAndValue
+	tree.Tree, tempDeletedValue, ok = avltree.Alter(tree.Tree, int64key.Int64Key(key), wrapAlterNodeCallBack(callBack))
+	deletedValue = wrapKeyAndValue(tempDeletedValue)
+	return
+}
+
+func (tree *Int64AVLTree) Clear() {
+	tree.Tree = avltree.Clear(tree.Tree)
+}
+
+func (tree *Int64AVLTree) Release() {
+	avltree.Release(&tree.Tree)
+}
+
+func (tree *Int64AVLTree) Find(key int64) (node Node) {
+	return wrapNode(avltree.Find(tree.Tree, int64key.Int64Key(key)))
+}
+
+func (tree *Int64AVLTree) Iterate(callBack IterateCallBack) {
+	avltree.Iterate(tree.Tree, false, wrapIterateCallBack(callBack))
+}
+
+func (tree *Int64AVLTree) IterateRev(callBack IterateCallBack) {
+	avltree.Iterate(tree.Tree, true, wrapIterateCallBack(callBack))
+}
+
+func (tree *Int64AVLTree) Range(lower, upper int64) (nodes []Node) {
+	return wrapNodes(avltree.Range(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper)))
+}
+
+func (tree *Int64AVLTree) RangeRev(lower, upper int64) (nodes []Node) {
+	return wrapNodes(avltree.Range(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper)))
+}
+
+func (tree *Int64AVLTree) RangeIterate(lower, upper int64, callBack IterateCallBack) {
+	avltree.RangeIterate(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapIterateCallBack(callBack))
+}
+
+func (tree *Int64AVLTree) RangeIterateRev(lower, upper int64, callBack IterateCallBack) {
+	avltree.RangeIterate(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapIterateCallBack(callBack))
+}
+
+func (tree *Int64AVLTree) Count() int {
+	return avltree.Count(tree.Tree)
+}
+
+func (tree *Int64AVLTree) CountRange(lower, upper int64) int {
+	return avltree.CountRange(tree.Tree, int64key.Int64Key(lower), int64key.Int64Key(upper))
+}
+
+func (tree *Int64AVLTree) Min() (node Node) {
+	return wrapNode(avltree.Min(tree.Tree))
+}
+
+func (tree *Int64AVLTree) Max() (node Node) {
+	return wrapNode(avltree.Max(tree.Tree))
+}
+
+func (tree *Int64AVLTree) DeleteAll(key int64) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteAll(tree.Tree, int64key.Int64Key(key))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) UpdateAll(key int64, callBack UpdateValueCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateAll(tree.Tree, int64key.Int64Key(key), wrapUpdateValueCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) ReplaceAll(key int64, value int64) (ok bool) {
+	tree.Tree, ok = avltree.ReplaceAll(tree.Tree, int64key.Int64Key(key), value)
+	return
+}
+
+func (tree *Int64AVLTree) AlterAll(key int64, callBack AlterNodeCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterAll(tree.Tree, int64key.Int64Key(key), wrapAlterNodeCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) FindAll(key int64) (nodes []Node) {
+	return wrapNodes(avltree.FindAll(tree.Tree, int64key.Int64Key(key)))
+}
+
+func (tree *Int64AVLTree) MinAll() (nodea []Node) {
+	return wrapNodes(avltree.MinAll(tree.Tree))
+}
+
+func (tree *Int64AVLTree) MaxAll() (nodes []Node) {
+	return wrapNodes(avltree.MaxAll(tree.Tree))
+}
+
+func (tree *Int64AVLTree) DeleteIterate(callBack DeleteIterateCallBack) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteIterate(tree.Tree, false, wrapDeleteIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) DeleteIterateRev(callBack DeleteIterateCallBack) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteIterate(tree.Tree, true, wrapDeleteIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) DeleteRange(lower, upper int64) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteRange(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) DeleteRangeRev(lower, upper int64) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteRange(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) DeleteRangeIterate(lower, upper int64, callBack DeleteIterateCallBack) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteRangeIterate(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapDeleteIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) DeleteRangeIterateRev(lower, upper int64, callBack DeleteIterateCallBack) (deletedValues []KeyAndValue) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues = avltree.DeleteRangeIterate(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapDeleteIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) UpdateIterate(callBack UpdateIterateCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateIterate(tree.Tree, false, wrapUpdateIterateCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) UpdateIterateRev(callBack UpdateIterateCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateIterate(tree.Tree, true, wrapUpdateIterateCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) UpdateRange(lower, upper int64, callBack UpdateValueCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateRange(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapUpdateValueCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) UpdateRangeRev(lower, upper int64, callBack UpdateValueCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateRange(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapUpdateValueCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) UpdateRangeIterate(lower, upper int64, callBack UpdateIterateCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateRangeIterate(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapUpdateIterateCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) UpdateRangeIterateRev(lower, upper int64, callBack UpdateIterateCallBack) (ok bool) {
+	tree.Tree, ok = avltree.UpdateRangeIterate(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapUpdateIterateCallBack(callBack))
+	return
+}
+
+func (tree *Int64AVLTree) ReplaceRange(lower, upper int64, value int64) (ok bool) {
+	tree.Tree, ok = avltree.ReplaceRange(tree.Tree, int64key.Int64Key(lower), int64key.Int64Key(upper), value)
+	return
+}
+
+func (tree *Int64AVLTree) AlterIterate(callBack AlterIterateCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterIterate(tree.Tree, false, wrapAlterIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) AlterIterateRev(callBack AlterIterateCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterIterate(tree.Tree, true, wrapAlterIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) AlterRange(lower, upper int64, callBack AlterNodeCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterRange(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapAlterNodeCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) AlterRangeRev(lower, upper int64, callBack AlterNodeCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterRange(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapAlterNodeCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) AlterRangeIterate(lower, upper int64, callBack AlterIterateCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterRangeIterate(tree.Tree, false, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapAlterIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func (tree *Int64AVLTree) AlterRangeIterateRev(lower, upper int64, callBack AlterIterateCallBack) (deletedValues []KeyAndValue, ok bool) {
+	var tempDeletedValues []avltree.KeyAndValue
+	tree.Tree, tempDeletedValues, ok = avltree.AlterRangeIterate(tree.Tree, true, int64key.Int64Key(lower), int64key.Int64Key(upper), wrapAlterIterateCallBack(callBack))
+	deletedValues = wrapKeyAndValues(tempDeletedValues)
+	return
+}
+
+func wrapKeyAndValue(kv avltree.KeyAndValue) KeyAndValue {
+	if kv == nil {
+		return nil
+	} else {
+		return &keyAndValueWrapper{kv}
+	}
+}
+
+func wrapKeyAndValues(kvs []avltree.KeyAndValue) []KeyAndValue {
+	if kvs == nil {
+		return nil
+	} else {
+		wrapped := make([]KeyAndValue, len(kvs))
+		for i, kv := range kvs {
+			wrapped[i] = &keyAndValueWrapper{kv}
+		}
+		return wrapped
+	}
+}
+
+func wrapNode(node avltree.Node) Node {
+	if node == nil {
+		return nil
+	} else {
+		return &nodeWrapper{node}
+	}
+}
+
+func wrapNodes(nodes []avltree.Node) []Node {
+	if nodes == nil {
+		return nil
+	} else {
+		wrapped := make([]Node, len(nodes))
+		for i, node := range nodes {
+			wrapped[i] = &nodeWrapper{node}
+		}
+		return wrapped
+	}
+}
+
+func wrapIterateCallBack(callBack IterateCallBack) avltree.IterateCallBack {
+	return func(node avltree.Node) (breakIteration bool) {
+		return callBack(&nodeWrapper{node})
+	}
+}
+
+func wrapUpdateValueCallBack(callBack UpdateValueCallBack) avltree.UpdateValueCallBack {
+	return func(key avltree.Key, value interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue, keepOldValue = callBack(int64(key.(int64key.Int64Key)), value.(int64))
+		return
+	}
+}
+func wrapUpdateIterateCallBack(callBack UpdateIterateCallBack) avltree.UpdateIterateCallBack {
+	return func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue, breakIteration bool) {
+		newValue, keepOldValue, breakIteration = callBack(int64(key.(int64key.Int64Key)), oldValue.(int64))
+		return
+	}
+}
+
+func wrapDeleteIterateCallBack(callBack DeleteIterateCallBack) avltree.DeleteIterateCallBack {
+	return func(key avltree.Key, value interface{}) (deleteNode, breakIteration bool) {
+		return callBack(int64(key.(int64key.Int64Key)), value.(int64))
+	}
+}
+
+func wrapAlterNodeCallBack(callBack AlterNodeCallBack) avltree.AlterNodeCallBack {
+	return func(node avltree.AlterNode) (request avltree.AlterRequest) {
+		return callBack(&alterNodeWrapper{node}).inner
+	}
+}
+
+func wrapAlterIterateCallBack(callBack AlterIterateCallBack) avltree.AlterIterateCallBack {
+	return func(node avltree.AlterNode) (request avltree.AlterRequest, breakIteration bool) {
+		req, breakIteration := callBack(&alterNodeWrapper{node})
+		return req.inner, breakIteration
+	}
+}
+
+func (kv *keyAndValueWrapper) Key() int64 {
+	return int64(kv.inner.Key().(int64key.Int64Key))
+}
+
+func (kv *keyAndValueWrapper) Value() int64 {
+	return kv.inner.Value().(int64)
+}
+
+func (node *nodeWrapper) Key() int64 {
+	return int64(node.inner.Key().(int64key.Int64Key))
+}
+
+func (node *nodeWrapper) Value() int64 {
+	return node.inner.Value().(int64)
+}
+
+func (node *nodeWrapper) LeftChild() Node {
+	return wrapNode(node.inner.LeftChild())
+}
+
+func (node *nodeWrapper) RightChild() Node {
+	return wrapNode(node.inner.RightChild())
+}
+
+func (node *nodeWrapper) SetValue(newValue int64) {
+	node.inner.SetValue(newValue)
+}
+
+// 内部で保持している実際のノード(avltree.Node)へアクセスするためのメソッド(バックドア？)
+func (node *nodeWrapper) Node() avltree.Node {
+	return node.inner
+}
+
+func (node *alterNodeWrapper) Key() int64 {
+	return int64(node.inner.Key().(int64key.Int64Key))
+}
+
+func (node *alterNodeWrapper) Value() int64 {
+	return node.inner.Value().(int64)
+}
+
+func (*alterNodeWrapper) Keep() (request AlterRequest) {
+	return
+}
+
+func (*alterNodeWrapper) Replace(newValue int64) (request AlterRequest) {
+	request.inner.Replace(newValue)
+	return
+}
+
+func (*alterNodeWrapper) Delete() (request AlterRequest) {
+	request.inner.Delete()
+	return
+}
+
+// AlterRequest内部で保持しているノードにアクセスするメソッド
+func (node *alterNodeWrapper) Node() Node {
+	if nodeGetter, ok := node.inner.(interface{ Node() avltree.Node }); ok {
+		return wrapNode(nodeGetter.Node())
+	} else {
+		return nil
+	}
+}
+
+func (request *AlterRequest) Keep() AlterRequest {
+	request.inner.Keep()
+	return *request
+}
+
+func (request *AlterRequest) Replace(newValue int64) AlterRequest {
+	request.inner.Replace(newValue)
+	return *request
+}
+
+func (request *AlterRequest) Delete() AlterRequest {
+	request.inner.Delete()
+	return *request
+}
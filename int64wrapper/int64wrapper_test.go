@@ -0,0 +1,61 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package int64wrapper
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// ラッパーの実装はintwrapperと同じテンプレートから生成しているため
+// ここではint64型の値の範囲と順序だけを確認する
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree": simpletree.New,
+	"int64arraytree": func(allowDuplicateKeys bool) avltree.Tree {
+		return int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeInt64, allowDuplicateKeys)
+	},
+}
+
+func TestInt64Range(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(values map[int64]int64) bool {
+			values[math.MinInt64] = math.MaxInt64
+			values[math.MaxInt64] = math.MinInt64
+			tree := New(newTree(false))
+			keys := []int64{}
+			for key, value := range values {
+				tree.Insert(key, value)
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i] < keys[j]
+			})
+			nodes := tree.Range(math.MinInt64, math.MaxInt64)
+			if len(nodes) != len(keys) || tree.Min().Key() != math.MinInt64 || tree.Max().Key() != math.MaxInt64 {
+				return false
+			}
+			for i, node := range nodes {
+				if node.Key() != keys[i] || node.Value() != values[keys[i]] {
+					return false
+				}
+			}
+			return tree.CountRange(math.MinInt64, -1) == sort.Search(len(keys), func(i int) bool {
+				return keys[i] >= 0
+			})
+		}
+
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
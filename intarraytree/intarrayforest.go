@@ -0,0 +1,121 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package intarraytree
+
+import (
+	"github.com/neetsdkasu/avltree"
+)
+
+// １つのIntArrayTreeの配列上に複数の独立した木(森)を構築する
+//
+// 全ての木は配列と再利用可能なノードのチェーンを共有する
+// 各木のルートノードのインデックスと同一キーを許可するかどうかの値は
+// ノード１つ分の領域(木のヘッダ)に保持し、この領域も共有のチェーンから確保する
+//
+// 木のヘッダは以下の情報を保持(残りの要素は未使用)
+// + ルートノードのインデックス
+// + 同一キーを許可するかどうかの値
+//
+// 配列の先頭にある本来の木の情報のうちルートノードのインデックスは使用しない
+//
+// NewTreeで得た木はavltree.Releaseで破棄でき、その木のノードとヘッダは共有のチェーンに戻され他の木で再利用される
+// Storageに対して直接Insertなどの操作やavltree.Clearを行ってはならない
+type IntArrayForest struct {
+	Storage *IntArrayTree
+}
+
+// IntArrayForest上の１つの木
+// Positionは木のヘッダのインデックス
+type IntArrayForestTree struct {
+	Forest   *IntArrayForest
+	Position int
+}
+
+const (
+	OffsetTreeRootPosition int = iota
+	OffsetTreeDuplicateKeysBehavior
+)
+
+func NewForest() *IntArrayForest {
+	return NewForestWithInitialCapacity(HeaderSize)
+}
+
+func NewForestWithInitialCapacity(initialCapacity int) *IntArrayForest {
+	storage := &IntArrayTree{make([]int, initialCapacity)}
+	storage.Init(false)
+	return &IntArrayForest{storage}
+}
+
+// 森に新しい空の木を追加する
+func (forest *IntArrayForest) NewTree(allowDuplicateKeys bool) avltree.Tree {
+	storage := forest.Storage
+	storage.init()
+	position := storage.allocate()
+	array := storage.Array
+	for i := 0; i < NodeSize; i++ {
+		array[position+i] = 0
+	}
+	array[position+OffsetTreeRootPosition] = NodeIsNothing
+	if allowDuplicateKeys {
+		array[position+OffsetTreeDuplicateKeysBehavior] = AllowDuplicateKeys
+	} else {
+		array[position+OffsetTreeDuplicateKeysBehavior] = DisallowDuplicateKeys
+	}
+	return &IntArrayForestTree{
+		Forest:   forest,
+		Position: position,
+	}
+}
+
+func (tree *IntArrayForestTree) storage() *IntArrayTree {
+	return tree.Forest.Storage
+}
+
+func (tree *IntArrayForestTree) get(offset int) int {
+	return tree.storage().Array[tree.Position+offset]
+}
+
+func (tree *IntArrayForestTree) set(offset, value int) {
+	tree.storage().Array[tree.Position+offset] = value
+}
+
+func (tree *IntArrayForestTree) getRoot() *IntArrayTreeNode {
+	return tree.storage().getNode(tree.get(OffsetTreeRootPosition))
+}
+
+func (tree *IntArrayForestTree) Root() avltree.Node {
+	return tree.getRoot().toNode()
+}
+
+func (tree *IntArrayForestTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	return tree.storage().NewNode(leftChild, rightChild, height, key, value)
+}
+
+func (tree *IntArrayForestTree) ReleaseNode(node avltree.RealNode) {
+	tree.storage().ReleaseNode(node)
+}
+
+func (tree *IntArrayForestTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.set(OffsetTreeRootPosition, unwrap(newRoot))
+	tree.getRoot().setParent(NodeIsNothing)
+	return tree
+}
+
+func (tree *IntArrayForestTree) AllowDuplicateKeys() bool {
+	return tree.get(OffsetTreeDuplicateKeysBehavior) == AllowDuplicateKeys
+}
+
+func (tree *IntArrayForestTree) NodeCount() int {
+	return tree.getRoot().NodeCount()
+}
+
+// 木のヘッダを共有のチェーンに戻す
+// ノードはavltree.Releaseの内部で先に共有のチェーンに戻されている
+// 以降この木を使用するとpanicになる
+func (tree *IntArrayForestTree) ReleaseTree() {
+	storage := tree.storage()
+	storage.ReleaseNode(storage.getNode(tree.Position))
+	tree.Forest = nil
+	tree.Position = NodeIsNothing
+}
@@ -0,0 +1,175 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package intarraytree
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func TestForestNewTree(t *testing.T) {
+	forest := NewForest()
+	tree1 := forest.NewTree(false)
+	tree2 := forest.NewTree(true)
+	avltree.Insert(tree1, false, IntKey(10), 100)
+	avltree.Insert(tree2, false, IntKey(20), 200)
+
+	want := &IntArrayTree{[]int{
+		// header
+		NodeIsNothing,         // root position (unused)
+		DisallowDuplicateKeys, // duplicate keys behavior (unused)
+		NodeIsNothing,         // idle node position
+		// header of tree1
+		HeaderSize + NodeSize*2, // root position
+		DisallowDuplicateKeys,   // duplicate keys behavior
+		0, 0, 0, 0, 0,
+		// header of tree2
+		HeaderSize + NodeSize*3, // root position
+		AllowDuplicateKeys,      // duplicate keys behavior
+		0, 0, 0, 0, 0,
+		// node of tree1
+		NodeIsNothing, // left child position
+		NodeIsNothing, // right child position
+		1,             // height
+		NodeIsNothing, // parent position
+		1,             // node count
+		10,            // key
+		100,           // value
+		// node of tree2
+		NodeIsNothing, // left child position
+		NodeIsNothing, // right child position
+		1,             // height
+		NodeIsNothing, // parent position
+		1,             // node count
+		20,            // key
+		200,           // value
+	}}
+
+	if !reflect.DeepEqual(forest.Storage, want) {
+		t.Fatalf("want %#v but %#v", want, forest.Storage)
+	}
+}
+
+func TestForestIndependentTrees(t *testing.T) {
+	f := func(allList [][]keyAndValue) [][]int {
+		forest := NewForest()
+		trees := []Tree{}
+		for range allList {
+			trees = append(trees, forest.NewTree(false))
+		}
+		for k := 0; ; k++ {
+			inserted := false
+			for i, list := range allList {
+				if k < len(list) {
+					avltree.Insert(trees[i], false, IntKey(list[k].Key), list[k].Value)
+					inserted = true
+				}
+			}
+			if !inserted {
+				break
+			}
+		}
+		for i, list := range allList {
+			for k, kv := range list {
+				if k%3 == 0 {
+					avltree.Delete(trees[i], IntKey(kv.Key))
+				}
+			}
+		}
+		result := [][]int{}
+		for _, tree := range trees {
+			if invalidNode := takeInvalidHeightNode(tree); invalidNode != nil {
+				return nil
+			}
+			if invalidNode := takeInvalidBalanceNode(tree); invalidNode != nil {
+				return nil
+			}
+			result = append(result, getAllAscKeyAndValues(tree))
+		}
+		return result
+	}
+
+	g := func(allList [][]keyAndValue) [][]int {
+		result := [][]int{}
+		for _, list := range allList {
+			tree := New(false)
+			for _, kv := range list {
+				avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+			}
+			for k, kv := range list {
+				if k%3 == 0 {
+					avltree.Delete(tree, IntKey(kv.Key))
+				}
+			}
+			result = append(result, getAllAscKeyAndValues(tree))
+		}
+		return result
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForestReleaseTree(t *testing.T) {
+	f := func(allList [][]keyAndValue) bool {
+		forest := NewForest()
+		build := func() []Tree {
+			trees := []Tree{}
+			for _, list := range allList {
+				tree := forest.NewTree(true)
+				for _, kv := range list {
+					avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+				}
+				trees = append(trees, tree)
+			}
+			return trees
+		}
+		trees := build()
+		size := len(forest.Storage.Array)
+		for i := range trees {
+			if i%2 == 0 {
+				avltree.Release(&trees[i])
+			}
+		}
+		for i, tree := range trees {
+			if i%2 == 0 {
+				continue
+			}
+			if avltree.Count(tree) != len(allList[i]) {
+				return false
+			}
+		}
+		for i := range trees {
+			if i%2 != 0 {
+				avltree.Release(&trees[i])
+			}
+		}
+		// 解放したノードとヘッダが再利用されるので配列は大きくならない
+		build()
+		return len(forest.Storage.Array) == size
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForestReleasedTreePanics(t *testing.T) {
+	forest := NewForest()
+	tree := forest.NewTree(false)
+	avltree.Insert(tree, false, IntKey(1), 1)
+	handle := tree.(*IntArrayForestTree)
+	avltree.Release(&tree)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on released tree")
+		}
+	}()
+	handle.Root()
+}
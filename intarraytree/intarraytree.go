@@ -23,6 +23,8 @@
 // + ノードのキー
 // + ノードの値
 //
+// NewForestを使うと１つの配列上に複数の独立した木を構築することもできる(IntArrayForestを参照)
+//
 // コード例
 //
 //		import (
@@ -179,8 +181,8 @@ func (tree *IntArrayTree) ReleaseNode(node avltree.RealNode) {
 	}
 }
 
-func (tree *IntArrayTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
-	tree.init()
+// 再利用可能なノードがあればそれを、なければ配列の末尾に新しいノード分の領域を確保し、そのインデックスを返す
+func (tree *IntArrayTree) allocate() int {
 	array := tree.Array
 	newNodePosition := array[PositionIdleNodePosition]
 	if newNodePosition == NodeIsNothing {
@@ -192,7 +194,12 @@ func (tree *IntArrayTree) NewNode(leftChild, rightChild avltree.Node, height int
 		nextIdleNodePosition := array[newNodePosition]
 		array[PositionIdleNodePosition] = nextIdleNodePosition
 	}
-	node := tree.getNode(newNodePosition)
+	return newNodePosition
+}
+
+func (tree *IntArrayTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	tree.init()
+	node := tree.getNode(tree.allocate())
 	node.set(OffsetLeftChildPosition, unwrap(leftChild))
 	node.set(OffsetRightChildPosition, unwrap(rightChild))
 	node.set(OffsetHeight, height)
@@ -0,0 +1,218 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// int64wrapper,uint64wrapper,int64float64wrapperをwrapper.go.tmplから生成する
+//
+// intwrapperと同じ実装をキーと値の型だけ変えて生成している
+// ラッパーの実装を変更する場合はwrapper.go.tmplを変更してからこのディレクトリでgo generateを実行する
+// (wrapper.go.tmplをint型で展開した結果がintwrapperと一致することをテストで確認している)
+package main
+
+//go:generate go run .
+
+import (
+	"bytes"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed wrapper.go.tmpl
+var source string
+
+var wrapperTemplate = template.Must(template.New("wrapper.go.tmpl").Funcs(template.FuncMap{
+	"comment": comment,
+}).Parse(source))
+
+// 生成するラッパー
+type wrapper struct {
+	Package    string // パッケージ名(ディレクトリ名とファイル名にも使う)
+	Tree       string // ラッパーの型名
+	Key        string // キーのやりとりに使う型
+	Value      string // 値のやりとりに使う型
+	KeyPackage string // 内部での実際のキーのパッケージ
+	KeyType    string // 内部での実際のキーの型
+
+	Doc     string // パッケージの説明
+	Example string // パッケージの説明のコード例
+}
+
+var wrappers = []wrapper{
+	{
+		Package:    "int64wrapper",
+		Tree:       "Int64AVLTree",
+		Key:        "int64",
+		Value:      "int64",
+		KeyPackage: "int64key",
+		KeyType:    "Int64Key",
+		Doc: `キーと値のやりとりをint64型に制限する
+内部での実際のキーはint64keyのInt64Keyを使用している
+
+int64型に制限するのはラッパーのメソッドの引数や戻り値だけではなく
+KeyAndValue,Node,AlterNode,AlterRequestもint64wrapper独自でint64型用に定義しなおされている`,
+		Example: `import (
+	"fmt"
+	"github.com/neetsdkasu/avltree/int64wrapper"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+func Example_int64wrapper() {
+	tree := simpletree.New(false)
+	w := int64wrapper.New(tree)
+	w.Insert(12, 345)
+	w.Insert(67, 890)
+	w.Insert(333, 666)
+	w.Insert(-5, 12345)
+	w.Delete(67)
+	w.Update(333, func(key, oldValue int64) (newValue int64, keepOldValue bool) {
+		newValue = oldValue * 3
+		return
+	})
+	if node := w.Find(12); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	w.Iterate(func(node int64wrapper.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 12 345
+	// Iterate! -5 12345
+	// Iterate! 12 345
+	// Iterate! 333 1998
+}`,
+	},
+	{
+		Package:    "uint64wrapper",
+		Tree:       "Uint64AVLTree",
+		Key:        "uint64",
+		Value:      "uint64",
+		KeyPackage: "uint64key",
+		KeyType:    "Uint64Key",
+		Doc: `キーと値のやりとりをuint64型に制限する
+内部での実際のキーはuint64keyのUint64Keyを使用している
+
+uint64型に制限するのはラッパーのメソッドの引数や戻り値だけではなく
+KeyAndValue,Node,AlterNode,AlterRequestもuint64wrapper独自でuint64型用に定義しなおされている`,
+		Example: `import (
+	"fmt"
+	"github.com/neetsdkasu/avltree/uint64wrapper"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+func Example_uint64wrapper() {
+	tree := simpletree.New(false)
+	w := uint64wrapper.New(tree)
+	w.Insert(12, 345)
+	w.Insert(67, 890)
+	w.Insert(333, 666)
+	w.Insert(5, 12345)
+	w.Delete(67)
+	w.Update(333, func(key, oldValue uint64) (newValue uint64, keepOldValue bool) {
+		newValue = oldValue * 3
+		return
+	})
+	if node := w.Find(12); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	w.Iterate(func(node uint64wrapper.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 12 345
+	// Iterate! 5 12345
+	// Iterate! 12 345
+	// Iterate! 333 1998
+}`,
+	},
+	{
+		Package:    "int64float64wrapper",
+		Tree:       "Int64Float64AVLTree",
+		Key:        "int64",
+		Value:      "float64",
+		KeyPackage: "int64key",
+		KeyType:    "Int64Key",
+		Doc: `キーのやりとりをint64型に、値のやりとりをfloat64型に制限する
+内部での実際のキーはint64keyのInt64Keyを使用している
+
+int64型やfloat64型に制限するのはラッパーのメソッドの引数や戻り値だけではなく
+KeyAndValue,Node,AlterNode,AlterRequestもint64float64wrapper独自でint64型とfloat64型用に定義しなおされている`,
+		Example: `import (
+	"fmt"
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	"github.com/neetsdkasu/avltree/int64float64wrapper"
+)
+func Example_int64float64wrapper() {
+	tree := int64arraytree.NewWithTypes(int64arraytree.KeyTypeInt64, int64arraytree.ValueTypeFloat64, false)
+	w := int64float64wrapper.New(tree)
+	w.Insert(1600000000000, 12.5)
+	w.Insert(1600000001000, 13.25)
+	w.Insert(1600000002000, 11.0)
+	w.Insert(1600000003000, 10.75)
+	w.Delete(1600000001000)
+	w.Update(1600000002000, func(key int64, oldValue float64) (newValue float64, keepOldValue bool) {
+		newValue = oldValue * 1.5
+		return
+	})
+	if node := w.Find(1600000000000); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	w.Iterate(func(node int64float64wrapper.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 1600000000000 12.5
+	// Iterate! 1600000000000 12.5
+	// Iterate! 1600000002000 16.5
+	// Iterate! 1600000003000 10.75
+}`,
+	},
+}
+
+// キーと値の引数を並べる
+// キーと値が同じ型の場合は型をまとめて書く
+func (w wrapper) Params(key, value string) string {
+	if w.Key == w.Value {
+		return key + ", " + value + " " + w.Value
+	}
+	return key + " " + w.Key + ", " + value + " " + w.Value
+}
+
+// コード例の行はインデントして各行をコメントにする
+func comment(indent bool, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "":
+			lines[i] = "//"
+		case indent:
+			lines[i] = "//\t\t" + line
+		default:
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (w wrapper) generate() []byte {
+	var buf bytes.Buffer
+	if err := wrapperTemplate.Execute(&buf, w); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// 生成したファイルのパス(このディレクトリからの相対パス)
+func (w wrapper) path() string {
+	return filepath.Join("..", "..", w.Package, w.Package+".go")
+}
+
+func main() {
+	for _, w := range wrappers {
+		if err := os.WriteFile(w.path(), w.generate(), 0644); err != nil {
+			panic(err)
+		}
+	}
+}
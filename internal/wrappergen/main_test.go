@@ -0,0 +1,47 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerated(t *testing.T) {
+	for _, w := range wrappers {
+		got, err := os.ReadFile(w.path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, w.generate()) {
+			t.Fatalf("%s is out of date, run go generate", w.path())
+		}
+	}
+}
+
+// テンプレートをint型で展開するとintwrapperの実装と一致する
+// (生成したラッパーの動作はintwrapperのテストで確認している)
+func TestSameAsIntwrapper(t *testing.T) {
+	w := wrapper{
+		Package:    "intwrapper",
+		Tree:       "IntAVLTree",
+		Key:        "int",
+		Value:      "int",
+		KeyPackage: "intkey",
+		KeyType:    "IntKey",
+	}
+	want, err := os.ReadFile(filepath.Join("..", "..", "intwrapper", "intwrapper.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := w.generate()
+	body := func(src []byte) []byte {
+		return src[bytes.Index(src, []byte("\npackage ")):]
+	}
+	if !bytes.Equal(body(got), body(want)) {
+		t.Fatal("wrapper.go.tmpl differs from intwrapper.go")
+	}
+}
@@ -0,0 +1,212 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+//go:build go1.18
+// +build go1.18
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// 標準パッケージnet/netipのnetip.Addr型(IPアドレス)とnetip.Prefix型(CIDR表記のアドレスの範囲)をキーにしてある
+// net/netipを使うためGo 1.18以降でのみビルドされる
+//
+// AddrKeyはIPv4のアドレスをIPv6のアドレスより小さいものとし、同じ種類のアドレスどうしはバイト列の辞書順で比較する
+// IPv4射影アドレス(::ffff:192.0.2.1など)はIPv4のアドレスに変換し、ゾーンは取り除いてからキーにする
+//
+// PrefixKeyは範囲の先頭のアドレスをAddrKeyと同じ順序で比較し、先頭のアドレスが等しい場合はプレフィックス長の短いほうを小さいものとする
+// そのため、あるアドレスを含む範囲のキーは全てそのアドレスの/32(IPv6では/128)のキー以下になり、
+// 長いプレフィックスほど大きいキーになる
+//
+// LongestPrefixMatchはPrefixKeyをキーとする木で指定のアドレスを含む最もプレフィックス長の長い範囲のノードを返す
+// ContainingPrefixesは指定のアドレスを含む全ての範囲のノードをプレフィックス長の長い順に巡る
+// どちらも木の中の指定の上限以下で最大のキー(RangeIterateを降順で巡った最初のノード)を求めることを繰り返し、
+// アドレスを含まない範囲に当たった場合はそのアドレスとの共通部分の長さまで上限を下げるため、範囲を全て調べることはしない
+package ipkey
+
+import (
+	"math/bits"
+	"net/netip"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type AddrKey netip.Addr
+
+type PrefixKey netip.Prefix
+
+// アドレスのキーを返す
+// addrが無効なアドレス(netip.Addr{})の場合はpanicする
+func NewAddr(addr netip.Addr) AddrKey {
+	if !addr.IsValid() {
+		panic("ipkey: invalid address")
+	}
+	return AddrKey(addr.Unmap().WithZone(""))
+}
+
+// 範囲のキーを返す
+// 範囲の先頭のアドレス以外のビットは取り除かれる(192.0.2.1/24は192.0.2.0/24になる)
+// prefixが無効な範囲の場合はpanicする
+func NewPrefix(prefix netip.Prefix) PrefixKey {
+	if !prefix.IsValid() {
+		panic("ipkey: invalid prefix")
+	}
+	addr, bits := prefix.Addr().WithZone(""), prefix.Bits()
+	if addr.Is4In6() {
+		if bits < 96 {
+			// IPv4射影アドレスの範囲を超えるのでIPv6の範囲のままにする
+			return PrefixKey(netip.PrefixFrom(addr, bits).Masked())
+		}
+		addr, bits = addr.Unmap(), bits-96
+	}
+	return PrefixKey(netip.PrefixFrom(addr, bits).Masked())
+}
+
+func ParseAddr(s string) (AddrKey, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return AddrKey{}, err
+	}
+	return NewAddr(addr), nil
+}
+
+func ParsePrefix(s string) (PrefixKey, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return PrefixKey{}, err
+	}
+	return NewPrefix(prefix), nil
+}
+
+func (key AddrKey) Addr() netip.Addr {
+	return netip.Addr(key)
+}
+
+func (key AddrKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	return avltree.KeyOrdering(key.Addr().Compare(other.(AddrKey).Addr()))
+}
+
+func (key AddrKey) Copy() avltree.Key {
+	return key
+}
+
+func (key AddrKey) String() string {
+	return key.Addr().String()
+}
+
+func (key PrefixKey) Prefix() netip.Prefix {
+	return netip.Prefix(key)
+}
+
+func (key PrefixKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	p1, p2 := key.Prefix(), other.(PrefixKey).Prefix()
+	if c := p1.Addr().Compare(p2.Addr()); c != 0 {
+		return avltree.KeyOrdering(c)
+	}
+	switch {
+	case p1.Bits() < p2.Bits():
+		return avltree.LessThanOtherKey
+	case p1.Bits() > p2.Bits():
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (key PrefixKey) Copy() avltree.Key {
+	return key
+}
+
+func (key PrefixKey) String() string {
+	return key.Prefix().String()
+}
+
+// 範囲に含まれる全てのAddrKeyを範囲とする下限と上限を返す
+func PrefixRange(prefix PrefixKey) (lower, upper AddrKey) {
+	p := prefix.Prefix()
+	first := p.Addr().AsSlice()
+	last := make([]byte, len(first))
+	for i := range first {
+		// 先頭からp.Bits()ビットより後ろのビットを1にする
+		hostBits := len(first)*8 - p.Bits() - (len(first)-1-i)*8
+		switch {
+		case hostBits <= 0:
+			last[i] = first[i]
+		case hostBits >= 8:
+			last[i] = 0xFF
+		default:
+			last[i] = first[i] | byte(1<<hostBits-1)
+		}
+	}
+	lastAddr, _ := netip.AddrFromSlice(last)
+	return AddrKey(p.Addr()), AddrKey(lastAddr)
+}
+
+// addrの先頭からbitsビットの範囲のキー
+func prefixOf(addr netip.Addr, bits int) PrefixKey {
+	prefix, _ := addr.Prefix(bits)
+	return PrefixKey(prefix)
+}
+
+// 2つの同じ種類のアドレスの先頭から共通するビットの長さ
+func commonBits(a, b netip.Addr) int {
+	x, y := a.As16(), b.As16()
+	n := 0
+	for i := range x {
+		if x[i] != y[i] {
+			return n + bits.LeadingZeros8(x[i]^y[i]) - (128 - a.BitLen())
+		}
+		n += 8
+	}
+	return a.BitLen()
+}
+
+// addrを含む範囲のキーを持つノードをプレフィックス長の長い順に探し、見つかるたびにfoundを呼び出す
+// foundがfalseを返した場合は探索を終える
+func searchContaining(tree avltree.Tree, addr netip.Addr, found func(key PrefixKey) bool) {
+	addr = NewAddr(addr).Addr()
+	lower := prefixOf(addr, 0)
+	upper := prefixOf(addr, addr.BitLen())
+	for {
+		var floor avltree.Node
+		avltree.RangeIterate(tree, true, lower, upper, func(node avltree.Node) (breakIteration bool) {
+			floor = node
+			return true
+		})
+		if floor == nil {
+			return
+		}
+		key := floor.Key().(PrefixKey)
+		prefix := key.Prefix()
+		if prefix.Contains(addr) {
+			if !found(key) || prefix.Bits() == 0 {
+				return
+			}
+			// より短い範囲はこの範囲のキーより小さい
+			upper = prefixOf(addr, prefix.Bits()-1)
+		} else {
+			// addrを含む範囲はprefixの先頭のアドレスとaddrとの共通部分より長くはならない
+			upper = prefixOf(addr, commonBits(prefix.Addr(), addr))
+		}
+	}
+}
+
+// PrefixKeyをキーとする木でaddrを含む範囲のうち最もプレフィックス長の長い範囲のキーを持つノードを返す
+// addrを含む範囲が無い場合はnilを返す
+// 木が同一キーを許可している場合は同一キーのノードのうちどのノードを返すかは不定
+func LongestPrefixMatch(tree avltree.Tree, addr netip.Addr) (node avltree.Node) {
+	searchContaining(tree, addr, func(key PrefixKey) bool {
+		node = avltree.Find(tree, key)
+		return false
+	})
+	return
+}
+
+// PrefixKeyをキーとする木でaddrを含む全ての範囲のキーを持つノードをプレフィックス長の長い順に巡ってコールバックを呼び出す
+// 同一キーのノードはRangeIterateの昇順で巡る
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func ContainingPrefixes(tree avltree.Tree, addr netip.Addr, callBack avltree.IterateCallBack) (ok bool) {
+	ok = true
+	searchContaining(tree, addr, func(key PrefixKey) bool {
+		ok = avltree.RangeIterate(tree, false, key, key, callBack)
+		return ok
+	})
+	return
+}
@@ -0,0 +1,208 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+//go:build go1.18
+// +build go1.18
+
+package ipkey
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+// 共通部分の多いアドレスを作る
+func makeAddr(v6 bool, b [4]byte) netip.Addr {
+	if v6 {
+		return netip.AddrFrom16([16]byte{0x20, 0x01, 0x0d, 0xb8, 12: b[0] & 0x0F, 13: b[1] & 0xF0, 14: b[2], 15: b[3] & 0x3})
+	}
+	return netip.AddrFrom4([4]byte{10, b[0] & 0x0F, b[1] & 0xF0, b[2] & 0x3})
+}
+
+type prefixSource struct {
+	V6   bool
+	Addr [4]byte
+	Bits uint8
+}
+
+func (src prefixSource) prefix() netip.Prefix {
+	addr := makeAddr(src.V6, src.Addr)
+	prefix, _ := addr.Prefix(int(src.Bits) % (addr.BitLen() + 1))
+	return prefix
+}
+
+func TestAddrKey(t *testing.T) {
+	f := func(b1, b2 [16]byte, v4a, v4b bool) bool {
+		a1, a2 := netip.AddrFrom16(b1), netip.AddrFrom16(b2)
+		if v4a {
+			a1 = netip.AddrFrom4([4]byte{b1[0], b1[1], b1[2], b1[3]})
+		}
+		if v4b {
+			a2 = netip.AddrFrom4([4]byte{b2[0], b2[1], b2[2], b2[3]})
+		}
+		key1, key2 := NewAddr(a1), NewAddr(a2)
+		cmp := key1.CompareTo(key2)
+		switch {
+		case a1.Unmap().Is4() != a2.Unmap().Is4():
+			return cmp.LessThan() == a1.Unmap().Is4()
+		case a1.Unmap() == a2.Unmap():
+			return cmp.EqualTo()
+		default:
+			return cmp == -key2.CompareTo(key1) && cmp.LessThan() == a1.Unmap().Less(a2.Unmap())
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+
+	mapped, _ := ParseAddr("::ffff:192.0.2.1")
+	plain, _ := ParseAddr("192.0.2.1")
+	zoned, _ := ParseAddr("fe80::1%eth0")
+	if mapped != plain || zoned.String() != "fe80::1" {
+		t.Fatal("not normalized", mapped, zoned)
+	}
+	if _, err := ParseAddr("192.0.2"); err == nil {
+		t.Fatal("no error")
+	}
+}
+
+func TestPrefixKey(t *testing.T) {
+	for _, tc := range []struct{ s, want string }{
+		{"192.0.2.1/24", "192.0.2.0/24"},
+		{"::ffff:192.0.2.1/120", "192.0.2.0/24"},
+		{"::ffff:0:0/80", "::/80"},
+		{"2001:db8::1/32", "2001:db8::/32"},
+	} {
+		if key, err := ParsePrefix(tc.s); err != nil || key.String() != tc.want {
+			t.Fatal("wrong prefix", tc.s, key, err)
+		}
+	}
+	var keys []PrefixKey
+	for _, s := range []string{"10.1.0.0/16", "::/0", "10.0.0.0/16", "10.0.0.0/8", "0.0.0.0/0", "10.0.0.0/32", "2001:db8::/32"} {
+		key, _ := ParsePrefix(s)
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CompareTo(keys[j]).LessThan()
+	})
+	if fmt.Sprint(keys) != "[0.0.0.0/0 10.0.0.0/8 10.0.0.0/16 10.0.0.0/32 10.1.0.0/16 ::/0 2001:db8::/32]" {
+		t.Fatal("wrong order", keys)
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	f := func(src prefixSource, b [4]byte) bool {
+		prefix := src.prefix()
+		addr := makeAddr(src.V6, b)
+		lower, upper := PrefixRange(NewPrefix(prefix))
+		inRange := !NewAddr(addr).CompareTo(lower).LessThan() && !NewAddr(addr).CompareTo(upper).GreaterThan()
+		return inRange == prefix.Contains(addr) && prefix.Contains(lower.Addr()) && prefix.Contains(upper.Addr())
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainingPrefixes(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(sources []prefixSource, v6 bool, b [4]byte) bool {
+			tree := simpletree.New(dup)
+			for i, src := range sources {
+				avltree.Insert(tree, false, NewPrefix(src.prefix()), i)
+			}
+			addr := makeAddr(v6, b)
+			var want []avltree.Node
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				if node.Key().(PrefixKey).Prefix().Contains(addr) {
+					want = append(want, node)
+				}
+				return
+			})
+			// プレフィックス長の長い順にし、同一キーのノードは昇順のままにする
+			sort.SliceStable(want, func(i, j int) bool {
+				return want[i].Key().CompareTo(want[j].Key()).GreaterThan()
+			})
+			var got []avltree.Node
+			if !ContainingPrefixes(tree, addr, func(node avltree.Node) (breakIteration bool) {
+				got = append(got, node)
+				return
+			}) {
+				return false
+			}
+			if len(want) == 0 {
+				return got == nil && LongestPrefixMatch(tree, addr) == nil
+			}
+			longest := LongestPrefixMatch(tree, addr)
+			return reflect.DeepEqual(got, want) && longest.Key().CompareTo(want[0].Key()).EqualTo()
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
+
+func TestCommonBits(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"10.0.0.0", "10.0.0.0", 32},
+		{"10.0.0.0", "10.0.0.1", 31},
+		{"10.0.0.0", "138.0.0.0", 0},
+		{"2001:db8::", "2001:db8::8000", 112},
+		{"::", "8000::", 0},
+	} {
+		if got := commonBits(netip.MustParseAddr(tc.a), netip.MustParseAddr(tc.b)); got != tc.want {
+			t.Fatal("wrong common bits", tc.a, tc.b, got)
+		}
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	for _, rule := range []struct {
+		prefix, action string
+	}{
+		{"0.0.0.0/0", "deny"},
+		{"10.0.0.0/8", "allow"},
+		{"10.1.0.0/16", "deny"},
+		{"10.1.2.0/24", "allow"},
+		{"10.2.0.0/16", "log"},
+		{"2001:db8::/32", "allow"},
+	} {
+		key, _ := ParsePrefix(rule.prefix)
+		avltree.Insert(tree, false, key, rule.action)
+	}
+	for _, s := range []string{"10.1.2.3", "10.1.3.4", "192.0.2.1", "2001:db8::1", "2001:db9::1"} {
+		if node := LongestPrefixMatch(tree, netip.MustParseAddr(s)); node != nil {
+			fmt.Println("Match!", s, node.Key(), node.Value())
+		} else {
+			fmt.Println("NoMatch!", s)
+		}
+	}
+	ContainingPrefixes(tree, netip.MustParseAddr("10.1.2.3"), func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Containing!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Match! 10.1.2.3 10.1.2.0/24 allow
+	// Match! 10.1.3.4 10.1.0.0/16 deny
+	// Match! 192.0.2.1 0.0.0.0/0 deny
+	// Match! 2001:db8::1 2001:db8::/32 allow
+	// NoMatch! 2001:db9::1
+	// Containing! 10.1.2.0/24 allow
+	// Containing! 10.1.0.0/16 deny
+	// Containing! 10.0.0.0/8 allow
+	// Containing! 0.0.0.0/0 deny
+}
@@ -0,0 +1,53 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package merkletree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func genKeyAndValues(n int) []*keyAndValue {
+	list := []*keyAndValue{}
+	for i := 0; i < n; i++ {
+		key := rand.Int()
+		value := rand.Int()
+		list = append(list, &keyAndValue{key, value})
+	}
+	return list
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree := New(true, testCodec)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list[:b.N] {
+		tree, _ = avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	list = list[b.N:]
+	b.ResetTimer()
+	for _, kv := range list {
+		tree, _ = avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+}
+
+func BenchmarkRangeHash(b *testing.B) {
+	tree := New(true, testCodec)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list[:b.N] {
+		tree, _ = avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	list = list[b.N:]
+	mt := tree.(*MerkleTree)
+	b.ResetTimer()
+	for i := 0; i+1 < len(list); i += 2 {
+		lower, upper := list[i].Key, list[i+1].Key
+		if upper < lower {
+			lower, upper = upper, lower
+		}
+		mt.RangeHash(IntKey(lower), IntKey(upper))
+	}
+}
@@ -0,0 +1,379 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのRealTree,RealNodeの実装例
+// immutabletreeと同様の不変ぽい木で、各ノードに部分木の内容のハッシュ値を保持する
+//
+// キーと値をcodecでバイト列に変換したものからSHA-256で3072ビットの整数を作りキーと値の組のハッシュ値とし、
+// 部分木のハッシュ値は部分木に含まれる全ての組のハッシュ値の積(素数p = 2^3072 - 1103717を法とする乗算)とする
+// (MuHashと呼ばれる多重集合のハッシュで、衝突を見つけることは法pの離散対数問題と同程度に難しい)
+// 積は木の形に依らないため、挿入や削除の順序が異なる複製どうしでも内容が同じならRootHashは一致する
+// ハッシュ値は各ノードに384バイトずつ二つ持ち、更新には3072ビットの整数の乗算が伴う
+// RangeHashはキーの範囲のハッシュ値と組の数を木の高さに比例する計算量で求める
+// 複製どうしでRootHashを比較し、一致しない場合は範囲を分割してRangeHashを比較することを繰り返せば
+// 内容の異なる範囲を対数回のやり取りで絞り込むことができる(範囲の分割にはNodeAtが使える)
+//
+// キーや値の変換に失敗した場合はpanicする
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/codec"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/merkletree"
+//		)
+//		func Example_merkletree() {
+//			c := codec.New(codec.IntKey, codec.StringValue)
+//			tree1 := merkletree.New(false, c)
+//			tree2 := merkletree.New(false, c)
+//			for k := 1; k <= 5; k++ {
+//				tree1, _ = avltree.Insert(tree1, false, IntKey(k), "v")
+//				tree2, _ = avltree.Insert(tree2, false, IntKey(6-k), "v")
+//			}
+//			hash1 := tree1.(*merkletree.MerkleTree).RootHash()
+//			hash2 := tree2.(*merkletree.MerkleTree).RootHash()
+//			fmt.Println("RootHash!", hash1 == hash2)
+//			tree2, _ = avltree.Replace(tree2, IntKey(4), "w")
+//			hash2 = tree2.(*merkletree.MerkleTree).RootHash()
+//			fmt.Println("Replaced!", hash1 == hash2)
+//			hash1, count1 := tree1.(*merkletree.MerkleTree).RangeHash(IntKey(1), IntKey(3))
+//			hash2, count2 := tree2.(*merkletree.MerkleTree).RangeHash(IntKey(1), IntKey(3))
+//			fmt.Println("RangeHash!", hash1 == hash2, count1, count2)
+//			hash1, _ = tree1.(*merkletree.MerkleTree).RangeHash(IntKey(4), nil)
+//			hash2, _ = tree2.(*merkletree.MerkleTree).RangeHash(IntKey(4), nil)
+//			fmt.Println("RangeHash!", hash1 == hash2)
+//			fmt.Println("NodeAt!", tree1.(*merkletree.MerkleTree).NodeAt(2).Key())
+//			// Output:
+//			// RootHash! true
+//			// Replaced! false
+//			// RangeHash! true 3 3
+//			// RangeHash! false
+//			// NodeAt! 3
+//		}
+//
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+)
+
+// キーと値の組または組の集まりのハッシュ値
+// ビッグエンディアンの3072ビットの整数とみなし、組の集まりのハッシュ値は各組のハッシュ値の法pでの積
+// 空の集まりのハッシュ値(積の単位元の1)はゼロ値で表す
+type Hash [hashSize]byte
+
+const hashSize = 3072 / 8
+
+// ハッシュ値の法 p = 2^3072 - c (素数)
+var (
+	modulusC = big.NewInt(1103717)
+	modulus  = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 3072), modulusC)
+	lowMask  = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 3072), big.NewInt(1))
+)
+
+// 0以上の整数xを法pで剰余したものにする
+// 2^3072 ≡ c (mod p) を使い上位のビットをc倍して下位のビットに足し込むことを繰り返す
+func reduce(x *big.Int) *big.Int {
+	hi := new(big.Int)
+	for x.BitLen() > 3072 {
+		hi.Rsh(x, 3072)
+		hi.Mul(hi, modulusC)
+		x.And(x, lowMask)
+		x.Add(x, hi)
+	}
+	if x.Cmp(modulus) >= 0 {
+		x.Sub(x, modulus)
+	}
+	return x
+}
+
+type MerkleTree struct {
+	RootNode                *MerkleTreeNode
+	AllowDuplicateKeysValue bool
+	Codec                   codec.Codec
+}
+
+type MerkleTreeNode struct {
+	LeftChildNode  *MerkleTreeNode
+	RightChildNode *MerkleTreeNode
+	HeightValue    int
+	NodeCountValue int
+	KeyData        avltree.Key
+	ValueData      interface{}
+
+	// このノードのキーと値の組のハッシュ値
+	EntryHash Hash
+
+	// このノードをルートとする部分木のハッシュ値
+	HashValue Hash
+
+	codec codec.Codec
+}
+
+func New(allowDuplicateKeys bool, c codec.Codec) avltree.Tree {
+	return &MerkleTree{
+		RootNode:                nil,
+		AllowDuplicateKeysValue: allowDuplicateKeys,
+		Codec:                   c,
+	}
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// ハッシュ値を整数にする
+func (h Hash) toInt() *big.Int {
+	if h == (Hash{}) {
+		return big.NewInt(1)
+	}
+	return new(big.Int).SetBytes(h[:])
+}
+
+// 整数をハッシュ値にする
+func fromInt(x *big.Int) (h Hash) {
+	if x.Cmp(big.NewInt(1)) != 0 {
+		x.FillBytes(h[:])
+	}
+	return
+}
+
+// 二つの集まりを合わせた集まりのハッシュ値を返す(法pでの積)
+func (h Hash) Add(other Hash) Hash {
+	if h == (Hash{}) {
+		return other
+	}
+	if other == (Hash{}) {
+		return h
+	}
+	x := h.toInt()
+	x.Mul(x, other.toInt())
+	return fromInt(reduce(x))
+}
+
+// 集まりからotherの集まりを取り除いた集まりのハッシュ値を返す(法pでの逆元との積)
+// (h.Sub(other).Add(other) == h となる)
+func (h Hash) Sub(other Hash) Hash {
+	if other == (Hash{}) {
+		return h
+	}
+	x := other.toInt()
+	x.ModInverse(x, modulus)
+	x.Mul(x, h.toInt())
+	return fromInt(reduce(x))
+}
+
+// キーと値の組のハッシュ値を計算する
+// キーのバイト列の長さ(uvarint)、キーのバイト列、値のバイト列を連結したもののSHA-256をdとし、
+// SHA-256(i || d) (iは1バイトのカウンタ)を3072ビットになるまで連結した整数を法pで剰余したもの
+func entryHash(c codec.Codec, key avltree.Key, value interface{}) Hash {
+	keyData, err := c.EncodeKey(key)
+	if err != nil {
+		panic(err)
+	}
+	valueData, err := c.EncodeValue(value)
+	if err != nil {
+		panic(err)
+	}
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], uint64(len(keyData)))
+	digest := sha256.New()
+	digest.Write(tmp[:n])
+	digest.Write(keyData)
+	digest.Write(valueData)
+	var d [sha256.Size]byte
+	digest.Sum(d[:0])
+	var h Hash
+	for i := 0; i < hashSize/sha256.Size; i++ {
+		digest.Reset()
+		digest.Write([]byte{byte(i)})
+		digest.Write(d[:])
+		digest.Sum(h[i*sha256.Size : i*sha256.Size])
+	}
+	x := new(big.Int).SetBytes(h[:])
+	return fromInt(reduce(x))
+}
+
+func unwrap(node avltree.Node) *MerkleTreeNode {
+	if node == nil {
+		return nil
+	} else {
+		return node.(*MerkleTreeNode)
+	}
+}
+
+func (node *MerkleTreeNode) toNode() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node
+	}
+}
+
+func (tree *MerkleTree) Root() avltree.Node {
+	return tree.RootNode.toNode()
+}
+
+func (tree *MerkleTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	newNode := &MerkleTreeNode{
+		LeftChildNode:  unwrap(leftChild),
+		RightChildNode: unwrap(rightChild),
+		HeightValue:    height,
+		NodeCountValue: 1,
+		KeyData:        key,
+		ValueData:      value,
+		EntryHash:      entryHash(tree.Codec, key, value),
+		codec:          tree.Codec,
+	}
+	newNode.resetSummary()
+	return newNode
+}
+
+func (tree *MerkleTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	newTree := *tree
+	newTree.RootNode = unwrap(newRoot)
+	return &newTree
+}
+
+func (tree *MerkleTree) AllowDuplicateKeys() bool {
+	return tree.AllowDuplicateKeysValue
+}
+
+func (tree *MerkleTree) NodeCount() int {
+	return tree.RootNode.NodeCount()
+}
+
+// 木の全体のハッシュ値を返す
+func (tree *MerkleTree) RootHash() Hash {
+	return tree.RootNode.Hash()
+}
+
+// キーがlower以上upper以下の組のハッシュ値と組の数を返す
+// lowerがnilの場合は最小のキーから、upperがnilの場合は最大のキーまでが対象になる
+func (tree *MerkleTree) RangeHash(lower, upper avltree.Key) (hash Hash, count int) {
+	return rangeHash(tree.RootNode, lower, upper)
+}
+
+// キーの昇順でindex番目(0始まり)のノードを返す
+// indexが範囲外の場合はnilを返す
+func (tree *MerkleTree) NodeAt(index int) avltree.Node {
+	node := tree.RootNode
+	for node != nil {
+		leftCount := node.LeftChildNode.NodeCount()
+		switch {
+		case index < leftCount:
+			node = node.LeftChildNode
+		case index == leftCount:
+			return node
+		default:
+			index -= leftCount + 1
+			node = node.RightChildNode
+		}
+	}
+	return nil
+}
+
+// 同一キーを許可する木でも正しく求まるように、
+// キーが範囲内のノードについては左右の部分木の両方を調べる
+func rangeHash(node *MerkleTreeNode, lower, upper avltree.Key) (hash Hash, count int) {
+	for node != nil {
+		if lower == nil && upper == nil {
+			return node.HashValue, node.NodeCountValue
+		}
+		if lower != nil && node.KeyData.CompareTo(lower).LessThan() {
+			node = node.RightChildNode
+			continue
+		}
+		if upper != nil && node.KeyData.CompareTo(upper).GreaterThan() {
+			node = node.LeftChildNode
+			continue
+		}
+		// 左の部分木のキーはupper以下、右の部分木のキーはlower以上
+		lowerHash, lowerCount := rangeHash(node.LeftChildNode, lower, nil)
+		upperHash, upperCount := rangeHash(node.RightChildNode, nil, upper)
+		hash = lowerHash.Add(node.EntryHash).Add(upperHash)
+		count = lowerCount + 1 + upperCount
+		return
+	}
+	return
+}
+
+func (node *MerkleTreeNode) resetSummary() {
+	if node != nil {
+		node.NodeCountValue = 1 + node.LeftChildNode.NodeCount() + node.RightChildNode.NodeCount()
+		node.HashValue = node.LeftChildNode.Hash().Add(node.EntryHash).Add(node.RightChildNode.Hash())
+	}
+}
+
+func (node *MerkleTreeNode) NodeCount() int {
+	if node == nil {
+		return 0
+	} else {
+		return node.NodeCountValue
+	}
+}
+
+// このノードをルートとする部分木のハッシュ値を返す
+func (node *MerkleTreeNode) Hash() Hash {
+	if node == nil {
+		return Hash{}
+	} else {
+		return node.HashValue
+	}
+}
+
+func (node *MerkleTreeNode) Key() avltree.Key {
+	return node.KeyData
+}
+
+func (node *MerkleTreeNode) Value() interface{} {
+	return node.ValueData
+}
+
+func (node *MerkleTreeNode) LeftChild() avltree.Node {
+	return node.LeftChildNode.toNode()
+}
+
+func (node *MerkleTreeNode) RightChild() avltree.Node {
+	return node.RightChildNode.toNode()
+}
+
+func (node *MerkleTreeNode) SetValue(newValue interface{}) avltree.Node {
+	newNode := *node
+	newNode.ValueData = newValue
+	newNode.EntryHash = entryHash(node.codec, node.KeyData, newValue)
+	newNode.resetSummary()
+	return &newNode
+}
+
+func (node *MerkleTreeNode) Height() int {
+	return node.HeightValue
+}
+
+func (node *MerkleTreeNode) SetChildren(newLeftChild, newRightChild avltree.Node, newHeight int) avltree.RealNode {
+	newNode := *node
+	newNode.LeftChildNode = unwrap(newLeftChild)
+	newNode.RightChildNode = unwrap(newRightChild)
+	newNode.HeightValue = newHeight
+	newNode.resetSummary()
+	return &newNode
+}
+
+func (node *MerkleTreeNode) Set(newLeftChild, newRightChild avltree.Node, newHeight int, newValue interface{}) avltree.RealNode {
+	newNode := *node
+	newNode.LeftChildNode = unwrap(newLeftChild)
+	newNode.RightChildNode = unwrap(newRightChild)
+	newNode.HeightValue = newHeight
+	newNode.ValueData = newValue
+	newNode.EntryHash = entryHash(node.codec, node.KeyData, newValue)
+	newNode.resetSummary()
+	return &newNode
+}
@@ -0,0 +1,214 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package merkletree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+var testCodec = codec.New(codec.IntKey, codec.IntValue)
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+// 部分木の情報が子から正しく計算されているか
+func checkNode(node *MerkleTreeNode) bool {
+	if node == nil {
+		return true
+	}
+	if node.EntryHash != entryHash(testCodec, node.KeyData, node.ValueData) {
+		return false
+	}
+	if node.NodeCountValue != 1+node.LeftChildNode.NodeCount()+node.RightChildNode.NodeCount() {
+		return false
+	}
+	if node.HashValue != node.LeftChildNode.Hash().Add(node.EntryHash).Add(node.RightChildNode.Hash()) {
+		return false
+	}
+	return checkNode(node.LeftChildNode) && checkNode(node.RightChildNode)
+}
+
+// 範囲内の組のハッシュ値を素朴に掛け合わせる
+func sumHash(tree avltree.Tree, lower, upper avltree.Key) (hash Hash, count int) {
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		if lower != nil && node.Key().CompareTo(lower).LessThan() {
+			return
+		}
+		if upper != nil && node.Key().CompareTo(upper).GreaterThan() {
+			return
+		}
+		hash = hash.Add(entryHash(testCodec, node.Key(), node.Value()))
+		count++
+		return
+	})
+	return
+}
+
+func toIntKey(k *int8) avltree.Key {
+	if k == nil {
+		return nil
+	}
+	return IntKey(*k)
+}
+
+func TestHashAdd(t *testing.T) {
+	f := func(a, b, c Hash) bool {
+		if a.Add(b) != b.Add(a) {
+			return false
+		}
+		if a.Add(b).Add(c) != a.Add(b.Add(c)) {
+			return false
+		}
+		if a.Add(b).Sub(b) != a || a.Sub(b).Add(b) != a {
+			return false
+		}
+		return a.Add(Hash{}) == a && a.Sub(a) == Hash{}
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+	// 1はゼロ値で表す
+	var one, two, minusOne Hash
+	one[len(one)-1] = 1
+	two[len(two)-1] = 2
+	modulus.FillBytes(minusOne[:])
+	minusOne[len(minusOne)-1]--
+	if one.Add(two) != two || two.Sub(two) != (Hash{}) {
+		t.Fatal("wrong identity")
+	}
+	if minusOne.Add(minusOne) != (Hash{}) || (Hash{}).Sub(minusOne) != minusOne {
+		t.Fatal("wrong modulus")
+	}
+}
+
+func TestRangeHash(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(list []keyAndValue, deletes []int8, lower, upper *int8) bool {
+			tree := New(dup, testCodec)
+			for _, kv := range list {
+				tree, _ = avltree.Insert(tree, false, IntKey(int8(kv.Key)), kv.Value)
+			}
+			for _, k := range deletes {
+				tree, _ = avltree.Delete(tree, IntKey(k))
+			}
+			mt := tree.(*MerkleTree)
+			if !checkNode(mt.RootNode) {
+				return false
+			}
+			wantHash, wantCount := sumHash(tree, nil, nil)
+			if mt.RootHash() != wantHash || mt.NodeCount() != wantCount {
+				return false
+			}
+			hash, count := mt.RangeHash(toIntKey(lower), toIntKey(upper))
+			wantHash, wantCount = sumHash(tree, toIntKey(lower), toIntKey(upper))
+			return hash == wantHash && count == wantCount
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
+
+// 同じ内容であれば木の形に依らず同じハッシュ値になる
+func TestShapeIndependence(t *testing.T) {
+	f := func(list []keyAndValue, seed int64) bool {
+		tree1 := New(false, testCodec)
+		for _, kv := range list {
+			tree1, _ = avltree.Insert(tree1, true, IntKey(int8(kv.Key)), kv.Value)
+		}
+		entries := []keyAndValue{}
+		avltree.Iterate(tree1, false, func(node avltree.Node) (breakIteration bool) {
+			entries = append(entries, keyAndValue{int(node.Key().(IntKey)), node.Value().(int)})
+			return
+		})
+		rand.New(rand.NewSource(seed)).Shuffle(len(entries), func(i, j int) {
+			entries[i], entries[j] = entries[j], entries[i]
+		})
+		// 余計なキーを挿入してから削除して形を変える
+		tree2 := New(false, testCodec)
+		for _, kv := range entries {
+			tree2, _ = avltree.Insert(tree2, false, IntKey(kv.Key+1000), 0)
+			tree2, _ = avltree.Insert(tree2, false, IntKey(kv.Key), kv.Value)
+		}
+		tree2, _ = avltree.DeleteRange(tree2, false, IntKey(1000-200), nil)
+		hash1 := tree1.(*MerkleTree).RootHash()
+		hash2 := tree2.(*MerkleTree).RootHash()
+		return hash1 == hash2
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValueChange(t *testing.T) {
+	tree := New(false, testCodec)
+	for k := 0; k < 20; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	saved := tree.(*MerkleTree).RootHash()
+	old := tree
+	tree, _ = avltree.Update(tree, IntKey(7), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		return oldValue.(int) + 1, false
+	})
+	if tree.(*MerkleTree).RootHash() == saved {
+		t.Fatal("hash is not changed")
+	}
+	if old.(*MerkleTree).RootHash() != saved || !checkNode(old.(*MerkleTree).RootNode) {
+		t.Fatal("old version is changed")
+	}
+	hash1, _ := tree.(*MerkleTree).RangeHash(nil, IntKey(6))
+	hash2, _ := old.(*MerkleTree).RangeHash(nil, IntKey(6))
+	hash3, _ := tree.(*MerkleTree).RangeHash(IntKey(8), nil)
+	hash4, _ := old.(*MerkleTree).RangeHash(IntKey(8), nil)
+	if hash1 != hash2 || hash3 != hash4 {
+		t.Fatal("hash of unchanged range is changed")
+	}
+	tree, _ = avltree.Replace(tree, IntKey(7), 7)
+	if tree.(*MerkleTree).RootHash() != saved {
+		t.Fatal("hash is not restored")
+	}
+}
+
+func TestNodeAt(t *testing.T) {
+	f := func(list []keyAndValue) bool {
+		tree := New(true, testCodec)
+		for _, kv := range list {
+			tree, _ = avltree.Insert(tree, false, IntKey(int8(kv.Key)), kv.Value)
+		}
+		want := avltree.Range(tree, false, nil, nil)
+		got := []avltree.Node{}
+		mt := tree.(*MerkleTree)
+		for i := 0; i < len(list); i++ {
+			got = append(got, mt.NodeAt(i))
+		}
+		if len(want) == 0 {
+			want = got
+		}
+		return reflect.DeepEqual(got, want) && mt.NodeAt(-1) == nil && mt.NodeAt(len(list)) == nil
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	tree := New(false, testCodec)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	avltree.Insert(tree, false, IntKey(1), "not int")
+}
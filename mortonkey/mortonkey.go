@@ -0,0 +1,293 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// 2次元または3次元の整数座標をZ順序曲線(Morton順序)の位置に変換してキーにしてある
+// 各座標のビットを下位から交互に並べたuint64型の値をそのままキーの順序としてある
+//  2次元 ... 各座標は32ビット(uint32型の全範囲)
+//  3次元 ... 各座標は21ビット(0からMax3Dまで)
+// 負の座標を扱う場合は利用者側で座標をずらしてから渡す必要がある
+//
+// BoxQueryは指定の矩形(直方体)の範囲にある座標のキーを持つノードをキーの昇順に巡る
+// 矩形の範囲はキーの順序では飛び飛びになるため、範囲外のキーに達した場合はBIGMINで範囲内の次のキーを求めて
+// そこからRangeIterateで巡り直す
+//
+// Nearestは指定の座標に近い(ユークリッド距離の小さい)座標のキーを持つノードを近い順に返す
+//
+// 1つの木に2次元のキーと3次元のキーを混ぜてはならない
+package mortonkey
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type MortonKey uint64
+
+// 座標(2次元の場合は要素数2、3次元の場合は要素数3)
+type Point []uint32
+
+const (
+	// 3次元の座標の各要素の最大値
+	Max3D = 1<<21 - 1
+)
+
+func (key MortonKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	v1 := uint64(key)
+	v2 := uint64(other.(MortonKey))
+	switch {
+	case v1 < v2:
+		return avltree.LessThanOtherKey
+	case v1 > v2:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (key MortonKey) Copy() avltree.Key {
+	return key
+}
+
+// 2次元の座標のキーを返す
+func New2D(x, y uint32) MortonKey {
+	return MortonKey(spread(x, 2) | spread(y, 2)<<1)
+}
+
+// 3次元の座標のキーを返す
+// 座標の要素がMax3Dより大きい場合はpanicする
+func New3D(x, y, z uint32) MortonKey {
+	if x > Max3D || y > Max3D || z > Max3D {
+		panic(fmt.Sprintf("mortonkey: coordinate out of range (%d, %d, %d)", x, y, z))
+	}
+	return MortonKey(spread(x, 3) | spread(y, 3)<<1 | spread(z, 3)<<2)
+}
+
+// 座標のキーを返す
+// 座標の要素数が2でも3でもない場合はpanicする
+func (point Point) Key() MortonKey {
+	switch len(point) {
+	case 2:
+		return New2D(point[0], point[1])
+	case 3:
+		return New3D(point[0], point[1], point[2])
+	default:
+		panic(fmt.Sprintf("mortonkey: invalid dimensions %d", len(point)))
+	}
+}
+
+// 2次元の座標のキーから座標を取り出す
+func (key MortonKey) XY() (x, y uint32) {
+	return compact(uint64(key), 2), compact(uint64(key)>>1, 2)
+}
+
+// 3次元の座標のキーから座標を取り出す
+func (key MortonKey) XYZ() (x, y, z uint32) {
+	return compact(uint64(key), 3), compact(uint64(key)>>1, 3), compact(uint64(key)>>2, 3)
+}
+
+// キーから指定の次元の座標を取り出す
+func (key MortonKey) Point(dims int) Point {
+	switch dims {
+	case 2:
+		x, y := key.XY()
+		return Point{x, y}
+	case 3:
+		x, y, z := key.XYZ()
+		return Point{x, y, z}
+	default:
+		panic(fmt.Sprintf("mortonkey: invalid dimensions %d", dims))
+	}
+}
+
+func (key MortonKey) String() string {
+	return fmt.Sprint(uint64(key))
+}
+
+// vの各ビットをdimsビットごとの位置に広げる
+func spread(v uint32, dims int) (result uint64) {
+	for i := 0; i*dims < 64 && i < 32; i++ {
+		result |= uint64(v>>i&1) << (i * dims)
+	}
+	return
+}
+
+// spreadの逆変換
+func compact(v uint64, dims int) (result uint32) {
+	for i := 0; i*dims < 64 && i < 32; i++ {
+		result |= uint32(v>>(i*dims)&1) << i
+	}
+	return
+}
+
+// 次元dimのビットの位置を表すマスク
+func dimensionMask(dim, dims int) (mask uint64) {
+	for pos := dim; pos < 64; pos += dims {
+		mask |= 1 << pos
+	}
+	return
+}
+
+// 範囲[zmin, zmax]の矩形の外にあるzより大きい矩形の中の最小のキーを返す(BIGMIN)
+// zはzminより大きくzmaxより小さい必要がある
+func bigmin(z, zmin, zmax uint64, dims int) uint64 {
+	var masks [3]uint64
+	for dim := 0; dim < dims; dim++ {
+		masks[dim] = dimensionMask(dim, dims)
+	}
+	result := zmax
+	for pos := 63; pos >= 0; pos-- {
+		bit := uint64(1) << pos
+		mask := masks[pos%dims]
+		// 同じ次元のpos未満のビット
+		lower := mask & (bit - 1)
+		switch z&bit != 0 {
+		case false:
+			switch {
+			case zmin&bit == 0 && zmax&bit != 0:
+				// 1000...をzminに、0111...をzmaxに読み込む
+				result = zmin&^lower | bit
+				zmax = zmax&^bit | lower
+			case zmin&bit != 0:
+				return zmin
+			}
+		case true:
+			switch {
+			case zmax&bit == 0:
+				return result
+			case zmin&bit == 0:
+				zmin = zmin&^lower | bit
+			}
+		}
+	}
+	return result
+}
+
+func checkBox(min, max Point) (dims int) {
+	dims = len(min)
+	if len(max) != dims || (dims != 2 && dims != 3) {
+		panic(fmt.Sprintf("mortonkey: invalid dimensions %d, %d", len(min), len(max)))
+	}
+	return
+}
+
+// 座標が矩形の範囲にあるか
+func inBox(point, min, max Point) bool {
+	for i := range point {
+		if point[i] < min[i] || max[i] < point[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 各要素がminの要素以上maxの要素以下となる座標のキーを持つノードをキーの昇順に巡ってコールバックを呼び出す
+// minとmaxの要素数は2か3で等しい必要があり、そうでない場合はpanicする
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func BoxQuery(tree avltree.Tree, min, max Point, callBack avltree.IterateCallBack) (ok bool) {
+	dims := checkBox(min, max)
+	for i := range min {
+		if min[i] > max[i] {
+			return true
+		}
+	}
+	zmin, zmax := uint64(min.Key()), uint64(max.Key())
+	lower := zmin
+	for {
+		skipped := false
+		ok = avltree.RangeIterate(tree, false, MortonKey(lower), MortonKey(zmax), func(node avltree.Node) (breakIteration bool) {
+			key := node.Key().(MortonKey)
+			if inBox(key.Point(dims), min, max) {
+				return callBack(node)
+			}
+			lower, skipped = bigmin(uint64(key), zmin, zmax, dims), true
+			return true
+		})
+		if !skipped {
+			return ok
+		}
+	}
+}
+
+// 距離の2乗(3次元で各要素の差の2乗の和は64ビットに収まらないので128ビットで表す)
+type distance struct {
+	hi, lo uint64
+}
+
+func (d distance) less(other distance) bool {
+	return d.hi < other.hi || (d.hi == other.hi && d.lo < other.lo)
+}
+
+func squaredDistance(p1, p2 Point) (d distance) {
+	for i := range p1 {
+		diff := uint64(p1[i]) - uint64(p2[i])
+		if p1[i] < p2[i] {
+			diff = uint64(p2[i]) - uint64(p1[i])
+		}
+		hi, lo := bits.Mul64(diff, diff)
+		var carry uint64
+		d.lo, carry = bits.Add64(d.lo, lo, 0)
+		d.hi += hi + carry
+	}
+	return
+}
+
+// pointから近い順にk個のノードを返す
+// 距離が等しいノードどうしはキーの昇順になる
+// 木のノードの総数がk未満の場合は全てのノードを返す
+// pointの要素数は2か3で木のキーと同じ次元である必要がある
+//
+// pointを中心とする矩形をBoxQueryで調べ、矩形の中に距離が矩形の半径以下のノードがk個見つかるまで半径を倍にしていく
+func Nearest(tree avltree.Tree, point Point, k int) (nodes []avltree.Node) {
+	dims := checkBox(point, point)
+	if k <= 0 {
+		return nil
+	}
+	limit := uint64(1<<32 - 1)
+	if dims == 3 {
+		limit = Max3D
+	}
+	type candidate struct {
+		node     avltree.Node
+		distance distance
+	}
+	for radius := uint64(1); ; radius *= 2 {
+		min, max := make(Point, dims), make(Point, dims)
+		whole := true
+		for i, v := range point {
+			if uint64(v) > radius {
+				min[i] = uint32(uint64(v) - radius)
+				whole = false
+			}
+			max[i] = uint32(limit)
+			if uint64(v)+radius < limit {
+				max[i] = uint32(uint64(v) + radius)
+				whole = false
+			}
+		}
+		var candidates []candidate
+		BoxQuery(tree, min, max, func(node avltree.Node) (breakIteration bool) {
+			key := node.Key().(MortonKey)
+			candidates = append(candidates, candidate{node, squaredDistance(key.Point(dims), point)})
+			return
+		})
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].distance.less(candidates[j].distance)
+		})
+		if len(candidates) > k {
+			candidates = candidates[:k]
+		}
+		// 半径より遠いノードは矩形の外にもっと近いノードがある可能性がある
+		hi, lo := bits.Mul64(radius, radius)
+		covered := len(candidates) == k && !(distance{hi, lo}).less(candidates[k-1].distance)
+		if whole || covered {
+			for _, c := range candidates {
+				nodes = append(nodes, c.node)
+			}
+			return nodes
+		}
+	}
+}
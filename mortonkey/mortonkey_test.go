@@ -0,0 +1,216 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package mortonkey
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+func TestMortonKey(t *testing.T) {
+	f := func(x1, y1, x2, y2 uint32) bool {
+		key1, key2 := New2D(x1, y1), New2D(x2, y2)
+		if x, y := key1.XY(); x != x1 || y != y1 {
+			return false
+		}
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return uint64(key1) < uint64(key2)
+		case avltree.EqualToOtherKey:
+			return x1 == x2 && y1 == y2
+		case avltree.GreaterThanOtherKey:
+			return uint64(key1) > uint64(key2)
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+
+	g := func(x, y, z uint32) bool {
+		x, y, z = x&Max3D, y&Max3D, z&Max3D
+		key := New3D(x, y, z)
+		return reflect.DeepEqual(key.Point(3), Point{x, y, z}) && Point{x, y, z}.Key() == key
+	}
+
+	if err := quick.Check(g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBitOrder(t *testing.T) {
+	// xが下位のビット
+	if New2D(1, 0) != 1 || New2D(0, 1) != 2 || New2D(2, 0) != 4 || New3D(0, 0, 1) != 4 || New3D(2, 0, 0) != 8 {
+		t.Fatal("wrong bit order")
+	}
+	if New2D(1<<32-1, 1<<32-1) != 1<<64-1 || New3D(Max3D, Max3D, Max3D) != 1<<63-1 {
+		t.Fatal("wrong max")
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	New3D(0, Max3D+1, 0)
+}
+
+// 小さな空間の矩形を作る
+func smallBox(dims int, a, b [3]uint8) (min, max Point) {
+	min, max = make(Point, dims), make(Point, dims)
+	for i := range min {
+		min[i], max[i] = uint32(a[i]%8), uint32(b[i]%8)
+		if min[i] > max[i] {
+			min[i], max[i] = max[i], min[i]
+		}
+	}
+	return
+}
+
+// 小さな空間の全てのキーで総当たりしてBIGMINを確かめる
+func TestBigmin(t *testing.T) {
+	for _, dims := range []int{2, 3} {
+		f := func(a, b [3]uint8) bool {
+			min, max := smallBox(dims, a, b)
+			zmin, zmax := uint64(min.Key()), uint64(max.Key())
+			for z := zmin + 1; z < zmax; z++ {
+				if inBox(MortonKey(z).Point(dims), min, max) {
+					continue
+				}
+				want := z + 1
+				for !inBox(MortonKey(want).Point(dims), min, max) {
+					want++
+				}
+				if bigmin(z, zmin, zmax, dims) != want {
+					return false
+				}
+			}
+			return true
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dims, err)
+		}
+	}
+}
+
+func TestBoxQuery(t *testing.T) {
+	for _, dims := range []int{2, 3} {
+		for _, dup := range []bool{false, true} {
+			f := func(points [][3]uint8, a, b [3]uint8, limit uint8) bool {
+				tree := simpletree.New(dup)
+				for i, p := range points {
+					point, _ := smallBox(dims, p, p)
+					avltree.Insert(tree, false, point.Key(), i)
+				}
+				min, max := smallBox(dims, a, b)
+				var want, got []avltree.Node
+				avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+					if inBox(node.Key().(MortonKey).Point(dims), min, max) {
+						want = append(want, node)
+					}
+					return
+				})
+				ok := BoxQuery(tree, min, max, func(node avltree.Node) (breakIteration bool) {
+					got = append(got, node)
+					return len(got) == int(limit)
+				})
+				if 0 < int(limit) && int(limit) <= len(want) {
+					return !ok && reflect.DeepEqual(got, want[:limit])
+				}
+				return ok && reflect.DeepEqual(got, want)
+			}
+			if err := quick.Check(f, cfg1000); err != nil {
+				t.Fatal(dims, dup, err)
+			}
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	for _, dims := range []int{2, 3} {
+		f := func(points []Point, target Point, k uint8) bool {
+			limit := uint32(1<<32 - 1)
+			if dims == 3 {
+				limit = Max3D
+			}
+			normalize := func(p Point) Point {
+				q := make(Point, dims)
+				for i := range q {
+					if i < len(p) {
+						// 近くの座標と空間の端の座標が混ざるようにする
+						q[i] = p[i] % 64
+						if p[i]%3 == 0 {
+							q[i] = limit - p[i]%64
+						}
+					}
+				}
+				return q
+			}
+			tree := simpletree.New(true)
+			for i, p := range points {
+				avltree.Insert(tree, false, normalize(p).Key(), i)
+			}
+			target = normalize(target)
+			want := avltree.Range(tree, false, nil, nil)
+			sort.SliceStable(want, func(i, j int) bool {
+				return squaredDistance(want[i].Key().(MortonKey).Point(dims), target).less(squaredDistance(want[j].Key().(MortonKey).Point(dims), target))
+			})
+			if len(want) > int(k%8) {
+				want = want[:k%8]
+			}
+			got := Nearest(tree, target, int(k%8))
+			return len(got) == len(want) && (len(got) == 0 || reflect.DeepEqual(got, want))
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dims, err)
+		}
+	}
+}
+
+func TestSquaredDistance(t *testing.T) {
+	d := squaredDistance(Point{0, 0, 0}, Point{Max3D, Max3D, Max3D})
+	if d.hi != 0 || d.lo != 3*Max3D*Max3D {
+		t.Fatal("wrong distance", d)
+	}
+	d = squaredDistance(Point{1<<32 - 1, 0}, Point{0, 1<<32 - 1})
+	// 2 * (2^32-1)^2 = 2^65 - 2^34 + 2
+	if d.hi != 1 || d.lo != 1<<64-1<<34+2 {
+		t.Fatal("wrong distance", d)
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	avltree.Insert(tree, false, New2D(3, 4), "cafe")
+	avltree.Insert(tree, false, New2D(10, 2), "station")
+	avltree.Insert(tree, false, New2D(5, 5), "park")
+	avltree.Insert(tree, false, New2D(1, 9), "museum")
+	avltree.Insert(tree, false, New2D(6, 3), "library")
+	BoxQuery(tree, Point{2, 2}, Point{6, 5}, func(node avltree.Node) (breakIteration bool) {
+		x, y := node.Key().(MortonKey).XY()
+		fmt.Println("Box!", x, y, node.Value())
+		return
+	})
+	for _, node := range Nearest(tree, Point{9, 3}, 2) {
+		fmt.Println("Nearest!", node.Key().(MortonKey).Point(2), node.Value())
+	}
+	// Output:
+	// Box! 6 3 library
+	// Box! 3 4 cafe
+	// Box! 5 5 park
+	// Nearest! [10 2] station
+	// Nearest! [6 3] library
+}
@@ -0,0 +1,197 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの木の変更をイベントとして通知するラッパーの実装例
+//
+// 任意の木をラップしてavltree.MutationObserverを実装し、
+// avltree.InsertやDelete,Clearなどで木に変更があった際に変更ごとにEventをリスナーに渡す
+// 範囲指定の操作(DeleteRangeIterateなど)では変更されたノードごとに変更した順にイベントが通知される
+//
+// リスナーは変更操作の途中で呼び出されるため、リスナーの中で木を参照したり変更したりしてはならない
+// 不変(immutable)の木をラップした場合も変更操作の度に新しい木を返すが、全てのバージョンで同じリスナーが使われる
+// avltree.Releaseは変更ではないためイベントは通知されない(avltree.Clearでは削除が通知される)
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/observabletree"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_observabletree() {
+//			tree := observabletree.New(simpletree.New(false), func(event observabletree.Event) {
+//				fmt.Println(event.Kind, event.Key, event.OldValue, event.NewValue)
+//			})
+//			tree, _ = avltree.Insert(tree, false, IntKey(1), 100)
+//			tree, _ = avltree.Insert(tree, false, IntKey(2), 200)
+//			tree, _ = avltree.Insert(tree, false, IntKey(3), 300)
+//			tree, _ = avltree.Insert(tree, true, IntKey(2), 222)
+//			tree, _ = avltree.UpdateRange(tree, false, IntKey(2), IntKey(3), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+//				newValue = oldValue.(int) + 1
+//				return
+//			})
+//			tree, _ = avltree.DeleteRange(tree, false, IntKey(1), IntKey(2))
+//			tree = avltree.Clear(tree)
+//			// Output:
+//			// Inserted 1 <nil> 100
+//			// Inserted 2 <nil> 200
+//			// Inserted 3 <nil> 300
+//			// Replaced 2 200 222
+//			// Replaced 2 222 223
+//			// Replaced 3 300 301
+//			// Deleted 1 100 <nil>
+//			// Deleted 2 223 <nil>
+//			// Deleted 3 301 <nil>
+//		}
+//
+package observabletree
+
+import (
+	"github.com/neetsdkasu/avltree"
+)
+
+// 変更の種類
+type EventKind int
+
+const (
+	// ノードが追加された
+	Inserted EventKind = iota
+
+	// ノードの値が置き換えられた
+	Replaced
+
+	// ノードが削除された
+	Deleted
+)
+
+// 変更の内容
+// Insertedの場合はOldValueはnil、Deletedの場合はNewValueはnil
+type Event struct {
+	Kind     EventKind
+	Key      avltree.Key
+	OldValue interface{}
+	NewValue interface{}
+}
+
+type Listener = func(event Event)
+
+type ObservableTree struct {
+	Inner    avltree.RealTree
+	Listener Listener
+}
+
+// ラップしている木がavltree.KeyComparatorを実装している場合のObservableTree
+// 比較方法もラップしている木に委譲する
+type comparatorTree struct {
+	*ObservableTree
+}
+
+// treeをラップし、変更があった場合にlistenerにイベントを渡す木を生成する
+// treeがavltree.KeyComparatorを実装している場合はavltree.KeyComparatorも実装した木を返す(*ObservableTreeへの型アサーションはできない)
+func New(tree avltree.Tree, listener Listener) avltree.Tree {
+	return wrap(&ObservableTree{
+		Inner:    tree.(avltree.RealTree),
+		Listener: listener,
+	})
+}
+
+// ラップしている木がavltree.KeyComparatorを実装している場合のみ比較方法を委譲する型で包む
+func wrap(tree *ObservableTree) avltree.RealTree {
+	if _, ok := tree.Inner.(avltree.KeyComparator); ok {
+		return comparatorTree{tree}
+	}
+	return tree
+}
+
+func (kind EventKind) String() string {
+	switch kind {
+	case Inserted:
+		return "Inserted"
+	case Replaced:
+		return "Replaced"
+	case Deleted:
+		return "Deleted"
+	default:
+		return "Unknown"
+	}
+}
+
+func (tree *ObservableTree) Root() avltree.Node {
+	return tree.Inner.Root()
+}
+
+func (tree *ObservableTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	return tree.Inner.NewNode(leftChild, rightChild, height, key, value)
+}
+
+// ラップしている木のSetRootが新しい木を返した場合は同じリスナーを持つ新しいObservableTreeでラップして返す
+func (tree *ObservableTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	newTree := tree.Inner.SetRoot(newRoot)
+	if newTree == tree.Inner {
+		return wrap(tree)
+	}
+	return wrap(&ObservableTree{
+		Inner:    newTree,
+		Listener: tree.Listener,
+	})
+}
+
+func (tree *ObservableTree) AllowDuplicateKeys() bool {
+	return tree.Inner.AllowDuplicateKeys()
+}
+
+func (tree *ObservableTree) NodeCount() int {
+	return avltree.Count(tree.Inner)
+}
+
+// ラップしている木がavltree.NodeReleaserを実装している場合のみ処理を委譲する
+func (tree *ObservableTree) ReleaseNode(node avltree.RealNode) {
+	if releaser, ok := tree.Inner.(avltree.NodeReleaser); ok {
+		releaser.ReleaseNode(node)
+	}
+}
+
+func (tree comparatorTree) CompareKeys(key1, key2 avltree.Key) avltree.KeyOrdering {
+	return tree.Inner.(avltree.KeyComparator).CompareKeys(key1, key2)
+}
+
+// ラップしている木がavltree.TreeCleanerを実装している場合のみ処理を委譲する
+func (tree *ObservableTree) CleanUpTree() {
+	if cleaner, ok := tree.Inner.(avltree.TreeCleaner); ok {
+		cleaner.CleanUpTree()
+	}
+}
+
+// ラップしている木がavltree.TreeReleaserを実装している場合のみ処理を委譲する
+func (tree *ObservableTree) ReleaseTree() {
+	if releaser, ok := tree.Inner.(avltree.TreeReleaser); ok {
+		releaser.ReleaseTree()
+	}
+}
+
+func (tree *ObservableTree) NodeInserted(key avltree.Key, value interface{}) {
+	tree.Listener(Event{
+		Kind:     Inserted,
+		Key:      key,
+		NewValue: value,
+	})
+}
+
+func (tree *ObservableTree) NodeReplaced(key avltree.Key, oldValue, newValue interface{}) {
+	tree.Listener(Event{
+		Kind:     Replaced,
+		Key:      key,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+}
+
+func (tree *ObservableTree) NodeDeleted(key avltree.Key, value interface{}) {
+	tree.Listener(Event{
+		Kind:     Deleted,
+		Key:      key,
+		OldValue: value,
+	})
+}
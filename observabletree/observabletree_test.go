@@ -0,0 +1,256 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package observabletree
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/comparatortree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	"github.com/neetsdkasu/avltree/intarraytree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"immutabletree": immutabletree.New,
+	"intarraytree": func(allowDuplicateKeys bool) avltree.Tree {
+		return intarraytree.New(allowDuplicateKeys)
+	},
+}
+
+// イベントを順に適用して木の内容を再現する
+type replica map[int]int
+
+func (r replica) apply(t *testing.T, event Event) {
+	key := int(event.Key.(IntKey))
+	value, exists := r[key]
+	switch event.Kind {
+	case Inserted:
+		if exists {
+			t.Fatal("inserted existing key", event)
+		}
+		r[key] = event.NewValue.(int)
+	case Replaced:
+		if !exists || value != event.OldValue.(int) {
+			t.Fatal("wrong old value", event, value)
+		}
+		r[key] = event.NewValue.(int)
+	case Deleted:
+		if !exists || value != event.OldValue.(int) {
+			t.Fatal("wrong deleted value", event, value)
+		}
+		delete(r, key)
+	default:
+		t.Fatal("unknown event", event)
+	}
+}
+
+func toMap(tree avltree.Tree) map[int]int {
+	m := map[int]int{}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		m[int(node.Key().(IntKey))] = node.Value().(int)
+		return
+	})
+	return m
+}
+
+func TestReplayEvents(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(list []keyAndValue, k1, k2 int8) bool {
+			r := replica{}
+			tree := New(newTree(false), func(event Event) {
+				r.apply(t, event)
+			})
+			check := func(op string) {
+				if want := toMap(tree); !reflect.DeepEqual(map[int]int(r), want) {
+					t.Fatal(name, op, r, want)
+				}
+			}
+			lower, upper := IntKey(k1), IntKey(k2)
+			for i, kv := range list {
+				key := IntKey(int8(kv.Key))
+				switch i % 8 {
+				case 0:
+					tree, _ = avltree.Insert(tree, false, key, kv.Value)
+				case 1:
+					tree, _ = avltree.Insert(tree, true, key, kv.Value)
+				case 2:
+					tree, _ = avltree.Delete(tree, key)
+				case 3:
+					tree, _ = avltree.Update(tree, key, func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+						return oldValue.(int) + kv.Value, kv.Value%2 == 0
+					})
+				case 4:
+					tree, _, _ = avltree.Alter(tree, key, func(node avltree.AlterNode) (request avltree.AlterRequest) {
+						switch kv.Value % 3 {
+						case 0:
+							return node.Keep()
+						case 1:
+							return node.Replace(kv.Value)
+						default:
+							return node.Delete()
+						}
+					})
+				case 5:
+					tree, _ = avltree.DeleteRangeIterate(tree, i%2 == 0, lower, upper, func(key avltree.Key, value interface{}) (deleteNode, breakIteration bool) {
+						return value.(int)%2 == 0, value.(int)%7 == 0
+					})
+				case 6:
+					tree, _ = avltree.UpdateRangeIterate(tree, i%2 == 0, lower, upper, func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue, breakIteration bool) {
+						return oldValue.(int) / 2, oldValue.(int)%3 == 0, oldValue.(int)%11 == 0
+					})
+				case 7:
+					tree, _, _ = avltree.AlterRangeIterate(tree, i%2 == 0, nil, upper, func(node avltree.AlterNode) (request avltree.AlterRequest, breakIteration bool) {
+						switch node.Value().(int) % 3 {
+						case 0:
+							request = node.Keep()
+						case 1:
+							request = node.Replace(node.Value().(int) + 1)
+						default:
+							request = node.Delete()
+						}
+						return request, node.Value().(int)%13 == 0
+					})
+				}
+				check("step")
+			}
+			tree, _ = avltree.DeleteIterate(tree, false, func(key avltree.Key, value interface{}) (deleteNode, breakIteration bool) {
+				return int(key.(IntKey))%2 == 0, false
+			})
+			check("DeleteIterate")
+			tree = avltree.Clear(tree)
+			check("Clear")
+			return len(r) == 0
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+func TestEventOrder(t *testing.T) {
+	var events []Event
+	tree := New(simpletree.New(false), func(event Event) {
+		events = append(events, event)
+	})
+	for k := 1; k <= 5; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	events = nil
+	tree, _ = avltree.DeleteRange(tree, true, IntKey(2), IntKey(4))
+	want := []Event{
+		{Deleted, IntKey(4), 4, nil},
+		{Deleted, IntKey(3), 3, nil},
+		{Deleted, IntKey(2), 2, nil},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatal("wrong events", events)
+	}
+	events = nil
+	avltree.Clear(tree)
+	want = []Event{
+		{Deleted, IntKey(1), 1, nil},
+		{Deleted, IntKey(5), 5, nil},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatal("wrong events", events)
+	}
+}
+
+// 古いバージョンを解放してもイベントは通知されない
+func TestReleaseNoEvent(t *testing.T) {
+	var events []Event
+	tree := New(immutabletree.New(false), func(event Event) {
+		events = append(events, event)
+	})
+	v1, _ := avltree.Insert(tree, false, IntKey(1), 10)
+	v2, _ := avltree.Insert(v1, false, IntKey(2), 20)
+	events = nil
+	avltree.Release(&v1)
+	if len(events) != 0 || v1 != nil {
+		t.Fatal("unexpected events", events)
+	}
+	if avltree.Count(v2) != 2 {
+		t.Fatal("newer version is changed")
+	}
+	avltree.Release(&v2)
+	if len(events) != 0 {
+		t.Fatal("unexpected events", events)
+	}
+}
+
+func TestNoEventWithoutChange(t *testing.T) {
+	count := 0
+	tree := New(immutabletree.New(false), func(event Event) {
+		count++
+	})
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 1)
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 2)
+	avltree.Delete(tree, IntKey(2))
+	avltree.Update(tree, IntKey(1), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		return nil, true
+	})
+	avltree.Alter(tree, IntKey(1), func(node avltree.AlterNode) (request avltree.AlterRequest) {
+		return node.Keep()
+	})
+	if count != 1 {
+		t.Fatal("unexpected events", count)
+	}
+}
+
+func TestImmutableVersions(t *testing.T) {
+	var events []Event
+	tree := New(immutabletree.New(false), func(event Event) {
+		events = append(events, event)
+	})
+	v1, _ := avltree.Insert(tree, false, IntKey(1), 10)
+	v2, _ := avltree.Replace(v1, IntKey(1), 20)
+	if v1 == v2 || avltree.Find(v1, IntKey(1)).Value() != 10 || avltree.Find(v2, IntKey(1)).Value() != 20 {
+		t.Fatal("versions are shared")
+	}
+	if len(events) != 2 || events[1].Kind != Replaced {
+		t.Fatal("wrong events", events)
+	}
+	if avltree.Count(v2) != 1 {
+		t.Fatal("wrong count")
+	}
+}
+
+func TestKeyComparator(t *testing.T) {
+	if _, ok := New(simpletree.New(false), func(Event) {}).(avltree.KeyComparator); ok {
+		t.Fatal("implements KeyComparator without comparator")
+	}
+	var events []Event
+	tree := New(comparatortree.New(immutabletree.New(false), func(a, b interface{}) int {
+		return b.(int) - a.(int)
+	}), func(event Event) {
+		events = append(events, event)
+	})
+	for _, v := range []int{2, 3, 1} {
+		tree, _ = avltree.Insert(tree, false, comparatortree.Key(v), v)
+	}
+	if _, ok := tree.(avltree.KeyComparator); !ok {
+		t.Fatal("not implements KeyComparator")
+	}
+	var values []interface{}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		values = append(values, node.Value())
+		return
+	})
+	if !reflect.DeepEqual(values, []interface{}{3, 2, 1}) || len(events) != 3 {
+		t.Fatal("wrong order", values, events)
+	}
+}
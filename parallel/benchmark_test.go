@@ -0,0 +1,63 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package parallel
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+const benchmarkTreeSize = 100000
+
+func genTree() avltree.Tree {
+	tree := immutabletree.New(true)
+	for i := 0; i < benchmarkTreeSize; i++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(rand.Int()), rand.Intn(1000))
+	}
+	return tree
+}
+
+func heavyMap(key avltree.Key, value interface{}) interface{} {
+	x := value.(int)
+	for i := 0; i < 100; i++ {
+		x = (x*31 + i) % 1000003
+	}
+	return x
+}
+
+func sum(left, right interface{}) interface{} {
+	return left.(int) + right.(int)
+}
+
+func BenchmarkIterate(b *testing.B) {
+	tree := genTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		total := 0
+		avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+			total += heavyMap(node.Key(), node.Value()).(int)
+			return
+		})
+	}
+}
+
+func BenchmarkParallelReduce(b *testing.B) {
+	tree := genTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParallelReduce(tree, 0, heavyMap, sum)
+	}
+}
+
+func BenchmarkParallelOrderedReduce(b *testing.B) {
+	tree := genTree()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParallelOrderedReduce(tree, 0, heavyMap, sum)
+	}
+}
@@ -0,0 +1,351 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの木を複数のゴルーチンで並行に巡る関数群
+//
+// 木をいくつかの部分木(と部分木に含まれない途中のノード)の並びに分割し、それらを複数のゴルーチンで分担して処理する
+// ノードがavltree.NodeCounterを実装している場合は部分木のノード数をもとに均等に分割する
+// 実装していない場合はノードの高さからノード数を見積もって分割する
+//
+// 木を読み取るだけで変更はしないため、処理中に他のゴルーチンから変更されない木であれば並行に使用できる
+// immutabletreeの木(スナップショット)は処理中に新しいバージョンが作られても影響を受けないので安全に使用できる
+// 可変(mutable)の木の場合は処理中に変更してはならない
+//
+// workersには使用するゴルーチンの数を指定する(0以下の場合はruntime.GOMAXPROCS(0)を使う)
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"sync/atomic"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/parallel"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_parallel() {
+//			tree := simpletree.New(false)
+//			for k := 1; k <= 1000; k++ {
+//				tree, _ = avltree.Insert(tree, false, IntKey(k), k*k)
+//			}
+//			sum, _ := parallel.ParallelReduce(tree, 4, func(key avltree.Key, value interface{}) interface{} {
+//				return value.(int)
+//			}, func(left, right interface{}) interface{} {
+//				return left.(int) + right.(int)
+//			})
+//			fmt.Println("Sum!", sum)
+//			keys, _ := parallel.ParallelOrderedReduce(tree, 4, func(key avltree.Key, value interface{}) interface{} {
+//				return []avltree.Key{key}
+//			}, func(left, right interface{}) interface{} {
+//				return append(left.([]avltree.Key), right.([]avltree.Key)...)
+//			})
+//			fmt.Println("Keys!", keys.([]avltree.Key)[:5])
+//			count := int64(0)
+//			parallel.ParallelRangeIterate(tree, 4, IntKey(100), IntKey(199), func(node avltree.Node) (breakIteration bool) {
+//				atomic.AddInt64(&count, 1)
+//				return
+//			})
+//			fmt.Println("Count!", count)
+//			// Output:
+//			// Sum! 333833500
+//			// Keys! [1 2 3 4 5]
+//			// Count! 100
+//		}
+//
+package parallel
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// キーと値から集約する値を求める関数
+type MapFunc = func(key avltree.Key, value interface{}) (mapped interface{})
+
+// ２つの値を１つに集約する関数
+// 結合則を満たす必要がある
+type ReduceFunc = func(left, right interface{}) (reduced interface{})
+
+// 各ゴルーチンに割り当てる分割数の目安
+const partitionsPerWorker = 4
+
+// 分割の単位
+// wholeがtrueのときはnodeを根とする部分木全体、falseのときはnodeだけ
+type item struct {
+	node  avltree.Node
+	whole bool
+}
+
+// キーの順序で連続するitemの並び
+type partition []item
+
+type splitter struct {
+	tree         avltree.Tree
+	lower, upper avltree.Key
+	target       int
+	partitions   []partition
+	current      partition
+	currentSize  int
+}
+
+func getWorkers(workers int) int {
+	if workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// 部分木のノード数を返す
+// ノードがNodeCounterを実装していない場合は高さから見積もる
+func subtreeSize(node avltree.Node) int {
+	if node == nil {
+		return 0
+	}
+	if counter, ok := node.(avltree.NodeCounter); ok {
+		return counter.NodeCount()
+	}
+	height := node.(avltree.RealNode).Height()
+	if height < 1 {
+		return 1
+	}
+	return 1 << uint(height-1)
+}
+
+// lower以上upper以下のキーを持つノードを含む部分をキーの順序でおよそworkers*partitionsPerWorker個に分割する
+func split(tree avltree.Tree, workers int, lower, upper avltree.Key) []partition {
+	root := tree.Root()
+	target := subtreeSize(root) / (workers * partitionsPerWorker)
+	if target < 1 {
+		target = 1
+	}
+	s := &splitter{
+		tree:   tree,
+		lower:  lower,
+		upper:  upper,
+		target: target,
+	}
+	s.walk(root)
+	if len(s.current) > 0 {
+		s.partitions = append(s.partitions, s.current)
+	}
+	return s.partitions
+}
+
+func (s *splitter) add(it item, size int) {
+	s.current = append(s.current, it)
+	s.currentSize += size
+	if s.currentSize >= s.target {
+		s.partitions = append(s.partitions, s.current)
+		s.current = nil
+		s.currentSize = 0
+	}
+}
+
+func (s *splitter) walk(node avltree.Node) {
+	if node == nil {
+		return
+	}
+	key := node.Key()
+	if s.lower != nil && avltree.CompareKeys(s.tree, key, s.lower).LessThan() {
+		s.walk(node.RightChild())
+		return
+	}
+	if s.upper != nil && avltree.CompareKeys(s.tree, key, s.upper).GreaterThan() {
+		s.walk(node.LeftChild())
+		return
+	}
+	if size := subtreeSize(node); size <= s.target {
+		s.add(item{node, true}, size)
+		return
+	}
+	s.walk(node.LeftChild())
+	s.add(item{node, false}, 1)
+	s.walk(node.RightChild())
+}
+
+// 部分木全体が範囲に含まれるとは限らないので範囲の確認をしながら巡る
+// 左の子はノードのキー以下、右の子はノードのキー以上なので同一キーを許可する木でも正しく巡る
+type visitor struct {
+	tree         avltree.Tree
+	lower, upper avltree.Key
+	stopped      *int32
+	callBack     avltree.IterateCallBack
+}
+
+func (v *visitor) inRange(key avltree.Key) bool {
+	if v.lower != nil && avltree.CompareKeys(v.tree, key, v.lower).LessThan() {
+		return false
+	}
+	if v.upper != nil && avltree.CompareKeys(v.tree, key, v.upper).GreaterThan() {
+		return false
+	}
+	return true
+}
+
+func (v *visitor) visitNode(node avltree.Node) (ok bool) {
+	if v.stopped != nil && atomic.LoadInt32(v.stopped) != 0 {
+		return false
+	}
+	if v.inRange(node.Key()) && v.callBack(node) {
+		if v.stopped != nil {
+			atomic.StoreInt32(v.stopped, 1)
+		}
+		return false
+	}
+	return true
+}
+
+func (v *visitor) visitSubtree(node avltree.Node) (ok bool) {
+	if node == nil {
+		return true
+	}
+	key := node.Key()
+	if v.lower == nil || avltree.CompareKeys(v.tree, key, v.lower).GreaterThanOrEqualTo() {
+		if !v.visitSubtree(node.LeftChild()) {
+			return false
+		}
+	}
+	if !v.visitNode(node) {
+		return false
+	}
+	if v.upper == nil || avltree.CompareKeys(v.tree, key, v.upper).LessThanOrEqualTo() {
+		return v.visitSubtree(node.RightChild())
+	}
+	return true
+}
+
+func (v *visitor) visit(p partition) (ok bool) {
+	for _, it := range p {
+		if it.whole {
+			ok = v.visitSubtree(it.node)
+		} else {
+			ok = v.visitNode(it.node)
+		}
+		if !ok {
+			return
+		}
+	}
+	return true
+}
+
+// partitionsをworkers個のゴルーチンで分担してprocessを呼び出す
+// processの引数はゴルーチンの番号と分割の番号
+func run(partitions []partition, workers int, process func(worker, index int)) {
+	if workers > len(partitions) {
+		workers = len(partitions)
+	}
+	indexes := make(chan int, len(partitions))
+	for i := range partitions {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for index := range indexes {
+				process(worker, index)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// 木の全てのノードを複数のゴルーチンで並行に巡ってコールバックを呼び出す
+// ParallelRangeIterateの範囲を指定しない場合と同じ
+func ParallelIterate(tree avltree.Tree, workers int, callBack avltree.IterateCallBack) (ok bool) {
+	return ParallelRangeIterate(tree, workers, nil, nil, callBack)
+}
+
+// 木のlower以上upper以下のキーを持つノードを複数のゴルーチンで並行に巡ってコールバックを呼び出す
+// コールバックは複数のゴルーチンから同時に呼び出される
+// 分割された各部分の中ではキーの昇順で呼び出されるが、全体としての順序は保証しない
+// コールバックが中断を要求した場合は全てのゴルーチンができるだけ早く巡るのをやめる(中断の要求後もしばらくコールバックが呼ばれることがある)
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func ParallelRangeIterate(tree avltree.Tree, workers int, lower, upper avltree.Key, callBack avltree.IterateCallBack) (ok bool) {
+	if lower != nil && upper != nil && avltree.CompareKeys(tree, lower, upper).GreaterThan() {
+		return true
+	}
+	workers = getWorkers(workers)
+	partitions := split(tree, workers, lower, upper)
+	stopped := int32(0)
+	v := &visitor{
+		tree:     tree,
+		lower:    lower,
+		upper:    upper,
+		stopped:  &stopped,
+		callBack: callBack,
+	}
+	run(partitions, workers, func(worker, index int) {
+		v.visit(partitions[index])
+	})
+	return stopped == 0
+}
+
+// 集約途中の値
+type accumulator struct {
+	value interface{}
+	has   bool
+}
+
+func (acc *accumulator) add(value interface{}, reduceFn ReduceFunc) {
+	if acc.has {
+		acc.value = reduceFn(acc.value, value)
+	} else {
+		acc.value = value
+		acc.has = true
+	}
+}
+
+// 各ノードのキーと値をmapFnで変換した値をreduceFnで１つに集約する
+// mapFn,reduceFnは複数のゴルーチンから同時に呼び出される
+// 集約の順序はキーの順序と一致しないためreduceFnは結合則と交換則を満たす必要がある
+// 戻り値のokは木にノードが無い場合はfalse(resultはnil)
+func ParallelReduce(tree avltree.Tree, workers int, mapFn MapFunc, reduceFn ReduceFunc) (result interface{}, ok bool) {
+	workers = getWorkers(workers)
+	partitions := split(tree, workers, nil, nil)
+	accumulators := make([]accumulator, workers)
+	run(partitions, workers, func(worker, index int) {
+		acc := &accumulators[worker]
+		v := &visitor{callBack: func(node avltree.Node) (breakIteration bool) {
+			acc.add(mapFn(node.Key(), node.Value()), reduceFn)
+			return
+		}}
+		v.visit(partitions[index])
+	})
+	var total accumulator
+	for _, acc := range accumulators {
+		if acc.has {
+			total.add(acc.value, reduceFn)
+		}
+	}
+	return total.value, total.has
+}
+
+// ParallelReduceと同様だが、キーの昇順で並べた値を左から順に集約したのと同じ結果になるように集約する
+// reduceFnは結合則を満たしていればよい(交換則を満たす必要はない)
+// 分割した部分ごとに集約した値を保持するためParallelReduceより多くのメモリを使用する
+func ParallelOrderedReduce(tree avltree.Tree, workers int, mapFn MapFunc, reduceFn ReduceFunc) (result interface{}, ok bool) {
+	workers = getWorkers(workers)
+	partitions := split(tree, workers, nil, nil)
+	accumulators := make([]accumulator, len(partitions))
+	run(partitions, workers, func(worker, index int) {
+		acc := &accumulators[index]
+		v := &visitor{callBack: func(node avltree.Node) (breakIteration bool) {
+			acc.add(mapFn(node.Key(), node.Value()), reduceFn)
+			return
+		}}
+		v.visit(partitions[index])
+	})
+	var total accumulator
+	for _, acc := range accumulators {
+		if acc.has {
+			total.add(acc.value, reduceFn)
+		}
+	}
+	return total.value, total.has
+}
@@ -0,0 +1,211 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package parallel
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/standardtree"
+)
+
+// go test -race で実行することを想定したテストを含む
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+// simpletreeはNodeCounterを実装していないので高さから見積もる分割が使われる
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"standardtree":  standardtree.New,
+	"immutabletree": immutabletree.New,
+}
+
+func makeTree(newTree func(allowDuplicateKeys bool) avltree.Tree, dup bool, list []keyAndValue) avltree.Tree {
+	tree := newTree(dup)
+	for _, kv := range list {
+		tree, _ = avltree.Insert(tree, false, IntKey(int8(kv.Key)), kv.Value)
+	}
+	return tree
+}
+
+func TestParallelRangeIterate(t *testing.T) {
+	for name, newTree := range treeMakers {
+		for _, dup := range []bool{false, true} {
+			f := func(list []keyAndValue, k1, k2 int8, workers uint8) []string {
+				tree := makeTree(newTree, dup, list)
+				var lower, upper avltree.Key
+				if k1%4 != 0 {
+					lower = IntKey(k1)
+				}
+				if k2%4 != 0 {
+					upper = IntKey(k2)
+				}
+				var mutex sync.Mutex
+				var result []string
+				ParallelRangeIterate(tree, int(workers%8), lower, upper, func(node avltree.Node) (breakIteration bool) {
+					mutex.Lock()
+					result = append(result, fmt.Sprint(node.Key(), node.Value()))
+					mutex.Unlock()
+					return
+				})
+				sort.Strings(result)
+				return result
+			}
+			g := func(list []keyAndValue, k1, k2 int8, workers uint8) []string {
+				tree := makeTree(newTree, dup, list)
+				var lower, upper avltree.Key
+				if k1%4 != 0 {
+					lower = IntKey(k1)
+				}
+				if k2%4 != 0 {
+					upper = IntKey(k2)
+				}
+				var result []string
+				avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+					result = append(result, fmt.Sprint(node.Key(), node.Value()))
+					return
+				})
+				sort.Strings(result)
+				return result
+			}
+			if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+				t.Fatal(name, dup, err)
+			}
+		}
+	}
+}
+
+func TestParallelReduce(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(list []keyAndValue, workers uint8) (int, bool) {
+			tree := makeTree(newTree, true, list)
+			result, ok := ParallelReduce(tree, int(workers%8), func(key avltree.Key, value interface{}) interface{} {
+				return int(key.(IntKey)) ^ value.(int)
+			}, func(left, right interface{}) interface{} {
+				return left.(int) + right.(int)
+			})
+			if !ok {
+				return 0, false
+			}
+			return result.(int), true
+		}
+		g := func(list []keyAndValue, workers uint8) (sum int, ok bool) {
+			for _, kv := range list {
+				sum += int(int8(kv.Key)) ^ kv.Value
+				ok = true
+			}
+			return
+		}
+		if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+func TestParallelOrderedReduce(t *testing.T) {
+	for name, newTree := range treeMakers {
+		for _, dup := range []bool{false, true} {
+			f := func(list []keyAndValue, workers uint8) []string {
+				tree := makeTree(newTree, dup, list)
+				result, ok := ParallelOrderedReduce(tree, int(workers%8), func(key avltree.Key, value interface{}) interface{} {
+					return []string{fmt.Sprint(key, value)}
+				}, func(left, right interface{}) interface{} {
+					return append(left.([]string), right.([]string)...)
+				})
+				if !ok {
+					return nil
+				}
+				return result.([]string)
+			}
+			g := func(list []keyAndValue, workers uint8) (result []string) {
+				tree := makeTree(newTree, dup, list)
+				avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+					result = append(result, fmt.Sprint(node.Key(), node.Value()))
+					return
+				})
+				return
+			}
+			if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+				t.Fatal(name, dup, err)
+			}
+		}
+	}
+}
+
+func TestParallelIterateBreak(t *testing.T) {
+	tree := simpletree.New(false)
+	for k := 0; k < 10000; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	count := int64(0)
+	ok := ParallelIterate(tree, 4, func(node avltree.Node) (breakIteration bool) {
+		return atomic.AddInt64(&count, 1) >= 10
+	})
+	if ok {
+		t.Fatal("not stopped")
+	}
+	if count >= 10000 {
+		t.Fatal("visited all nodes", count)
+	}
+	if !ParallelIterate(tree, 4, func(node avltree.Node) (breakIteration bool) { return }) {
+		t.Fatal("stopped")
+	}
+}
+
+func TestEmptyTree(t *testing.T) {
+	tree := simpletree.New(false)
+	if result, ok := ParallelReduce(tree, 4, nil, nil); ok || result != nil {
+		t.Fatal("not empty", result, ok)
+	}
+	if result, ok := ParallelOrderedReduce(tree, 4, nil, nil); ok || result != nil {
+		t.Fatal("not empty", result, ok)
+	}
+	if !ParallelIterate(tree, 4, func(node avltree.Node) (breakIteration bool) { return true }) {
+		t.Fatal("stopped")
+	}
+}
+
+// 別のゴルーチンで新しいバージョンを作り続けても古いスナップショットの集約結果は変わらない
+func TestImmutableSnapshot(t *testing.T) {
+	snapshot := immutabletree.New(false)
+	for k := 0; k < 5000; k++ {
+		snapshot, _ = avltree.Insert(snapshot, false, IntKey(k), k)
+	}
+	mapFn := func(key avltree.Key, value interface{}) interface{} {
+		return value.(int)
+	}
+	reduceFn := func(left, right interface{}) interface{} {
+		return left.(int) + right.(int)
+	}
+	want, _ := ParallelReduce(snapshot, 1, mapFn, reduceFn)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tree := snapshot
+		for k := 0; k < 5000; k++ {
+			tree, _ = avltree.Delete(tree, IntKey(k))
+			tree, _ = avltree.Insert(tree, false, IntKey(k+5000), k)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if got, _ := ParallelReduce(snapshot, 4, mapFn, reduceFn); !reflect.DeepEqual(got, want) {
+			t.Fatal("snapshot changed", got, want)
+		}
+	}
+	<-done
+}
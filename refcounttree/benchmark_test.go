@@ -0,0 +1,70 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package refcounttree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func genKeyAndValues(n int) []*keyAndValue {
+	list := []*keyAndValue{}
+	for i := 0; i < n; i++ {
+		key := rand.Int()
+		value := rand.Int()
+		list = append(list, &keyAndValue{key, value})
+	}
+	return list
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list[:b.N] {
+		newTree, _ := avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		tree = Advance(tree, newTree)
+	}
+	list = list[b.N:]
+	b.ResetTimer()
+	for _, kv := range list {
+		newTree, _ := avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		tree = Advance(tree, newTree)
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		newTree, _ := avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		tree = Advance(tree, newTree)
+	}
+	list = list[:b.N]
+	b.ResetTimer()
+	for _, kv := range list {
+		newTree, _ := avltree.Delete(tree, IntKey(kv.Key))
+		tree = Advance(tree, newTree)
+	}
+}
+
+// 削除と挿入を繰り返す場合のメモリ割り当ての確認用
+func BenchmarkChurn(b *testing.B) {
+	tree := New(true)
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		newTree, _ := avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		tree = Advance(tree, newTree)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, kv := range list {
+		newTree, _ := avltree.Delete(tree, IntKey(kv.Key))
+		tree = Advance(tree, newTree)
+		newTree, _ = avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		tree = Advance(tree, newTree)
+	}
+}
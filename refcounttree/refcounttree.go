@@ -0,0 +1,323 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのRealTree,RealNodeの実装例
+// immutabletreeと同様に変更の度に新しい木(バージョン)を返す不変(immutable)ぽい木を構築する
+// 各ノードは参照カウントを持ち、どのバージョンからも参照されなくなったノードは再利用される
+//
+// 参照カウントはSetRootでバージョンが生成されたときに新しく作られたノードに対してのみ加算され、
+// avltree.Releaseでバージョンを解放したときに減算される
+// 参照カウントが0になったノードは同じ木から派生した全てのバージョンで共有するNodePoolに戻され、NewNodeなどで再利用される
+// どのバージョンにも公開されていない(参照カウントが0の)ノードは変更時に複製せずそのまま書き換える
+//
+// キーと値の組も参照カウントを持ち、どのノードからも参照されなくなったときにFinalizerが呼び出される
+// (一度もバージョンに公開されなかったキーと値の組に対してはFinalizerは呼び出されない)
+//
+// ノードは複数のバージョンで共有されているためavltree.NodeReleaserは実装していない
+// 不要になったバージョンはavltree.Releaseで解放する必要がある(解放しなかったバージョンのノードは再利用されずGCに任される)
+// 解放したバージョンを使用してはならない(変更しようとするとpanicになる)
+// 並行に使用することはできない
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/refcounttree"
+//		)
+//		func Example_refcounttree() {
+//			tree := refcounttree.NewWithFinalizer(false, func(key avltree.Key, value interface{}) {
+//				fmt.Println("Finalize!", key, value)
+//			})
+//			newTree, _ := avltree.Insert(tree, false, IntKey(12), 345)
+//			tree = refcounttree.Advance(tree, newTree)
+//			newTree, _ = avltree.Insert(tree, false, IntKey(67), 890)
+//			tree = refcounttree.Advance(tree, newTree)
+//			saved := tree
+//			tree, _ = avltree.Delete(tree, IntKey(67))
+//			newTree, _ = avltree.Replace(tree, IntKey(12), 999)
+//			tree = refcounttree.Advance(tree, newTree)
+//			fmt.Println("Release saved")
+//			avltree.Release(&saved)
+//			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Iterate!", node.Key(), node.Value())
+//				return
+//			})
+//			fmt.Println("Release tree")
+//			avltree.Release(&tree)
+//			// Output:
+//			// Release saved
+//			// Finalize! 67 890
+//			// Finalize! 12 345
+//			// Iterate! 12 999
+//			// Release tree
+//			// Finalize! 12 999
+//		}
+//
+package refcounttree
+
+import "github.com/neetsdkasu/avltree"
+
+// 木のバージョン
+type RefCountTree struct {
+	RootNode                *RefCountTreeNode
+	AllowDuplicateKeysValue bool
+	Pool                    *NodePool
+	released                bool
+}
+
+type RefCountTreeNode struct {
+	LeftChildNode  *RefCountTreeNode
+	RightChildNode *RefCountTreeNode
+	HeightValue    int
+	NodeCountValue int
+	Entry          *Entry
+	RefCount       int
+	Pool           *NodePool
+}
+
+// ノードのキーと値の組
+// 値だけ変更したノードとはキーを共有するが組としては別になる
+type Entry struct {
+	KeyData   avltree.Key
+	ValueData interface{}
+	RefCount  int
+}
+
+// 同じ木から派生した全てのバージョンで共有する情報
+// IdleNodeから辿れるチェーン(LeftChildNodeで繋ぐ)に再利用可能なノードを保持する
+// LiveNodeCountはいずれかのバージョンに公開されているノードの数
+type NodePool struct {
+	IdleNode      *RefCountTreeNode
+	IdleNodeCount int
+	LiveNodeCount int
+	Finalizer     func(key avltree.Key, value interface{})
+}
+
+func New(allowDuplicateKeys bool) avltree.Tree {
+	return NewWithFinalizer(allowDuplicateKeys, nil)
+}
+
+// どのノードからも参照されなくなったキーと値の組に対してfinalizerが呼び出される木を生成する
+// finalizerはavltree.Releaseの内部で呼び出される
+func NewWithFinalizer(allowDuplicateKeys bool, finalizer func(key avltree.Key, value interface{})) avltree.Tree {
+	return &RefCountTree{
+		RootNode:                nil,
+		AllowDuplicateKeysValue: allowDuplicateKeys,
+		Pool:                    &NodePool{Finalizer: finalizer},
+	}
+}
+
+func unwrap(node avltree.Node) *RefCountTreeNode {
+	if node == nil {
+		return nil
+	} else {
+		return node.(*RefCountTreeNode)
+	}
+}
+
+func (node *RefCountTreeNode) toNode() avltree.Node {
+	if node == nil {
+		return nil
+	} else {
+		return node
+	}
+}
+
+func (pool *NodePool) newNode() *RefCountTreeNode {
+	node := pool.IdleNode
+	if node == nil {
+		return &RefCountTreeNode{Pool: pool}
+	}
+	pool.IdleNode = node.LeftChildNode
+	pool.IdleNodeCount--
+	node.LeftChildNode = nil
+	return node
+}
+
+// 公開されたノードの参照カウントを加算する
+// 初めて公開されたノードの場合は子とキーと値の組の参照カウントも加算する
+func (pool *NodePool) retain(node *RefCountTreeNode) {
+	if node == nil {
+		return
+	}
+	node.RefCount++
+	if node.RefCount > 1 {
+		return
+	}
+	pool.LiveNodeCount++
+	node.Entry.RefCount++
+	pool.retain(node.LeftChildNode)
+	pool.retain(node.RightChildNode)
+}
+
+// ノードの参照カウントを減算する
+// 参照カウントが0になったノードは子の参照カウントを減算したあと再利用のためにチェーンに戻す
+func (pool *NodePool) release(node *RefCountTreeNode) {
+	if node == nil {
+		return
+	}
+	node.RefCount--
+	if node.RefCount > 0 {
+		return
+	}
+	pool.release(node.LeftChildNode)
+	pool.release(node.RightChildNode)
+	entry := node.Entry
+	entry.RefCount--
+	if entry.RefCount == 0 && pool.Finalizer != nil {
+		pool.Finalizer(entry.KeyData, entry.ValueData)
+	}
+	*node = RefCountTreeNode{
+		LeftChildNode: pool.IdleNode,
+		Pool:          pool,
+	}
+	pool.IdleNode = node
+	pool.IdleNodeCount++
+	pool.LiveNodeCount--
+}
+
+func (tree *RefCountTree) checkReleased() {
+	if tree.released {
+		panic("released tree")
+	}
+}
+
+func (tree *RefCountTree) Root() avltree.Node {
+	return tree.RootNode.toNode()
+}
+
+func (tree *RefCountTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	tree.checkReleased()
+	newNode := tree.Pool.newNode()
+	newNode.LeftChildNode = unwrap(leftChild)
+	newNode.RightChildNode = unwrap(rightChild)
+	newNode.HeightValue = height
+	newNode.Entry = &Entry{
+		KeyData:   key,
+		ValueData: value,
+	}
+	newNode.resetNodeCount()
+	return newNode
+}
+
+func (tree *RefCountTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.checkReleased()
+	newTree := &RefCountTree{
+		RootNode:                unwrap(newRoot),
+		AllowDuplicateKeysValue: tree.AllowDuplicateKeysValue,
+		Pool:                    tree.Pool,
+	}
+	tree.Pool.retain(newTree.RootNode)
+	return newTree
+}
+
+func (tree *RefCountTree) AllowDuplicateKeys() bool {
+	return tree.AllowDuplicateKeysValue
+}
+
+func (tree *RefCountTree) NodeCount() int {
+	return tree.RootNode.NodeCount()
+}
+
+// 変更操作で得た新しいバージョンnewTreeを返し、不要になった古いバージョンoldTreeを解放する
+// 変更がなくoldTreeとnewTreeが同じ場合やoldTreeがnilの場合は何も解放しない
+func Advance(oldTree, newTree avltree.Tree) avltree.Tree {
+	if oldTree != nil && oldTree != newTree {
+		avltree.Release(&oldTree)
+	}
+	return newTree
+}
+
+// このバージョンが参照していたノードの参照カウントを減算する
+func (tree *RefCountTree) ReleaseTree() {
+	tree.checkReleased()
+	tree.Pool.release(tree.RootNode)
+	tree.RootNode = nil
+	tree.released = true
+}
+
+// どのバージョンにも公開されていないノードはそのまま、公開済みのノードは複製を返す
+func (node *RefCountTreeNode) mutable() *RefCountTreeNode {
+	if node.RefCount == 0 {
+		return node
+	}
+	newNode := node.Pool.newNode()
+	newNode.LeftChildNode = node.LeftChildNode
+	newNode.RightChildNode = node.RightChildNode
+	newNode.HeightValue = node.HeightValue
+	newNode.NodeCountValue = node.NodeCountValue
+	newNode.Entry = node.Entry
+	return newNode
+}
+
+func (node *RefCountTreeNode) resetNodeCount() {
+	if node != nil {
+		node.NodeCountValue = 1 + node.LeftChildNode.NodeCount() + node.RightChildNode.NodeCount()
+	}
+}
+
+func (node *RefCountTreeNode) NodeCount() int {
+	if node == nil {
+		return 0
+	} else {
+		return node.NodeCountValue
+	}
+}
+
+func (node *RefCountTreeNode) Key() avltree.Key {
+	return node.Entry.KeyData
+}
+
+func (node *RefCountTreeNode) Value() interface{} {
+	return node.Entry.ValueData
+}
+
+func (node *RefCountTreeNode) LeftChild() avltree.Node {
+	return node.LeftChildNode.toNode()
+}
+
+func (node *RefCountTreeNode) RightChild() avltree.Node {
+	return node.RightChildNode.toNode()
+}
+
+func (node *RefCountTreeNode) setValue(newValue interface{}) {
+	if node.Entry.RefCount == 0 {
+		node.Entry.ValueData = newValue
+	} else {
+		node.Entry = &Entry{
+			KeyData:   node.Entry.KeyData,
+			ValueData: newValue,
+		}
+	}
+}
+
+func (node *RefCountTreeNode) SetValue(newValue interface{}) avltree.Node {
+	newNode := node.mutable()
+	newNode.setValue(newValue)
+	return newNode
+}
+
+func (node *RefCountTreeNode) Height() int {
+	return node.HeightValue
+}
+
+func (node *RefCountTreeNode) SetChildren(newLeftChild, newRightChild avltree.Node, newHeight int) avltree.RealNode {
+	newNode := node.mutable()
+	newNode.LeftChildNode = unwrap(newLeftChild)
+	newNode.RightChildNode = unwrap(newRightChild)
+	newNode.HeightValue = newHeight
+	newNode.resetNodeCount()
+	return newNode
+}
+
+func (node *RefCountTreeNode) Set(newLeftChild, newRightChild avltree.Node, newHeight int, newValue interface{}) avltree.RealNode {
+	newNode := node.mutable()
+	newNode.LeftChildNode = unwrap(newLeftChild)
+	newNode.RightChildNode = unwrap(newRightChild)
+	newNode.HeightValue = newHeight
+	newNode.setValue(newValue)
+	newNode.resetNodeCount()
+	return newNode
+}
@@ -0,0 +1,224 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package refcounttree
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type (
+	Node = avltree.Node
+	Tree = avltree.Tree
+)
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+func getAllAscKeyAndValues(tree Tree) (result []int) {
+	avltree.Iterate(tree, false, func(node Node) (breakIteration bool) {
+		result = append(result, int(node.Key().(IntKey)))
+		result = append(result, node.Value().(int))
+		return
+	})
+	return
+}
+
+func toAscKeyAndValues(model map[int]int) (result []int) {
+	keys := []int{}
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		result = append(result, k, model[k])
+	}
+	return
+}
+
+func checkNode(node *RefCountTreeNode) bool {
+	if node == nil {
+		return true
+	}
+	if node.NodeCountValue != 1+node.LeftChildNode.NodeCount()+node.RightChildNode.NodeCount() {
+		return false
+	}
+	var hLeft, hRight int
+	if node.LeftChildNode != nil {
+		hLeft = node.LeftChildNode.HeightValue
+	}
+	if node.RightChildNode != nil {
+		hRight = node.RightChildNode.HeightValue
+	}
+	if hLeft < hRight {
+		hLeft, hRight = hRight, hLeft
+	}
+	if hLeft-hRight > 1 || node.HeightValue != hLeft+1 {
+		return false
+	}
+	return checkNode(node.LeftChildNode) && checkNode(node.RightChildNode)
+}
+
+// 操作ごとに得られる全てのバージョンを保持し、解放していないバージョンが壊れていないか確認する
+func TestVersions(t *testing.T) {
+	f := func(ops []keyAndValue, releaseOrder []int) bool {
+		tree := New(false)
+		versions := []Tree{tree}
+		models := []map[int]int{{}}
+		for _, op := range ops {
+			key := op.Key % 20
+			model := make(map[int]int)
+			for k, v := range models[len(models)-1] {
+				model[k] = v
+			}
+			switch op.Value % 3 {
+			case 0:
+				tree, _ = avltree.Delete(tree, IntKey(key))
+				delete(model, key)
+			default:
+				tree, _ = avltree.Insert(tree, true, IntKey(key), op.Value)
+				model[key] = op.Value
+			}
+			versions = append(versions, tree)
+			models = append(models, model)
+		}
+		released := make([]bool, len(versions))
+		for _, r := range releaseOrder {
+			if r < 0 {
+				r = -r
+			}
+			i := r % len(versions)
+			if released[i] {
+				continue
+			}
+			// 変更がなく同じバージョンが続く場合はまとめて解放する
+			for j := range versions {
+				if versions[j] == versions[i] {
+					released[j] = true
+				}
+			}
+			avltree.Release(&versions[i])
+			for j, version := range versions {
+				if released[j] {
+					continue
+				}
+				if !checkNode(version.(*RefCountTree).RootNode) {
+					return false
+				}
+				if !reflect.DeepEqual(getAllAscKeyAndValues(version), toAscKeyAndValues(models[j])) {
+					return false
+				}
+			}
+		}
+		pool := tree.(*RefCountTree).Pool
+		for j := range versions {
+			if released[j] {
+				continue
+			}
+			for k := range versions {
+				if versions[k] == versions[j] {
+					released[k] = true
+				}
+			}
+			avltree.Release(&versions[j])
+		}
+		return pool.LiveNodeCount == 0
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFinalizer(t *testing.T) {
+	f := func(ops []keyAndValue) bool {
+		finalized := []keyAndValue{}
+		tree := NewWithFinalizer(false, func(key avltree.Key, value interface{}) {
+			finalized = append(finalized, keyAndValue{int(key.(IntKey)), value.(int)})
+		})
+		published := []keyAndValue{}
+		for _, op := range ops {
+			key := op.Key % 20
+			var newTree Tree
+			if op.Value%3 == 0 {
+				newTree, _ = avltree.Delete(tree, IntKey(key))
+			} else {
+				newTree, _ = avltree.Insert(tree, true, IntKey(key), op.Value)
+				published = append(published, keyAndValue{key, op.Value})
+			}
+			tree = Advance(tree, newTree)
+		}
+		if tree.(*RefCountTree).Pool.LiveNodeCount != avltree.Count(tree) {
+			return false
+		}
+		avltree.Release(&tree)
+		less := func(list []keyAndValue) func(i, j int) bool {
+			return func(i, j int) bool {
+				if list[i].Key != list[j].Key {
+					return list[i].Key < list[j].Key
+				}
+				return list[i].Value < list[j].Value
+			}
+		}
+		sort.Slice(finalized, less(finalized))
+		sort.Slice(published, less(published))
+		return len(finalized) == len(published) &&
+			(len(published) == 0 || reflect.DeepEqual(finalized, published))
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReuseReleasedNodes(t *testing.T) {
+	tree := New(false)
+	for i := 0; i < 100; i++ {
+		newTree, _ := avltree.Insert(tree, false, IntKey(i), i)
+		tree = Advance(tree, newTree)
+	}
+	pool := tree.(*RefCountTree).Pool
+	if pool.LiveNodeCount != 100 {
+		t.Fatalf("want 100 live nodes but %d", pool.LiveNodeCount)
+	}
+	avltree.Release(&tree)
+	if pool.LiveNodeCount != 0 {
+		t.Fatalf("want 0 live nodes but %d", pool.LiveNodeCount)
+	}
+	idle := pool.IdleNodeCount
+	if idle < 100 {
+		t.Fatalf("want more than 100 idle nodes but %d", idle)
+	}
+
+	tree = &RefCountTree{Pool: pool}
+	for i := 0; i < 100; i++ {
+		newTree, _ := avltree.Insert(tree, false, IntKey(i), i)
+		tree = Advance(tree, newTree)
+	}
+	if pool.LiveNodeCount+pool.IdleNodeCount != idle {
+		t.Fatalf("released nodes are not reused: live %d idle %d", pool.LiveNodeCount, pool.IdleNodeCount)
+	}
+}
+
+func TestReleasedTreePanics(t *testing.T) {
+	tree := New(false)
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 1)
+	released := tree
+	avltree.Release(&tree)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on released tree")
+		}
+	}()
+	avltree.Insert(released, false, IntKey(2), 2)
+}
@@ -0,0 +1,249 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの木をキーの逆順で扱うためのキーとラッパーの実装例
+//
+// ReverseKeyは任意のKeyを包み、包んだキーの逆順で比較されるようにする
+// 最大のキーから順に取り出したい木のキーをReverseKeyで包めば、descOrderにtrueを指定しなくてもMinやIterateで大きい順に扱える
+//
+// Descendingは既存の木を複製せずにキーの逆順の木として見せるラッパーを返す
+// ラッパーのノードは左右の子を入れ替えて見せ、キーの比較結果も逆にするため、
+// Find,Range,Min,Max,Iterate,CountRangeなどの昇順を前提に書かれた処理がそのまま降順で動作する
+// 範囲の指定も逆順になるため、lowerには大きい側のキーを、upperには小さい側のキーを指定する
+// ラッパー経由でInsertやDeleteなどの変更もでき、変更は左右を入れ替えて元の木に反映される
+// 同一キーを許可する木では同一キーのノードどうしの順序も逆になる
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/reversetree"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_reversetree() {
+//			tree := simpletree.New(false)
+//			for _, k := range []int{5, 1, 9, 3, 7} {
+//				avltree.Insert(tree, false, IntKey(k), k*10)
+//			}
+//			desc := reversetree.Descending(tree)
+//			fmt.Println("Min!", avltree.Min(desc).Key(), avltree.Max(desc).Key())
+//			avltree.RangeIterate(desc, false, IntKey(8), IntKey(3), func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Range!", node.Key(), node.Value())
+//				return
+//			})
+//			avltree.Insert(desc, false, IntKey(4), 40)
+//			fmt.Println("Count!", avltree.Count(tree), avltree.CountRange(desc, IntKey(5), IntKey(3)))
+//			keys := simpletree.New(false)
+//			for _, k := range []int{5, 1, 9} {
+//				avltree.Insert(keys, false, reversetree.Reverse(IntKey(k)), k)
+//			}
+//			fmt.Println("Reverse!", avltree.Min(keys).Key(), avltree.Max(keys).Key())
+//			// Output:
+//			// Min! 9 1
+//			// Range! 7 70
+//			// Range! 5 50
+//			// Range! 3 30
+//			// Count! 6 3
+//			// Reverse! 9 1
+//		}
+//
+package reversetree
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 包んだキーの逆順で比較されるKey
+type ReverseKey struct {
+	Key avltree.Key
+}
+
+// キーの比較結果を逆にするためavltree.KeyComparatorを常に実装する
+type DescendingTree struct {
+	Inner avltree.RealTree
+}
+
+// ラップしている木がavltree.MutationObserverを実装している場合のDescendingTree
+// 変更の通知もラップしている木に委譲する
+type observerTree struct {
+	*DescendingTree
+}
+
+// ラップしている木のノードの左右の子を入れ替えて見せるノード
+type DescendingNode struct {
+	Inner avltree.Node
+}
+
+// ラップしているノードがavltree.NodeCounterを実装している場合のDescendingNode
+type counterNode struct {
+	DescendingNode
+}
+
+func Reverse(key avltree.Key) ReverseKey {
+	return ReverseKey{key}
+}
+
+func (key ReverseKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	return other.(ReverseKey).Key.CompareTo(key.Key)
+}
+
+func (key ReverseKey) Copy() avltree.Key {
+	return ReverseKey{key.Key.Copy()}
+}
+
+func (key ReverseKey) String() string {
+	return fmt.Sprint(key.Key)
+}
+
+// treeをキーの逆順の木として見せるラッパーを返す
+// treeがDescendingで得たラッパーの場合はラップしている元の木を返す
+// treeがavltree.MutationObserverを実装している場合はavltree.MutationObserverも実装した木を返す(*DescendingTreeへの型アサーションはできない)
+func Descending(tree avltree.Tree) avltree.Tree {
+	switch desc := tree.(type) {
+	case *DescendingTree:
+		return desc.Inner
+	case observerTree:
+		return desc.Inner
+	}
+	return wrap(&DescendingTree{tree.(avltree.RealTree)})
+}
+
+// ラップしている木がavltree.MutationObserverを実装している場合のみ通知を委譲する型で包む
+func wrap(tree *DescendingTree) avltree.RealTree {
+	if _, ok := tree.Inner.(avltree.MutationObserver); ok {
+		return observerTree{tree}
+	}
+	return tree
+}
+
+// ラップしているノードがavltree.NodeCounterを実装している場合のみavltree.NodeCounterを実装したノードで包む
+func mirror(node avltree.Node) avltree.Node {
+	if node == nil {
+		return nil
+	}
+	if _, ok := node.(avltree.NodeCounter); ok {
+		return counterNode{DescendingNode{node}}
+	}
+	return DescendingNode{node}
+}
+
+func mirrorReal(node avltree.RealNode) avltree.RealNode {
+	return mirror(node).(avltree.RealNode)
+}
+
+func unwrap(node avltree.Node) avltree.Node {
+	switch desc := node.(type) {
+	case DescendingNode:
+		return desc.Inner
+	case counterNode:
+		return desc.Inner
+	}
+	return nil
+}
+
+func (tree *DescendingTree) Root() avltree.Node {
+	return mirror(tree.Inner.Root())
+}
+
+func (tree *DescendingTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	return mirrorReal(tree.Inner.NewNode(unwrap(rightChild), unwrap(leftChild), height, key, value))
+}
+
+// ラップしている木のSetRootが新しい木を返した場合は新しいDescendingTreeでラップして返す
+func (tree *DescendingTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	var root avltree.RealNode
+	if newRoot != nil {
+		root = unwrap(newRoot).(avltree.RealNode)
+	}
+	newTree := tree.Inner.SetRoot(root)
+	if newTree == tree.Inner {
+		return wrap(tree)
+	}
+	return wrap(&DescendingTree{newTree})
+}
+
+func (tree *DescendingTree) AllowDuplicateKeys() bool {
+	return tree.Inner.AllowDuplicateKeys()
+}
+
+func (tree *DescendingTree) NodeCount() int {
+	return avltree.Count(tree.Inner)
+}
+
+// ラップしている木の比較方法の逆順でキーを比較する
+func (tree *DescendingTree) CompareKeys(key1, key2 avltree.Key) avltree.KeyOrdering {
+	return avltree.CompareKeys(tree.Inner, key2, key1)
+}
+
+// ラップしている木がavltree.NodeReleaserを実装している場合のみ処理を委譲する
+func (tree *DescendingTree) ReleaseNode(node avltree.RealNode) {
+	if releaser, ok := tree.Inner.(avltree.NodeReleaser); ok {
+		releaser.ReleaseNode(unwrap(node).(avltree.RealNode))
+	}
+}
+
+// ラップしている木がavltree.TreeCleanerを実装している場合のみ処理を委譲する
+func (tree *DescendingTree) CleanUpTree() {
+	if cleaner, ok := tree.Inner.(avltree.TreeCleaner); ok {
+		cleaner.CleanUpTree()
+	}
+}
+
+// ラップしている木がavltree.TreeReleaserを実装している場合のみ処理を委譲する
+func (tree *DescendingTree) ReleaseTree() {
+	if releaser, ok := tree.Inner.(avltree.TreeReleaser); ok {
+		releaser.ReleaseTree()
+	}
+}
+
+func (tree observerTree) NodeInserted(key avltree.Key, value interface{}) {
+	tree.Inner.(avltree.MutationObserver).NodeInserted(key, value)
+}
+
+func (tree observerTree) NodeReplaced(key avltree.Key, oldValue, newValue interface{}) {
+	tree.Inner.(avltree.MutationObserver).NodeReplaced(key, oldValue, newValue)
+}
+
+func (tree observerTree) NodeDeleted(key avltree.Key, value interface{}) {
+	tree.Inner.(avltree.MutationObserver).NodeDeleted(key, value)
+}
+
+func (node DescendingNode) Key() avltree.Key {
+	return node.Inner.Key()
+}
+
+func (node DescendingNode) Value() interface{} {
+	return node.Inner.Value()
+}
+
+func (node DescendingNode) LeftChild() avltree.Node {
+	return mirror(node.Inner.RightChild())
+}
+
+func (node DescendingNode) RightChild() avltree.Node {
+	return mirror(node.Inner.LeftChild())
+}
+
+func (node DescendingNode) SetValue(newValue interface{}) avltree.Node {
+	return mirror(node.Inner.SetValue(newValue))
+}
+
+func (node DescendingNode) Height() int {
+	return node.Inner.(avltree.RealNode).Height()
+}
+
+func (node DescendingNode) SetChildren(newLeftChild, newRightChild avltree.Node, newHeight int) avltree.RealNode {
+	return mirrorReal(node.Inner.(avltree.RealNode).SetChildren(unwrap(newRightChild), unwrap(newLeftChild), newHeight))
+}
+
+func (node DescendingNode) Set(newLeftChild, newRightChild avltree.Node, newHeight int, newValue interface{}) avltree.RealNode {
+	return mirrorReal(node.Inner.(avltree.RealNode).Set(unwrap(newRightChild), unwrap(newLeftChild), newHeight, newValue))
+}
+
+func (node counterNode) NodeCount() int {
+	return node.Inner.(avltree.NodeCounter).NodeCount()
+}
@@ -0,0 +1,229 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package reversetree
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/comparatortree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	"github.com/neetsdkasu/avltree/intarraytree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/observabletree"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/standardtree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type operation struct {
+	Kind  uint8
+	Key   int8
+	Upper int8
+	Value int
+}
+
+var treeMakers = map[string]func(dup bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"standardtree":  standardtree.New,
+	"immutabletree": immutabletree.New,
+	"intarraytree":  intarraytree.New,
+}
+
+// 同一キーを許可する木では同一キーのノードの順序が逆になるのでキーだけを並べる
+func toList(nodes []avltree.Node, dup bool) (list []int) {
+	for _, node := range nodes {
+		list = append(list, int(node.Key().(IntKey)))
+		if !dup {
+			list = append(list, node.Value().(int))
+		}
+	}
+	return
+}
+
+func listOf(kvs []avltree.KeyAndValue, dup bool) (list []int) {
+	for _, kv := range kvs {
+		list = append(list, int(kv.Key().(IntKey)))
+		if !dup {
+			list = append(list, kv.Value().(int))
+		}
+	}
+	return
+}
+
+// 木がAVL木の条件を満たしているか確認する
+func checkTree(node avltree.Node) (height int, ok bool) {
+	if node == nil {
+		return 0, true
+	}
+	leftHeight, ok1 := checkTree(node.LeftChild())
+	rightHeight, ok2 := checkTree(node.RightChild())
+	if !ok1 || !ok2 || leftHeight-rightHeight > 1 || rightHeight-leftHeight > 1 {
+		return 0, false
+	}
+	if left := node.LeftChild(); left != nil && left.Key().CompareTo(node.Key()).GreaterThan() {
+		return 0, false
+	}
+	if right := node.RightChild(); right != nil && right.Key().CompareTo(node.Key()).LessThan() {
+		return 0, false
+	}
+	height = 1 + leftHeight
+	if rightHeight > leftHeight {
+		height = 1 + rightHeight
+	}
+	return height, height == node.(avltree.RealNode).Height()
+}
+
+// ラッパー経由の操作がラップしていない木への逆順の操作と同じ結果になる
+func TestSameAsDescOrder(t *testing.T) {
+	for name, newTree := range treeMakers {
+		for _, dup := range []bool{false, true} {
+			f := func(ops []operation) bool {
+				tree := Descending(newTree(dup))
+				model := newTree(dup)
+				for _, op := range ops {
+					k, u := IntKey(op.Key%16), IntKey(op.Upper%16)
+					if k < u {
+						k, u = u, k
+					}
+					descOrder := op.Value%2 == 0
+					var ok1, ok2 bool
+					var list1, list2 []int
+					switch op.Kind % 8 {
+					case 0, 1:
+						tree, ok1 = avltree.Insert(tree, op.Kind%8 == 1, k, op.Value)
+						model, ok2 = avltree.Insert(model, op.Kind%8 == 1, k, op.Value)
+					case 2:
+						var kv1, kv2 avltree.KeyAndValue
+						tree, kv1 = avltree.Delete(tree, k)
+						model, kv2 = avltree.Delete(model, k)
+						ok1, ok2 = kv1 != nil, kv2 != nil
+					case 3:
+						tree, ok1 = avltree.Replace(tree, k, op.Value)
+						model, ok2 = avltree.Replace(model, k, op.Value)
+					case 4:
+						var kvs1, kvs2 []avltree.KeyAndValue
+						tree, kvs1 = avltree.DeleteRange(tree, descOrder, k, u)
+						model, kvs2 = avltree.DeleteRange(model, !descOrder, u, k)
+						list1, list2 = listOf(kvs1, dup), listOf(kvs2, dup)
+					case 5:
+						tree, ok1 = avltree.ReplaceRange(tree, k, u, op.Value)
+						model, ok2 = avltree.ReplaceRange(model, u, k, op.Value)
+					case 6:
+						ok1 = avltree.Find(tree, k) != nil
+						ok2 = avltree.Find(model, k) != nil
+						if avltree.CountRange(tree, k, u) != avltree.CountRange(model, u, k) {
+							return false
+						}
+						list1 = toList(avltree.Range(tree, descOrder, k, u), dup)
+						list2 = toList(avltree.Range(model, !descOrder, u, k), dup)
+					case 7:
+						list1 = toList(append(avltree.MinAll(tree), avltree.MaxAll(tree)...), true)
+						list2 = toList(append(avltree.MaxAll(model), avltree.MinAll(model)...), true)
+						if avltree.Count(tree) != avltree.Count(model) || len(avltree.FindAll(tree, k)) != len(avltree.FindAll(model, k)) {
+							return false
+						}
+					}
+					if ok1 != ok2 || !reflect.DeepEqual(list1, list2) {
+						return false
+					}
+				}
+				inner := Descending(tree)
+				if _, ok := checkTree(inner.Root()); !ok {
+					return false
+				}
+				return reflect.DeepEqual(toList(avltree.Range(inner, false, nil, nil), dup), toList(avltree.Range(model, false, nil, nil), dup))
+			}
+			if err := quick.Check(f, cfg1000); err != nil {
+				t.Fatal(name, dup, err)
+			}
+		}
+	}
+}
+
+func TestReverseKey(t *testing.T) {
+	f := func(a, b int) bool {
+		return Reverse(IntKey(a)).CompareTo(Reverse(IntKey(b))) == IntKey(b).CompareTo(IntKey(a))
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+	tree := simpletree.New(false)
+	for k := 0; k < 5; k++ {
+		avltree.Insert(tree, false, Reverse(IntKey(k)), k)
+	}
+	var list []int
+	avltree.RangeIterate(tree, false, Reverse(IntKey(3)), Reverse(IntKey(1)), func(node avltree.Node) (breakIteration bool) {
+		list = append(list, node.Value().(int))
+		return
+	})
+	if !reflect.DeepEqual(list, []int{3, 2, 1}) {
+		t.Fatal("wrong order", list)
+	}
+	key := Reverse(IntKey(7))
+	if copied := key.Copy().(ReverseKey); copied != key {
+		t.Fatal("not same", copied)
+	}
+}
+
+func TestDoubleDescending(t *testing.T) {
+	tree := simpletree.New(false)
+	if Descending(Descending(tree)) != tree {
+		t.Fatal("not original tree")
+	}
+}
+
+// ラップしている木の比較方法と変更の通知が使われる
+func TestWrapped(t *testing.T) {
+	count := 0
+	inner := observabletree.New(comparatortree.New(standardtree.New(false), func(a, b interface{}) int {
+		return a.(int) - b.(int)
+	}), func(event observabletree.Event) {
+		count++
+	})
+	tree := Descending(inner)
+	for k := 0; k < 5; k++ {
+		tree, _ = avltree.Insert(tree, false, comparatortree.Key(k), k)
+	}
+	tree, _ = avltree.Delete(tree, comparatortree.Key(2))
+	var list []int
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		list = append(list, node.Value().(int))
+		return
+	})
+	if count != 6 || !reflect.DeepEqual(list, []int{4, 3, 1, 0}) {
+		t.Fatal("wrong result", count, list)
+	}
+	if avltree.CountRange(tree, comparatortree.Key(3), comparatortree.Key(0)) != 3 || avltree.Min(inner).Value() != 0 {
+		t.Fatal("wrong count")
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	plain := Descending(simpletree.New(false))
+	avltree.Insert(plain, false, IntKey(1), 1)
+	if _, ok := plain.(avltree.MutationObserver); ok {
+		t.Fatal("implements MutationObserver without observer")
+	}
+	if _, ok := plain.Root().(avltree.NodeCounter); ok {
+		t.Fatal("implements NodeCounter without counter")
+	}
+	if Descending(plain) == plain {
+		t.Fatal("not unwrapped")
+	}
+	observed := Descending(observabletree.New(standardtree.New(false), func(observabletree.Event) {}))
+	observed, _ = avltree.Insert(observed, false, IntKey(1), 1)
+	if _, ok := observed.(avltree.MutationObserver); !ok {
+		t.Fatal("not implements MutationObserver")
+	}
+	if _, ok := observed.Root().(avltree.NodeCounter); !ok {
+		t.Fatal("not implements NodeCounter")
+	}
+	if _, ok := Descending(observed).(avltree.MutationObserver); !ok {
+		t.Fatal("not unwrapped")
+	}
+}
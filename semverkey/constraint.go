@@ -0,0 +1,267 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package semverkey
+
+import (
+	"strings"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// バージョンの範囲の境界
+type bound struct {
+	version   SemVerKey
+	inclusive bool
+}
+
+// バージョンの条件
+// 全ての比較を満たすバージョンが条件を満たす
+type Constraint struct {
+	// nilの場合は境界が無い
+	lower, upper *bound
+
+	// 条件の中に書かれたプレリリース版(同じメジャー、マイナー、パッチのプレリリース版のみ条件を満たせる)
+	preReleases []SemVerKey
+}
+
+// 空白(または',')で区切られた比較を全て満たすバージョンの条件を解釈する
+// 比較は演算子(>=,>,<=,<,=,^,~)とバージョンからなり、演算子を省略した場合は=になる
+// 比較のバージョンはマイナーやパッチを省略でき、省略された部分は任意の値を表す
+//
+//	">1.4"は">=1.5.0"、"<=1.4"は"<1.5.0"、"1.4"は">=1.4.0 <1.5.0"になる
+//	"^1.2.3"は">=1.2.3 <2.0.0"、"^0.2.3"は">=0.2.3 <0.3.0"、"~1.2.3"は">=1.2.3 <1.3.0"になる
+//
+// "*"または空の文字列は全てのプレリリースでないバージョンを表す
+func ParseConstraint(s string) (constraint Constraint, err error) {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "*" {
+			continue
+		}
+		version := strings.TrimLeft(field, "<>=^~")
+		op := field[:len(field)-len(version)]
+		if version == "" && i+1 < len(fields) {
+			// 演算子とバージョンの間に空白がある
+			i++
+			version = fields[i]
+		}
+		if err = constraint.add(s, op, version); err != nil {
+			return Constraint{}, err
+		}
+	}
+	return constraint, nil
+}
+
+// 条件を解釈する
+// 解釈できない場合はpanicする
+func MustParseConstraint(s string) Constraint {
+	constraint, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return constraint
+}
+
+// 省略されたバージョンを解釈し、省略されていない部分の数を返す
+func parsePartial(s, part string) (version SemVerKey, parts int, err error) {
+	core := part
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	if strings.Count(core, ".") == 2 {
+		version, err = Parse(part)
+		return version, 3, err
+	}
+	if core != part {
+		return SemVerKey{}, 0, invalid("%q has pre-release or build with partial version %q", s, part)
+	}
+	numbers, err := parseNumbers(s, core)
+	if err != nil {
+		return SemVerKey{}, 0, err
+	}
+	if len(numbers) > 2 {
+		return SemVerKey{}, 0, invalid("%q has too many numbers in %q", s, part)
+	}
+	switch len(numbers) {
+	case 2:
+		version.Minor = numbers[1]
+		fallthrough
+	case 1:
+		version.Major = numbers[0]
+	}
+	return version, len(numbers), nil
+}
+
+// 省略されていない部分の最後の数に1を加えたバージョン
+func nextVersion(version SemVerKey, parts int) SemVerKey {
+	switch parts {
+	case 1:
+		return SemVerKey{Major: version.Major + 1}
+	case 2:
+		return SemVerKey{Major: version.Major, Minor: version.Minor + 1}
+	default:
+		return SemVerKey{Major: version.Major, Minor: version.Minor, Patch: version.Patch + 1}
+	}
+}
+
+func (constraint *Constraint) add(s, op, part string) error {
+	version, parts, err := parsePartial(s, part)
+	if err != nil {
+		return err
+	}
+	if len(version.PreRelease) > 0 {
+		constraint.preReleases = append(constraint.preReleases, version)
+	}
+	switch op {
+	case ">=":
+		constraint.setLower(version, true)
+	case ">":
+		if parts == 3 {
+			constraint.setLower(version, false)
+		} else {
+			constraint.setLower(nextVersion(version, parts), true)
+		}
+	case "<=":
+		if parts == 3 {
+			constraint.setUpper(version, true)
+		} else {
+			constraint.setUpper(nextVersion(version, parts), false)
+		}
+	case "<":
+		constraint.setUpper(version, false)
+	case "", "=":
+		constraint.setLower(version, true)
+		if parts == 3 {
+			constraint.setUpper(version, true)
+		} else {
+			constraint.setUpper(nextVersion(version, parts), false)
+		}
+	case "^":
+		constraint.setLower(version, true)
+		switch {
+		case version.Major > 0 || parts == 1:
+			constraint.setUpper(nextVersion(version, 1), false)
+		case version.Minor > 0 || parts == 2:
+			constraint.setUpper(nextVersion(version, 2), false)
+		default:
+			constraint.setUpper(nextVersion(version, 3), false)
+		}
+	case "~":
+		constraint.setLower(version, true)
+		if parts == 1 {
+			constraint.setUpper(nextVersion(version, 1), false)
+		} else {
+			constraint.setUpper(nextVersion(version, 2), false)
+		}
+	default:
+		return invalid("%q has unknown operator %q", s, op)
+	}
+	return nil
+}
+
+// 下限を狭めるときだけ設定する
+func (constraint *Constraint) setLower(version SemVerKey, inclusive bool) {
+	if lower := constraint.lower; lower != nil {
+		cmp := version.CompareTo(lower.version)
+		if cmp.LessThan() || (cmp.EqualTo() && (inclusive || !lower.inclusive)) {
+			return
+		}
+	}
+	constraint.lower = &bound{version, inclusive}
+}
+
+// 上限を狭めるときだけ設定する
+func (constraint *Constraint) setUpper(version SemVerKey, inclusive bool) {
+	if upper := constraint.upper; upper != nil {
+		cmp := version.CompareTo(upper.version)
+		if cmp.GreaterThan() || (cmp.EqualTo() && (inclusive || !upper.inclusive)) {
+			return
+		}
+	}
+	constraint.upper = &bound{version, inclusive}
+}
+
+// versionが条件を満たすか
+func (constraint Constraint) Contains(version SemVerKey) bool {
+	if lower := constraint.lower; lower != nil {
+		if cmp := version.CompareTo(lower.version); cmp.LessThan() || (cmp.EqualTo() && !lower.inclusive) {
+			return false
+		}
+	}
+	if upper := constraint.upper; upper != nil {
+		if cmp := version.CompareTo(upper.version); cmp.GreaterThan() || (cmp.EqualTo() && !upper.inclusive) {
+			return false
+		}
+	}
+	if len(version.PreRelease) == 0 {
+		return true
+	}
+	for _, preRelease := range constraint.preReleases {
+		if version.compareCore(preRelease).EqualTo() {
+			return true
+		}
+	}
+	return false
+}
+
+// 条件の範囲の下限と上限(RangeIterateに渡す値で、境界を含まない場合も境界のバージョンを返す)
+func (constraint Constraint) bounds() (lower, upper avltree.Key) {
+	if constraint.lower != nil {
+		lower = constraint.lower.version
+	}
+	if constraint.upper != nil {
+		upper = constraint.upper.version
+	}
+	return
+}
+
+// SemVerKeyをキーとする木で条件を満たすキーを持つノードを指定の順序で巡ってコールバックを呼び出す
+// descOrderがfalseのときはキーの昇順
+// descOrderがtrueのときはキーの降順
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func Iterate(tree avltree.Tree, constraint Constraint, descOrder bool, callBack avltree.IterateCallBack) (ok bool) {
+	lower, upper := constraint.bounds()
+	if lower != nil && upper != nil && lower.CompareTo(upper).GreaterThan() {
+		return true
+	}
+	return avltree.RangeIterate(tree, descOrder, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		if !constraint.Contains(node.Key().(SemVerKey)) {
+			return false
+		}
+		return callBack(node)
+	})
+}
+
+// SemVerKeyをキーとする木で条件を満たす最大のキーを持つノードを返す
+// 条件を満たすキーが無い場合はnilを返す
+func Latest(tree avltree.Tree, constraint Constraint) (node avltree.Node) {
+	Iterate(tree, constraint, true, func(found avltree.Node) (breakIteration bool) {
+		node = found
+		return true
+	})
+	return
+}
+
+func (constraint Constraint) String() string {
+	var parts []string
+	if lower := constraint.lower; lower != nil {
+		if lower.inclusive {
+			parts = append(parts, ">="+lower.version.String())
+		} else {
+			parts = append(parts, ">"+lower.version.String())
+		}
+	}
+	if upper := constraint.upper; upper != nil {
+		if upper.inclusive {
+			parts = append(parts, "<="+upper.version.String())
+		} else {
+			parts = append(parts, "<"+upper.version.String())
+		}
+	}
+	if len(parts) == 0 {
+		return "*"
+	}
+	return strings.Join(parts, " ")
+}
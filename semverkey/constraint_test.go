@@ -0,0 +1,173 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package semverkey
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+func TestParseConstraint(t *testing.T) {
+	for _, tc := range []struct{ s, want string }{
+		{"", "*"},
+		{"*", "*"},
+		{">=1.4 <2.0", ">=1.4.0 <2.0.0"},
+		{">= 1.4, < 2", ">=1.4.0 <2.0.0"},
+		{">1.4", ">=1.5.0"},
+		{">1.4.2", ">1.4.2"},
+		{"<=1.4", "<1.5.0"},
+		{"<=1.4.2", "<=1.4.2"},
+		{"1.4", ">=1.4.0 <1.5.0"},
+		{"=1.4.2", ">=1.4.2 <=1.4.2"},
+		{"^1.2.3", ">=1.2.3 <2.0.0"},
+		{"^0.2.3", ">=0.2.3 <0.3.0"},
+		{"^0.0.3", ">=0.0.3 <0.0.4"},
+		{"^0.0", ">=0.0.0 <0.1.0"},
+		{"^0", ">=0.0.0 <1.0.0"},
+		{"~1.2.3", ">=1.2.3 <1.3.0"},
+		{"~1", ">=1.0.0 <2.0.0"},
+		{"^1.2 >=1.5 <1.9 <3", ">=1.5.0 <1.9.0"},
+		{">1.0.0 >=1.0.0", ">1.0.0"},
+		{"<=2.0.0 <2.0.0", "<2.0.0"},
+		{">=2.0.0-rc.1", ">=2.0.0-rc.1"},
+	} {
+		if constraint, err := ParseConstraint(tc.s); err != nil || constraint.String() != tc.want {
+			t.Fatal("wrong constraint", tc.s, constraint, err)
+		}
+	}
+	for _, s := range []string{">=", "=>1.0", "!1.0", "1.x", ">=1.0-rc", "1.2.3.4", "<01.0"} {
+		if constraint, err := ParseConstraint(s); !errors.Is(err, ErrInvalidVersion) {
+			t.Fatal("no error", s, constraint)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		contains   []string
+		excludes   []string
+	}{
+		{">=1.4 <2.0", []string{"1.4.0", "1.10.3", "1.99.99+build"}, []string{"1.3.9", "2.0.0", "1.4.0-rc.1", "2.0.0-rc.1"}},
+		{">=2.0.0-rc.1", []string{"2.0.0-rc.1", "2.0.0-rc.2", "2.0.0", "3.0.0"}, []string{"2.0.0-beta", "3.0.0-rc.1"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"*", []string{"0.0.0", "100.0.0"}, []string{"1.0.0-alpha"}},
+	} {
+		constraint := MustParseConstraint(tc.constraint)
+		for _, s := range tc.contains {
+			if !constraint.Contains(MustParse(s)) {
+				t.Fatal("not contains", tc.constraint, s)
+			}
+		}
+		for _, s := range tc.excludes {
+			if constraint.Contains(MustParse(s)) {
+				t.Fatal("contains", tc.constraint, s)
+			}
+		}
+	}
+}
+
+var operators = []string{">=", ">", "<=", "<", "=", "", "^", "~"}
+
+type comparatorSource struct {
+	Op      uint8
+	Version versionSource
+	Parts   uint8
+}
+
+func (src comparatorSource) String() string {
+	version := src.Version.version()
+	switch src.Parts % 4 {
+	case 1:
+		return fmt.Sprint(operators[int(src.Op)%len(operators)], version.Major)
+	case 2:
+		return fmt.Sprint(operators[int(src.Op)%len(operators)], version.Major, ".", version.Minor)
+	default:
+		return operators[int(src.Op)%len(operators)] + version.String()
+	}
+}
+
+// 木から条件で巡ったノードと全てのノードから条件で選んだノードが同じになる
+func TestIterate(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(sources []versionSource, comparators []comparatorSource, descOrder bool) bool {
+			tree := simpletree.New(dup)
+			for i, src := range sources {
+				avltree.Insert(tree, false, src.version(), i)
+			}
+			s := ""
+			for _, comparator := range comparators[:len(comparators)%3] {
+				s += comparator.String() + " "
+			}
+			constraint, err := ParseConstraint(s)
+			if err != nil {
+				return false
+			}
+			var got, want []avltree.Node
+			Iterate(tree, constraint, descOrder, func(node avltree.Node) (breakIteration bool) {
+				got = append(got, node)
+				return
+			})
+			avltree.Iterate(tree, descOrder, func(node avltree.Node) (breakIteration bool) {
+				if constraint.Contains(node.Key().(SemVerKey)) {
+					want = append(want, node)
+				}
+				return
+			})
+			latest := Latest(tree, constraint)
+			if len(want) == 0 {
+				return got == nil && latest == nil
+			}
+			last := want[len(want)-1]
+			if descOrder {
+				last = want[0]
+			}
+			return reflect.DeepEqual(got, want) && latest.Key().CompareTo(last.Key()).EqualTo()
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	for _, s := range []string{"1.9.0", "1.10.0", "1.4.2", "2.0.0-rc.1", "1.10.1-beta", "2.0.0", "1.3.0"} {
+		avltree.Insert(tree, false, MustParse(s), s)
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key())
+		return
+	})
+	constraint, _ := ParseConstraint(">=1.4 <2.0")
+	if node := Latest(tree, constraint); node != nil {
+		fmt.Println("Latest!", node.Key())
+	}
+	Iterate(tree, MustParseConstraint("^1.4.0"), true, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Match!", node.Key())
+		return
+	})
+	if _, err := Parse("1.02.0"); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// Iterate! 1.3.0
+	// Iterate! 1.4.2
+	// Iterate! 1.9.0
+	// Iterate! 1.10.0
+	// Iterate! 1.10.1-beta
+	// Iterate! 2.0.0-rc.1
+	// Iterate! 2.0.0
+	// Latest! 1.10.0
+	// Match! 1.10.0
+	// Match! 1.9.0
+	// Match! 1.4.2
+	// semverkey: invalid version: "1.02.0" has leading zero in "02"
+}
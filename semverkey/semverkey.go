@@ -0,0 +1,207 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// セマンティックバージョニング(SemVer 2.0.0)のバージョンをキーにしてある
+// 仕様の優先順位(precedence)をキーの順序としてある
+//
+//	メジャー、マイナー、パッチの順に数値として比較する("1.9.0"は"1.10.0"より小さい)
+//	プレリリース版はプレリリースの無い版より小さい("1.0.0-rc.1"は"1.0.0"より小さい)
+//	プレリリースの識別子は数字だけのものは数値として、それ以外はASCIIの順で比較し、数字だけの識別子のほうを小さいものとする
+//	ビルドメタデータは比較に使わない("1.0.0+a"と"1.0.0+b"は等しいキーになる)
+//
+// Parseは文字列をバージョンとして解釈し、仕様に沿わない文字列の場合はErrInvalidVersionをラップしたエラーを返す
+// ParseConstraintは">=1.4 <2.0"や"^1.2.3"のようなバージョンの条件を解釈する
+// IterateやLatestは条件を満たすキーのノードを条件から求めた範囲のRangeIterateで巡る
+// プレリリース版は条件の中に同じメジャー、マイナー、パッチのプレリリース版が書かれている場合にのみ条件を満たす
+// (">=1.4 <2.0"は"2.0.0-rc.1"を含まないが、">=2.0.0-rc.1"は"2.0.0-rc.2"を含む)
+package semverkey
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// バージョン
+// PreReleaseとBuildは'.'で区切られた識別子を要素とする
+type SemVerKey struct {
+	Major, Minor, Patch uint64
+	PreRelease          []string
+	Build               []string
+}
+
+var ErrInvalidVersion = errors.New("semverkey: invalid version")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidVersion}, args...)...)
+}
+
+// 文字列をバージョンとして解釈する
+// 先頭の"v"は受け付けない
+func Parse(s string) (version SemVerKey, err error) {
+	rest := s
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		if version.Build, err = parseIdentifiers(s, rest[i+1:], false); err != nil {
+			return SemVerKey{}, err
+		}
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		if version.PreRelease, err = parseIdentifiers(s, rest[i+1:], true); err != nil {
+			return SemVerKey{}, err
+		}
+		rest = rest[:i]
+	}
+	numbers, err := parseNumbers(s, rest)
+	if err != nil {
+		return SemVerKey{}, err
+	}
+	if len(numbers) != 3 {
+		return SemVerKey{}, invalid("%q must have major, minor and patch", s)
+	}
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+	return version, nil
+}
+
+// 文字列をバージョンとして解釈する
+// 仕様に沿わない文字列の場合はpanicする
+func MustParse(s string) SemVerKey {
+	version, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+// '.'で区切られた数値を解釈する
+func parseNumbers(s, part string) (numbers []uint64, err error) {
+	for _, field := range strings.Split(part, ".") {
+		if !isNumeric(field) {
+			return nil, invalid("%q has invalid number %q", s, field)
+		}
+		if len(field) > 1 && field[0] == '0' {
+			return nil, invalid("%q has leading zero in %q", s, field)
+		}
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, invalid("%q has too large number %q", s, field)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// '.'で区切られた識別子を解釈する
+// preReleaseがtrueの場合は数字だけの識別子の先頭の0を許さない
+func parseIdentifiers(s, part string, preRelease bool) ([]string, error) {
+	identifiers := strings.Split(part, ".")
+	for _, id := range identifiers {
+		if id == "" {
+			return nil, invalid("%q has empty identifier", s)
+		}
+		for i := 0; i < len(id); i++ {
+			if c := id[i]; !isDigit(c) && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && c != '-' {
+				return nil, invalid("%q has invalid identifier %q", s, id)
+			}
+		}
+		if preRelease && len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return nil, invalid("%q has leading zero in %q", s, id)
+		}
+	}
+	return identifiers, nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func compareUint(a, b uint64) avltree.KeyOrdering {
+	switch {
+	case a < b:
+		return avltree.LessThanOtherKey
+	case a > b:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+// プレリリースの識別子を比較する
+func compareIdentifier(a, b string) avltree.KeyOrdering {
+	numA, numB := isNumeric(a), isNumeric(b)
+	switch {
+	case numA && numB:
+		// 先頭に0が無いので桁数の少ないほうが小さい
+		if len(a) != len(b) {
+			return compareUint(uint64(len(a)), uint64(len(b)))
+		}
+	case numA:
+		return avltree.LessThanOtherKey
+	case numB:
+		return avltree.GreaterThanOtherKey
+	}
+	return avltree.KeyOrdering(strings.Compare(a, b))
+}
+
+func (version SemVerKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	v := other.(SemVerKey)
+	if cmp := version.compareCore(v); !cmp.EqualTo() {
+		return cmp
+	}
+	switch {
+	case len(version.PreRelease) == 0 && len(v.PreRelease) == 0:
+		return avltree.EqualToOtherKey
+	case len(version.PreRelease) == 0:
+		return avltree.GreaterThanOtherKey
+	case len(v.PreRelease) == 0:
+		return avltree.LessThanOtherKey
+	}
+	for i := 0; i < len(version.PreRelease) && i < len(v.PreRelease); i++ {
+		if cmp := compareIdentifier(version.PreRelease[i], v.PreRelease[i]); !cmp.EqualTo() {
+			return cmp
+		}
+	}
+	return compareUint(uint64(len(version.PreRelease)), uint64(len(v.PreRelease)))
+}
+
+// メジャー、マイナー、パッチだけを比較する
+func (version SemVerKey) compareCore(other SemVerKey) avltree.KeyOrdering {
+	if cmp := compareUint(version.Major, other.Major); !cmp.EqualTo() {
+		return cmp
+	}
+	if cmp := compareUint(version.Minor, other.Minor); !cmp.EqualTo() {
+		return cmp
+	}
+	return compareUint(version.Patch, other.Patch)
+}
+
+func (version SemVerKey) Copy() avltree.Key {
+	newVersion := version
+	newVersion.PreRelease = append([]string(nil), version.PreRelease...)
+	newVersion.Build = append([]string(nil), version.Build...)
+	return newVersion
+}
+
+func (version SemVerKey) String() string {
+	s := fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch)
+	if len(version.PreRelease) > 0 {
+		s += "-" + strings.Join(version.PreRelease, ".")
+	}
+	if len(version.Build) > 0 {
+		s += "+" + strings.Join(version.Build, ".")
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package semverkey
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+// 比較結果が偏らない小さなバージョンを作る
+type versionSource struct {
+	Major, Minor, Patch uint8
+	PreRelease          []uint8
+	Build               uint8
+}
+
+var identifiers = []string{"alpha", "beta", "rc", "0", "1", "2", "11", "x-y", "Z"}
+
+func (src versionSource) version() SemVerKey {
+	version := SemVerKey{
+		Major: uint64(src.Major % 3),
+		Minor: uint64(src.Minor % 3),
+		Patch: uint64(src.Patch % 3),
+	}
+	for _, id := range src.PreRelease[:len(src.PreRelease)%4] {
+		version.PreRelease = append(version.PreRelease, identifiers[int(id)%len(identifiers)])
+	}
+	if src.Build%2 == 0 {
+		version.Build = []string{"build", identifiers[int(src.Build)%len(identifiers)]}
+	}
+	return version
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"0.0.0", "1.2.3", "10.20.30", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-0.3.7", "1.0.0-x.7.z.92", "1.0.0-x-y-z.--", "1.0.0+20130313144700", "1.0.0-beta+exp.sha.5114f85", "1.0.0+21AF26D3----117B344092BD", "18446744073709551615.0.0"} {
+		version, err := Parse(s)
+		if err != nil || version.String() != s {
+			t.Fatal("failed to parse", s, version, err)
+		}
+	}
+	for _, s := range []string{"", "1", "1.2", "1.2.3.4", "v1.2.3", "01.2.3", "1.02.3", "1.2.03", "1.2.3-", "1.2.3+", "1.2.3-01", "1.2.3-a..b", "1.2.3+a_b", "1.2.3-α", "-1.2.3", "1.2.-3", "18446744073709551616.0.0", " 1.2.3"} {
+		if version, err := Parse(s); !errors.Is(err, ErrInvalidVersion) {
+			t.Fatal("no error", s, version)
+		}
+	}
+	// ビルドメタデータの数字は先頭が0でもよい
+	if _, err := Parse("1.2.3+001"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	// SemVer 2.0.0の仕様の例
+	list := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.9.0", "1.10.0", "1.11.0", "2.0.0", "2.1.0", "2.1.1"}
+	for i := range list {
+		for j := range list {
+			cmp := MustParse(list[i]).CompareTo(MustParse(list[j]))
+			if cmp != avltree.KeyOrdering(compareInt(i, j)) {
+				t.Fatal("wrong precedence", list[i], list[j], cmp)
+			}
+		}
+	}
+	if !MustParse("1.0.0+a").CompareTo(MustParse("1.0.0+b")).EqualTo() {
+		t.Fatal("build metadata is compared")
+	}
+	if !MustParse("1.0.0-99999999999999999999").CompareTo(MustParse("1.0.0-100000000000000000000")).LessThan() {
+		t.Fatal("large numeric identifiers")
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func TestSemVerKey(t *testing.T) {
+	f := func(src1, src2, src3 versionSource) bool {
+		v1, v2, v3 := src1.version(), src2.version(), src3.version()
+		if parsed, err := Parse(v1.String()); err != nil || !reflect.DeepEqual(parsed, v1) {
+			return false
+		}
+		if v1.CompareTo(v2) != -v2.CompareTo(v1) {
+			return false
+		}
+		// 推移律
+		list := []SemVerKey{v1, v2, v3}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].CompareTo(list[j]).LessThan()
+		})
+		return !list[0].CompareTo(list[2]).GreaterThan() && !list[0].CompareTo(list[1]).GreaterThan() && !list[1].CompareTo(list[2]).GreaterThan()
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	version := MustParse("1.2.3-rc.1+build.5")
+	copied := version.Copy().(SemVerKey)
+	version.PreRelease[1] = "2"
+	version.Build[1] = "6"
+	if copied.String() != "1.2.3-rc.1+build.5" {
+		t.Fatal("copy shares identifiers", copied)
+	}
+}
@@ -0,0 +1,415 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeを用いたシャード分割された順序付きマップの実装例
+//
+// キーの空間を境界のキーで複数の範囲(シャード)に分割し、シャードごとに木とsync.RWMutexを持つ
+// 異なるシャードへの変更は並行に実行できる
+// シャードの分割や結合(Split,Merge,Rebalance)はシャードの構成全体のロックを排他で取って行い、
+// その他の操作はシャードの構成全体のロックを共有で取ったうえで対象のシャードのロックを取る
+//
+// 複数のシャードにまたがる読み取り(Count,CountRange,Range,RangeIterate)はシャードを順に１つずつロックして行う
+// 各シャードの中では一貫した内容を読むが、マップ全体として同じ時点の内容を読むことは保証しない
+//
+// コールバックはシャードのロックを保持したまま呼び出されるため、コールバックの中から同じMapのメソッドを呼び出してはならない
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/shardedmap"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//		)
+//		func Example_shardedmap() {
+//			m := shardedmap.New(simpletree.New, false, IntKey(100), IntKey(200))
+//			for k := 0; k < 300; k += 25 {
+//				m.Insert(IntKey(k), k*2)
+//			}
+//			fmt.Println("Counts!", m.ShardCounts())
+//			m.RangeIterate(IntKey(80), IntKey(210), func(key avltree.Key, value interface{}) (breakIteration bool) {
+//				fmt.Println("RangeIterate!", key, value)
+//				return
+//			})
+//			m.Merge(IntKey(100))
+//			m.Split(IntKey(250))
+//			fmt.Println("Boundaries!", m.Boundaries())
+//			fmt.Println("Counts!", m.ShardCounts())
+//			// Output:
+//			// Counts! [4 4 4]
+//			// RangeIterate! 100 200
+//			// RangeIterate! 125 250
+//			// RangeIterate! 150 300
+//			// RangeIterate! 175 350
+//			// RangeIterate! 200 400
+//			// Boundaries! [200 250]
+//			// Counts! [8 2 2]
+//		}
+//
+package shardedmap
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type IterateCallBack = func(key avltree.Key, value interface{}) (breakIteration bool)
+
+type Map struct {
+	structure          sync.RWMutex
+	shards             []*shard
+	newTree            func(allowDuplicateKeys bool) avltree.Tree
+	allowDuplicateKeys bool
+}
+
+// Lowerはシャードの範囲の下限(このキーを含む)
+// 最初のシャードのLowerはnil(下限なし)
+// シャードの範囲の上限は次のシャードのLower(このキーを含まない)
+type shard struct {
+	lower avltree.Key
+	mutex sync.RWMutex
+	tree  avltree.Tree
+}
+
+type keyAndValueCopy struct {
+	key   avltree.Key
+	value interface{}
+}
+
+// 境界のキーboundariesで分割したMapを生成する
+// newTreeはシャードの木を生成する関数(simpletree.Newなど)
+// boundariesは昇順に並んでいて重複が無い必要がある
+func New(newTree func(allowDuplicateKeys bool) avltree.Tree, allowDuplicateKeys bool, boundaries ...avltree.Key) *Map {
+	m := &Map{
+		newTree:            newTree,
+		allowDuplicateKeys: allowDuplicateKeys,
+	}
+	m.shards = append(m.shards, m.newShard(nil))
+	for i, boundary := range boundaries {
+		if i > 0 && boundaries[i-1].CompareTo(boundary).GreaterThan() {
+			panic("boundaries are not sorted")
+		}
+		if i > 0 && boundaries[i-1].CompareTo(boundary).EqualTo() {
+			panic("duplicate boundaries")
+		}
+		m.shards = append(m.shards, m.newShard(boundary.Copy()))
+	}
+	return m
+}
+
+func (m *Map) newShard(lower avltree.Key) *shard {
+	return &shard{
+		lower: lower,
+		tree:  m.newTree(m.allowDuplicateKeys),
+	}
+}
+
+func (kv *keyAndValueCopy) Key() avltree.Key {
+	return kv.key
+}
+
+func (kv *keyAndValueCopy) Value() interface{} {
+	return kv.value
+}
+
+func copyNode(node avltree.Node) avltree.KeyAndValue {
+	if node == nil {
+		return nil
+	}
+	return &keyAndValueCopy{node.Key(), node.Value()}
+}
+
+// キーを含むシャードのインデックスを返す
+// 呼び出し側でstructureのロックを取っている必要がある
+func (m *Map) indexOf(key avltree.Key) int {
+	// lowerがkeyより大きい最初のシャードの１つ前
+	i := sort.Search(len(m.shards)-1, func(i int) bool {
+		return m.shards[i+1].lower.CompareTo(key).GreaterThan()
+	})
+	return i
+}
+
+// lower以上upper以下の範囲と重なるシャードのインデックスの範囲[first,last]を返す
+func (m *Map) indexRange(lower, upper avltree.Key) (first, last int) {
+	first, last = 0, len(m.shards)-1
+	if lower != nil {
+		first = m.indexOf(lower)
+	}
+	if upper != nil {
+		last = m.indexOf(upper)
+	}
+	return
+}
+
+func (m *Map) write(key avltree.Key, operation func(tree avltree.Tree) (modified avltree.Tree)) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	s := m.shards[m.indexOf(key)]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tree = operation(s.tree)
+}
+
+func (m *Map) read(key avltree.Key, operation func(tree avltree.Tree)) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	s := m.shards[m.indexOf(key)]
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	operation(s.tree)
+}
+
+func (m *Map) Insert(key avltree.Key, value interface{}) (ok bool) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(tree, false, key, value)
+		return
+	})
+	return
+}
+
+func (m *Map) InsertOrReplace(key avltree.Key, value interface{}) (ok bool) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Insert(tree, true, key, value)
+		return
+	})
+	return
+}
+
+func (m *Map) Delete(key avltree.Key) (deletedValue avltree.KeyAndValue) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue = avltree.Delete(tree, key)
+		return
+	})
+	return
+}
+
+func (m *Map) Update(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Update(tree, key, callBack)
+		return
+	})
+	return
+}
+
+func (m *Map) Replace(key avltree.Key, value interface{}) (ok bool) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, ok = avltree.Replace(tree, key, value)
+		return
+	})
+	return
+}
+
+func (m *Map) Alter(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValue avltree.KeyAndValue, ok bool) {
+	m.write(key, func(tree avltree.Tree) (modified avltree.Tree) {
+		modified, deletedValue, ok = avltree.Alter(tree, key, callBack)
+		return
+	})
+	return
+}
+
+// キーに一致するキーと値の複製を返す
+func (m *Map) Find(key avltree.Key) (value avltree.KeyAndValue) {
+	m.read(key, func(tree avltree.Tree) {
+		value = copyNode(avltree.Find(tree, key))
+	})
+	return
+}
+
+func (m *Map) Count() (count int) {
+	return m.CountRange(nil, nil)
+}
+
+func (m *Map) CountRange(lower, upper avltree.Key) (count int) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	if lower != nil && upper != nil && lower.CompareTo(upper).GreaterThan() {
+		return 0
+	}
+	first, last := m.indexRange(lower, upper)
+	for _, s := range m.shards[first : last+1] {
+		s.mutex.RLock()
+		count += avltree.CountRange(s.tree, lower, upper)
+		s.mutex.RUnlock()
+	}
+	return
+}
+
+// lower以上upper以下のキーを持つキーと値を順番にコールバックに渡す
+// シャードを順番に巡る(descOrderがtrueの場合は逆順に巡る)
+func (m *Map) rangeIterate(descOrder bool, lower, upper avltree.Key, callBack IterateCallBack) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	if lower != nil && upper != nil && lower.CompareTo(upper).GreaterThan() {
+		return
+	}
+	first, last := m.indexRange(lower, upper)
+	for i := first; i <= last; i++ {
+		s := m.shards[i]
+		if descOrder {
+			s = m.shards[first+last-i]
+		}
+		breakIteration := false
+		s.mutex.RLock()
+		avltree.RangeIterate(s.tree, descOrder, lower, upper, func(node avltree.Node) bool {
+			breakIteration = callBack(node.Key(), node.Value())
+			return breakIteration
+		})
+		s.mutex.RUnlock()
+		if breakIteration {
+			return
+		}
+	}
+}
+
+func (m *Map) Iterate(callBack IterateCallBack) {
+	m.rangeIterate(false, nil, nil, callBack)
+}
+
+func (m *Map) IterateRev(callBack IterateCallBack) {
+	m.rangeIterate(true, nil, nil, callBack)
+}
+
+func (m *Map) RangeIterate(lower, upper avltree.Key, callBack IterateCallBack) {
+	m.rangeIterate(false, lower, upper, callBack)
+}
+
+func (m *Map) RangeIterateRev(lower, upper avltree.Key, callBack IterateCallBack) {
+	m.rangeIterate(true, lower, upper, callBack)
+}
+
+// lower以上upper以下のキーを持つキーと値の複製を返す
+func (m *Map) Range(lower, upper avltree.Key) (values []avltree.KeyAndValue) {
+	m.rangeIterate(false, lower, upper, func(key avltree.Key, value interface{}) (breakIteration bool) {
+		values = append(values, &keyAndValueCopy{key, value})
+		return
+	})
+	return
+}
+
+func (m *Map) RangeRev(lower, upper avltree.Key) (values []avltree.KeyAndValue) {
+	m.rangeIterate(true, lower, upper, func(key avltree.Key, value interface{}) (breakIteration bool) {
+		values = append(values, &keyAndValueCopy{key, value})
+		return
+	})
+	return
+}
+
+// シャードの境界のキー(２番目以降のシャードの下限)を返す
+func (m *Map) Boundaries() (boundaries []avltree.Key) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	for _, s := range m.shards[1:] {
+		boundaries = append(boundaries, s.lower)
+	}
+	return
+}
+
+// 各シャードのキーの数を返す
+func (m *Map) ShardCounts() (counts []int) {
+	m.structure.RLock()
+	defer m.structure.RUnlock()
+	for _, s := range m.shards {
+		s.mutex.RLock()
+		counts = append(counts, avltree.Count(s.tree))
+		s.mutex.RUnlock()
+	}
+	return
+}
+
+// boundaryを含むシャードをboundaryの位置で２つに分割する
+// boundaryが既に境界の場合は何もせずfalseを返す
+func (m *Map) Split(boundary avltree.Key) (ok bool) {
+	m.structure.Lock()
+	defer m.structure.Unlock()
+	return m.split(boundary)
+}
+
+func (m *Map) split(boundary avltree.Key) (ok bool) {
+	i := m.indexOf(boundary)
+	s := m.shards[i]
+	if s.lower != nil && s.lower.CompareTo(boundary).EqualTo() {
+		return false
+	}
+	newShard := m.newShard(boundary.Copy())
+	var moved []avltree.KeyAndValue
+	s.tree, moved = avltree.DeleteRange(s.tree, false, boundary, nil)
+	for _, kv := range moved {
+		newShard.tree, _ = avltree.Insert(newShard.tree, false, kv.Key(), kv.Value())
+	}
+	m.shards = append(m.shards, nil)
+	copy(m.shards[i+2:], m.shards[i+1:])
+	m.shards[i+1] = newShard
+	return true
+}
+
+// boundaryを下限とするシャードを１つ前のシャードに結合する
+// boundaryが境界でない場合は何もせずfalseを返す
+func (m *Map) Merge(boundary avltree.Key) (ok bool) {
+	m.structure.Lock()
+	defer m.structure.Unlock()
+	i := m.indexOf(boundary)
+	if i == 0 || !m.shards[i].lower.CompareTo(boundary).EqualTo() {
+		return false
+	}
+	m.merge(i)
+	return true
+}
+
+// i番目のシャードをi-1番目のシャードに結合する
+func (m *Map) merge(i int) {
+	dst, src := m.shards[i-1], m.shards[i]
+	avltree.Iterate(src.tree, false, func(node avltree.Node) (breakIteration bool) {
+		dst.tree, _ = avltree.Insert(dst.tree, false, node.Key(), node.Value())
+		return
+	})
+	avltree.Release(&src.tree)
+	m.shards = append(m.shards[:i], m.shards[i+1:]...)
+}
+
+// キーの数がmaxShardSizeより多いシャードを中央のキーで分割し、
+// 隣り合うシャードのキーの数の合計がmaxShardSizeの半分以下の場合は結合する
+func (m *Map) Rebalance(maxShardSize int) {
+	m.structure.Lock()
+	defer m.structure.Unlock()
+	for i := 0; i < len(m.shards); i++ {
+		s := m.shards[i]
+		for avltree.Count(s.tree) > maxShardSize {
+			median := medianKey(s.tree)
+			if median == nil || (s.lower != nil && s.lower.CompareTo(median).EqualTo()) {
+				// 同一キーばかりで分割できない
+				break
+			}
+			m.split(median)
+		}
+	}
+	for i := 1; i < len(m.shards); {
+		if avltree.Count(m.shards[i-1].tree)+avltree.Count(m.shards[i].tree) <= maxShardSize/2 {
+			m.merge(i)
+		} else {
+			i++
+		}
+	}
+}
+
+// 木の中央のキーを返す
+// 中央のキーが最小のキーと同じ場合は中央より後ろで最初に現れる異なるキーを返す
+func medianKey(tree avltree.Tree) (median avltree.Key) {
+	half := avltree.Count(tree) / 2
+	var min avltree.Key
+	index := 0
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		if min == nil {
+			min = node.Key()
+		}
+		if index >= half && !min.CompareTo(node.Key()).EqualTo() {
+			median = node.Key()
+			return true
+		}
+		index++
+		return
+	})
+	return
+}
@@ -0,0 +1,226 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package shardedmap
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/simplewrapper"
+)
+
+// go test -race で実行することを想定したテストを含む
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"immutabletree": immutabletree.New,
+}
+
+func toKeyValueInts(list []avltree.KeyAndValue) (result []int) {
+	for _, kv := range list {
+		result = append(result, int(kv.Key().(IntKey)), kv.Value().(int))
+	}
+	return
+}
+
+func nodesToKeyValueInts(list []avltree.Node) (result []int) {
+	for _, node := range list {
+		result = append(result, int(node.Key().(IntKey)), node.Value().(int))
+	}
+	return
+}
+
+func toBoundaries(list []int8) (boundaries []avltree.Key) {
+	seen := map[int8]bool{}
+	var keys []int
+	for _, b := range list {
+		if !seen[b] {
+			seen[b] = true
+			keys = append(keys, int(b))
+		}
+	}
+	sort.Ints(keys)
+	for _, k := range keys {
+		boundaries = append(boundaries, IntKey(k))
+	}
+	return
+}
+
+func TestSameAsSimpleWrapper(t *testing.T) {
+	for name, newTree := range treeMakers {
+		for _, dup := range []bool{false, true} {
+			f := func(boundaries []int8, list []keyAndValue, k1, k2 int8, split, merge int8, size uint8) []int {
+				m := New(newTree, dup, toBoundaries(boundaries)...)
+				w := simplewrapper.New(newTree(dup))
+				var result []int
+				for i, kv := range list {
+					key := IntKey(int8(kv.Key))
+					op := i % 5
+					if dup {
+						// 同一キーがある場合にDeleteやReplaceで選ばれるノードは木の形に依存するため挿入だけ比較する
+						op = 0
+					}
+					switch op {
+					case 3:
+						d1, d2 := m.Delete(key), w.Delete(key)
+						if (d1 == nil) != (d2 == nil) {
+							result = append(result, -1)
+						}
+					case 4:
+						if m.Replace(key, kv.Value) != w.Replace(key, kv.Value) {
+							result = append(result, -2)
+						}
+					default:
+						if m.Insert(key, kv.Value) != w.Insert(key, kv.Value) {
+							result = append(result, -3)
+						}
+					}
+					if i%7 == 6 {
+						m.Rebalance(int(size%16) + 1)
+					}
+				}
+				m.Split(IntKey(split))
+				m.Merge(IntKey(merge))
+				lower, upper := IntKey(k1), IntKey(k2)
+				if !reflect.DeepEqual(toKeyValueInts(m.Range(lower, upper)), nodesToKeyValueInts(w.Range(lower, upper))) {
+					result = append(result, -4)
+				}
+				if !reflect.DeepEqual(toKeyValueInts(m.RangeRev(lower, upper)), nodesToKeyValueInts(w.RangeRev(lower, upper))) {
+					result = append(result, -5)
+				}
+				if m.CountRange(lower, upper) != w.CountRange(lower, upper) {
+					result = append(result, -6)
+				}
+				if m.Count() != w.Count() {
+					result = append(result, -7)
+				}
+				if kv := m.Find(lower); (kv == nil) != (w.Find(lower) == nil) {
+					result = append(result, -8)
+				}
+				total := 0
+				for _, c := range m.ShardCounts() {
+					total += c
+				}
+				if total != w.Count() || len(m.ShardCounts()) != len(m.Boundaries())+1 {
+					result = append(result, -9)
+				}
+				return result
+			}
+			if err := quick.CheckEqual(f, func(boundaries []int8, list []keyAndValue, k1, k2 int8, split, merge int8, size uint8) []int {
+				return nil
+			}, cfg1000); err != nil {
+				t.Fatal(name, dup, err)
+			}
+		}
+	}
+}
+
+func TestShardBoundaries(t *testing.T) {
+	m := New(simpletree.New, false, IntKey(10), IntKey(20))
+	for k := 0; k < 30; k++ {
+		m.Insert(IntKey(k), k)
+	}
+	if counts := m.ShardCounts(); !reflect.DeepEqual(counts, []int{10, 10, 10}) {
+		t.Fatal("wrong counts", counts)
+	}
+	if m.Split(IntKey(10)) {
+		t.Fatal("split at existing boundary")
+	}
+	if !m.Split(IntKey(5)) {
+		t.Fatal("failed to split")
+	}
+	if counts := m.ShardCounts(); !reflect.DeepEqual(counts, []int{5, 5, 10, 10}) {
+		t.Fatal("wrong counts", counts)
+	}
+	if m.Merge(IntKey(15)) {
+		t.Fatal("merge at non boundary")
+	}
+	if !m.Merge(IntKey(20)) {
+		t.Fatal("failed to merge")
+	}
+	if boundaries := m.Boundaries(); !reflect.DeepEqual(boundaries, []avltree.Key{IntKey(5), IntKey(10)}) {
+		t.Fatal("wrong boundaries", boundaries)
+	}
+	m.Rebalance(4)
+	for _, c := range m.ShardCounts() {
+		if c > 4 {
+			t.Fatal("too large shard", m.ShardCounts())
+		}
+	}
+	for k := 0; k < 30; k++ {
+		m.Delete(IntKey(k))
+	}
+	m.Rebalance(100)
+	if counts := m.ShardCounts(); len(counts) != 1 || counts[0] != 0 {
+		t.Fatal("not merged", counts)
+	}
+}
+
+func TestRebalanceSameKeys(t *testing.T) {
+	m := New(simpletree.New, true)
+	for i := 0; i < 10; i++ {
+		m.Insert(IntKey(7), i)
+	}
+	m.Rebalance(2)
+	if counts := m.ShardCounts(); !reflect.DeepEqual(counts, []int{10}) {
+		t.Fatal("split same keys", counts)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	const n = 8
+	const size = 500
+	m := New(simpletree.New, false, IntKey(size), IntKey(size*2), IntKey(size*3))
+	var wg sync.WaitGroup
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < size; i++ {
+				key := IntKey(g*size + i)
+				m.Insert(key, i)
+				if m.Find(key) == nil {
+					t.Error("not found", key)
+					return
+				}
+				if i%50 == 0 {
+					prev := IntKey(-1)
+					m.Iterate(func(key avltree.Key, value interface{}) (breakIteration bool) {
+						if prev.CompareTo(key).GreaterThan() {
+							t.Error("wrong order", prev, key)
+							return true
+						}
+						prev = key.(IntKey)
+						return
+					})
+				}
+			}
+		}(g)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			m.Rebalance(size / 2)
+		}
+	}()
+	wg.Wait()
+	if m.Count() != n*size {
+		t.Fatal("wrong count", m.Count())
+	}
+}
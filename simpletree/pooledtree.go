@@ -0,0 +1,71 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package simpletree
+
+import "github.com/neetsdkasu/avltree"
+
+// 木から切り離されたノードを再利用するSimpleTree
+// avltree.NodeReleaserとavltree.TreeReleaserを実装している
+//
+// 切り離されたノードはキーと値と子の参照を消去したうえで
+// IdleNodeから辿れるチェーン(LeftChildNodeで繋ぐ)に保持され、NewNodeで再利用される
+// チェーンは木ごとに持ち、avltree.Clearでも破棄されない
+// avltree.Releaseでチェーンは破棄され、以降この木を変更しようとするとpanicになる
+type PooledSimpleTree struct {
+	SimpleTree
+	IdleNode      *SimpleNode
+	IdleNodeCount int
+	released      bool
+}
+
+func NewPooled(allowDuplicateKeys bool) avltree.Tree {
+	return &PooledSimpleTree{SimpleTree: SimpleTree{nil, allowDuplicateKeys}}
+}
+
+func (tree *PooledSimpleTree) checkReleased() {
+	if tree.released {
+		panic("released tree")
+	}
+}
+
+func (tree *PooledSimpleTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	tree.checkReleased()
+	node := tree.IdleNode
+	if node == nil {
+		return &SimpleNode{leftChild, rightChild, height, key, value}
+	}
+	tree.IdleNode, _ = node.LeftChildNode.(*SimpleNode)
+	tree.IdleNodeCount--
+	node.LeftChildNode = leftChild
+	node.RightChildNode = rightChild
+	node.HeightValue = height
+	node.KeyData = key
+	node.ValueData = value
+	return node
+}
+
+func (tree *PooledSimpleTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.checkReleased()
+	tree.RootNode = newRoot
+	return tree
+}
+
+func (tree *PooledSimpleTree) ReleaseNode(node avltree.RealNode) {
+	tree.checkReleased()
+	simpleNode := node.(*SimpleNode)
+	*simpleNode = SimpleNode{}
+	// LeftChildNodeはavltree.Node型なので、IdleNodeがnilのときに代入すると型付きのnilになってしまう
+	if tree.IdleNode != nil {
+		simpleNode.LeftChildNode = tree.IdleNode
+	}
+	tree.IdleNode = simpleNode
+	tree.IdleNodeCount++
+}
+
+func (tree *PooledSimpleTree) ReleaseTree() {
+	tree.RootNode = nil
+	tree.IdleNode = nil
+	tree.IdleNodeCount = 0
+	tree.released = true
+}
@@ -0,0 +1,125 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package simpletree
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func TestPooledInsertAndDelete(t *testing.T) {
+	f := func(list1Base, list2Base []keyAndValue) []int {
+		lists := omitAllDuplicates([][]keyAndValue{list1Base, list2Base})
+		tree := NewPooled(false)
+		for _, kv := range lists[0] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		deleted := 0
+		for i, kv := range lists[0] {
+			if i%2 == 0 {
+				avltree.Delete(tree, IntKey(kv.Key))
+				deleted++
+			}
+		}
+		if tree.(*PooledSimpleTree).IdleNodeCount != deleted {
+			return nil
+		}
+		for _, kv := range lists[1] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		reused := deleted
+		if len(lists[1]) < reused {
+			reused = len(lists[1])
+		}
+		if tree.(*PooledSimpleTree).IdleNodeCount != deleted-reused {
+			return nil
+		}
+		if invalidNode := takeInvalidHeightNode(tree); invalidNode != nil {
+			return nil
+		}
+		if invalidNode := takeInvalidBalanceNode(tree); invalidNode != nil {
+			return nil
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	g := func(list1Base, list2Base []keyAndValue) []int {
+		lists := omitAllDuplicates([][]keyAndValue{list1Base, list2Base})
+		tree := New(false)
+		for _, kv := range lists[0] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		for i, kv := range lists[0] {
+			if i%2 == 0 {
+				avltree.Delete(tree, IntKey(kv.Key))
+			}
+		}
+		for _, kv := range lists[1] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPooledReleaseNode(t *testing.T) {
+	tree := NewPooled(false)
+	avltree.Insert(tree, false, IntKey(1), 10)
+	avltree.Insert(tree, false, IntKey(2), 20)
+	avltree.Insert(tree, false, IntKey(3), 30)
+	node := avltree.Find(tree, IntKey(3)).(*SimpleNode)
+	avltree.Delete(tree, IntKey(3))
+	avltree.Delete(tree, IntKey(1))
+
+	// 切り離されたノードはキーと値と子の参照が消去される
+	want := &SimpleNode{}
+	if !reflect.DeepEqual(node, want) {
+		t.Fatalf("want %#v but %#v", want, node)
+	}
+
+	avltree.Insert(tree, false, IntKey(4), 40)
+	avltree.Insert(tree, false, IntKey(5), 50)
+	if avltree.Find(tree, IntKey(5)) != node {
+		t.Fatal("released node is not reused")
+	}
+	if tree.(*PooledSimpleTree).IdleNodeCount != 0 {
+		t.Fatal("idle nodes remain")
+	}
+}
+
+func TestPooledClear(t *testing.T) {
+	tree := NewPooled(false)
+	for i := 0; i < 10; i++ {
+		avltree.Insert(tree, false, IntKey(i), i)
+	}
+	avltree.Clear(tree)
+	if tree.Root() != nil || tree.(*PooledSimpleTree).IdleNodeCount != 10 {
+		t.Fatal("nodes are not released")
+	}
+}
+
+func TestPooledRelease(t *testing.T) {
+	tree := NewPooled(false)
+	for i := 0; i < 10; i++ {
+		avltree.Insert(tree, false, IntKey(i), i)
+	}
+	pooled := tree.(*PooledSimpleTree)
+	avltree.Release(&tree)
+	if pooled.IdleNode != nil || pooled.IdleNodeCount != 0 {
+		t.Fatal("idle nodes remain")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on released tree")
+		}
+	}()
+	avltree.Insert(pooled, false, IntKey(1), 1)
+}
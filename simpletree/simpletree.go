@@ -4,6 +4,8 @@
 // github.com/neetsdkasu/avltreeのRealTree,RealNodeの実装例
 // 実装に必要な最小限の構成になっている
 //
+// NewPooledを使うと木から切り離されたノードを再利用する木を生成できる(PooledSimpleTreeを参照)
+//
 // コード例
 //
 //		import (
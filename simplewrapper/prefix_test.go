@@ -0,0 +1,56 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package simplewrapper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	"github.com/neetsdkasu/avltree/stringkey"
+)
+
+func TestStringPrefix(t *testing.T) {
+	w := New(immutabletree.New(false))
+	for _, key := range []string{"ab", "a", "abc", "b", "ab\xff", "ac"} {
+		w.Insert(stringkey.StringKey(key), key)
+	}
+	var got []string
+	w.StringPrefixIterate("ab", func(node avltree.Node) (breakIteration bool) {
+		got = append(got, node.Value().(string))
+		return
+	})
+	if !reflect.DeepEqual(got, []string{"ab", "abc", "ab\xff"}) || w.StringPrefixCount("ab") != 3 {
+		t.Fatal("wrong prefix scan", got)
+	}
+	if w.StringPrefixIterate("a", func(node avltree.Node) (breakIteration bool) { return true }) {
+		t.Fatal("break is not reported")
+	}
+	if deleted := w.StringPrefixDelete("ab"); len(deleted) != 3 || w.Count() != 3 || w.StringPrefixCount("a") != 2 {
+		t.Fatal("wrong prefix delete", deleted)
+	}
+}
+
+func TestBytesPrefix(t *testing.T) {
+	w := New(immutabletree.New(false))
+	for _, key := range []string{"\xff", "\xff\xff", "\xff\x00", "\xfe\xff", "\xff\xff\x01"} {
+		w.Insert(byteskey.BytesKey(key), key)
+	}
+	var got []string
+	w.BytesPrefixIterate([]byte("\xff\xff"), func(node avltree.Node) (breakIteration bool) {
+		got = append(got, node.Value().(string))
+		return
+	})
+	if !reflect.DeepEqual(got, []string{"\xff\xff", "\xff\xff\x01"}) || w.BytesPrefixCount([]byte("\xff")) != 4 {
+		t.Fatal("wrong prefix scan", got)
+	}
+	if !w.BytesPrefixIterate([]byte("\x00"), func(node avltree.Node) (breakIteration bool) { return true }) {
+		t.Fatal("empty prefix scan is not ok")
+	}
+	if deleted := w.BytesPrefixDelete([]byte("\xfe")); len(deleted) != 1 || w.Count() != 4 {
+		t.Fatal("wrong prefix delete", deleted)
+	}
+}
@@ -40,7 +40,11 @@
 //
 package simplewrapper
 
-import "github.com/neetsdkasu/avltree"
+import (
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+	"github.com/neetsdkasu/avltree/stringkey"
+)
 
 type AVLTree struct {
 	Tree avltree.Tree
@@ -258,3 +262,33 @@ func (tree *AVLTree) AlterRangeIterateRev(lower, upper avltree.Key, callBack avl
 	tree.Tree, deletedValues, ok = avltree.AlterRangeIterate(tree.Tree, true, lower, upper, callBack)
 	return
 }
+
+// キーがstringkey.StringKeyの木でprefixで始まるキーを持つノードを昇順に巡る
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func (tree *AVLTree) StringPrefixIterate(prefix string, callBack avltree.IterateCallBack) (ok bool) {
+	return stringkey.PrefixIterate(tree.Tree, prefix, callBack)
+}
+
+func (tree *AVLTree) StringPrefixCount(prefix string) int {
+	return stringkey.PrefixCount(tree.Tree, prefix)
+}
+
+func (tree *AVLTree) StringPrefixDelete(prefix string) (deletedValues []avltree.KeyAndValue) {
+	tree.Tree, deletedValues = stringkey.PrefixDelete(tree.Tree, prefix)
+	return
+}
+
+// キーがbyteskey.BytesKeyの木でprefixで始まるキーを持つノードを昇順に巡る
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func (tree *AVLTree) BytesPrefixIterate(prefix []byte, callBack avltree.IterateCallBack) (ok bool) {
+	return byteskey.PrefixIterate(tree.Tree, prefix, callBack)
+}
+
+func (tree *AVLTree) BytesPrefixCount(prefix []byte) int {
+	return byteskey.PrefixCount(tree.Tree, prefix)
+}
+
+func (tree *AVLTree) BytesPrefixDelete(prefix []byte) (deletedValues []avltree.KeyAndValue) {
+	tree.Tree, deletedValues = byteskey.PrefixDelete(tree.Tree, prefix)
+	return
+}
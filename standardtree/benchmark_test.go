@@ -223,3 +223,64 @@ func BenchmarkDescRangeIterate(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkInsertと同じ大きさの木に、一度削除してプールに戻したノードを再利用して挿入する
+func BenchmarkPooledInsert(b *testing.B) {
+	tree := NewPooled(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	list = list[b.N:]
+	for _, kv := range list {
+		avltree.Delete(tree, IntKey(kv.Key))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+}
+
+// BenchmarkDeleteと同じ大きさの木から、一度削除して再利用したノードを削除する
+func BenchmarkPooledDelete(b *testing.B) {
+	tree := NewPooled(true)
+	list := genKeyAndValues(2 * b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	list = list[:b.N]
+	for _, kv := range list {
+		avltree.Delete(tree, IntKey(kv.Key))
+	}
+	for _, kv := range list {
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Delete(tree, IntKey(kv.Key))
+	}
+}
+
+// 削除と挿入を繰り返す場合のメモリ割り当ての比較用
+func benchmarkChurn(b *testing.B, tree Tree) {
+	list := genKeyAndValues(b.N)
+	for _, kv := range list {
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, kv := range list {
+		avltree.Delete(tree, IntKey(kv.Key))
+		avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	benchmarkChurn(b, New(true))
+}
+
+func BenchmarkPooledChurn(b *testing.B) {
+	benchmarkChurn(b, NewPooled(true))
+}
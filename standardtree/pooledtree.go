@@ -0,0 +1,86 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package standardtree
+
+import "github.com/neetsdkasu/avltree"
+
+// 木から切り離されたノードを再利用するStandardTree
+// avltree.NodeReleaserとavltree.TreeReleaserを実装している
+//
+// 切り離されたノードはキーと値と親子の参照を消去したうえで
+// IdleNodeから辿れるチェーン(LeftChildNodeで繋ぐ)に保持され、NewNodeで再利用される
+// チェーンは木ごとに持ち、avltree.Clearでも破棄されない
+// avltree.Releaseでチェーンは破棄され、以降この木を変更しようとするとpanicになる
+type PooledStandardTree struct {
+	StandardTree
+	IdleNode      *StandardTreeNode
+	IdleNodeCount int
+	released      bool
+}
+
+func NewPooled(allowDuplicateKeys bool) avltree.Tree {
+	return &PooledStandardTree{
+		StandardTree: StandardTree{
+			nil, // RootNode
+			allowDuplicateKeys,
+		},
+	}
+}
+
+func (tree *PooledStandardTree) checkReleased() {
+	if tree.released {
+		panic("released tree")
+	}
+}
+
+func (tree *PooledStandardTree) NewNode(
+	leftChild,
+	rightChild avltree.Node,
+	height int,
+	key avltree.Key,
+	value interface{},
+) avltree.RealNode {
+	tree.checkReleased()
+	node := tree.IdleNode
+	if node == nil {
+		return tree.StandardTree.NewNode(leftChild, rightChild, height, key, value)
+	}
+	tree.IdleNode = node.LeftChildNode
+	tree.IdleNodeCount--
+	node.LeftChildNode = unwrap(leftChild)
+	node.RightChildNode = unwrap(rightChild)
+	node.HeightValue = height
+	node.ParentNode = nil
+	node.KeyData = key
+	node.ValueData = value
+	node.resetNodeCount()
+	return node
+}
+
+func (tree *PooledStandardTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	tree.checkReleased()
+	tree.StandardTree.SetRoot(newRoot)
+	return tree
+}
+
+func (tree *PooledStandardTree) ReleaseNode(node avltree.RealNode) {
+	tree.checkReleased()
+	standardNode := unwrap(node)
+	standardNode.LeftChildNode = tree.IdleNode
+	standardNode.RightChildNode = nil
+	standardNode.HeightValue = 0
+	standardNode.ParentNode = nil
+	standardNode.NodeCountValue = 0
+	standardNode.KeyData = nil
+	standardNode.ValueData = nil
+	tree.IdleNode = standardNode
+	tree.IdleNodeCount++
+}
+
+func (tree *PooledStandardTree) ReleaseTree() {
+	tree.RootNode = nil
+	tree.IdleNode = nil
+	tree.IdleNodeCount = 0
+	tree.released = true
+}
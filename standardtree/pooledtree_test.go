@@ -0,0 +1,163 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package standardtree
+
+import (
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+)
+
+func TestPooledInsertAndDelete(t *testing.T) {
+	f := func(list1Base, list2Base []keyAndValue) []int {
+		lists := omitAllDuplicates([][]keyAndValue{list1Base, list2Base})
+		tree := NewPooled(false)
+		for _, kv := range lists[0] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		deleted := 0
+		for i, kv := range lists[0] {
+			if i%2 == 0 {
+				avltree.Delete(tree, IntKey(kv.Key))
+				deleted++
+			}
+		}
+		if tree.(*PooledStandardTree).IdleNodeCount != deleted {
+			return nil
+		}
+		for _, kv := range lists[1] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		reused := deleted
+		if len(lists[1]) < reused {
+			reused = len(lists[1])
+		}
+		if tree.(*PooledStandardTree).IdleNodeCount != deleted-reused {
+			return nil
+		}
+		if invalidNode := takeInvalidHeightNode(tree); invalidNode != nil {
+			return nil
+		}
+		if invalidNode := takeInvalidBalanceNode(tree); invalidNode != nil {
+			return nil
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	g := func(list1Base, list2Base []keyAndValue) []int {
+		lists := omitAllDuplicates([][]keyAndValue{list1Base, list2Base})
+		tree := New(false)
+		for _, kv := range lists[0] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		for i, kv := range lists[0] {
+			if i%2 == 0 {
+				avltree.Delete(tree, IntKey(kv.Key))
+			}
+		}
+		for _, kv := range lists[1] {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		return getAllAscKeyAndValues(tree)
+	}
+
+	if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPooledReleaseNode(t *testing.T) {
+	tree := NewPooled(false)
+	avltree.Insert(tree, false, IntKey(1), 10)
+	avltree.Insert(tree, false, IntKey(2), 20)
+	avltree.Insert(tree, false, IntKey(3), 30)
+	node := avltree.Find(tree, IntKey(3)).(*StandardTreeNode)
+	avltree.Delete(tree, IntKey(3))
+	avltree.Delete(tree, IntKey(1))
+
+	// 切り離されたノードはキーと値と親子の参照が消去される
+	want := &StandardTreeNode{}
+	if !reflect.DeepEqual(node, want) {
+		t.Fatalf("want %#v but %#v", want, node)
+	}
+
+	avltree.Insert(tree, false, IntKey(4), 40)
+	avltree.Insert(tree, false, IntKey(5), 50)
+	if avltree.Find(tree, IntKey(5)) != node {
+		t.Fatal("released node is not reused")
+	}
+	if tree.(*PooledStandardTree).IdleNodeCount != 0 {
+		t.Fatal("idle nodes remain")
+	}
+}
+
+func TestPooledClear(t *testing.T) {
+	tree := NewPooled(false)
+	for i := 0; i < 10; i++ {
+		avltree.Insert(tree, false, IntKey(i), i)
+	}
+	avltree.Clear(tree)
+	if tree.Root() != nil || tree.(*PooledStandardTree).IdleNodeCount != 10 {
+		t.Fatal("nodes are not released")
+	}
+}
+
+func TestPooledRelease(t *testing.T) {
+	tree := NewPooled(false)
+	for i := 0; i < 10; i++ {
+		avltree.Insert(tree, false, IntKey(i), i)
+	}
+	pooled := tree.(*PooledStandardTree)
+	avltree.Release(&tree)
+	if pooled.IdleNode != nil || pooled.IdleNodeCount != 0 {
+		t.Fatal("idle nodes remain")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on released tree")
+		}
+	}()
+	avltree.Insert(pooled, false, IntKey(1), 1)
+}
+
+func TestPooledParentAndNodeCount(t *testing.T) {
+	f := func(list1, list2 []keyAndValue) bool {
+		tree := NewPooled(true)
+		for _, kv := range list1 {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		for i, kv := range list1 {
+			if i%2 == 0 {
+				avltree.Delete(tree, IntKey(kv.Key))
+			}
+		}
+		for _, kv := range list2 {
+			avltree.Insert(tree, false, IntKey(kv.Key), kv.Value)
+		}
+		if tree.(avltree.NodeCounter).NodeCount() != avltree.Count(tree) {
+			return false
+		}
+		checkNode := func(node Node) (ok bool) {
+			standardNode := node.(*StandardTreeNode)
+			if parent := standardNode.ParentNode; parent != nil {
+				if parent.LeftChildNode != standardNode && parent.RightChildNode != standardNode {
+					return false
+				}
+			} else if tree.Root() != node {
+				return false
+			}
+			return standardNode.NodeCountValue == 1+
+				standardNode.LeftChildNode.NodeCount()+
+				standardNode.RightChildNode.NodeCount()
+		}
+		return takeInvalidNode(tree, checkNode) == nil
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,8 @@
 // + 各ノードが親ノードの情報を持っている (avltree.ParentGetterを実装している)
 // + 各ノードがそのノードをサブツリーとしたときのノード総数の情報を持っている(avltree.NodeCounterを実装している)
 //
+// NewPooledを使うと木から切り離されたノードを再利用する木を生成できる(PooledStandardTreeを参照)
+//
 // コード例
 //
 //		import (
@@ -0,0 +1,46 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"unicode"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 大文字と小文字を同一視して比較するキー
+// 各文字はunicode.SimpleFoldで互いに移り合う文字の中で最小の文字に置き換えて比較する
+// そのため"Apple"と"apple"は等しいキーになり、英字は'Z'と'['の間にあるものとして並ぶ
+type FoldedKey string
+
+func (key FoldedKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	if bound, ok := other.(*prefixBound); ok {
+		return -bound.CompareTo(key)
+	}
+	s1 := string(key)
+	s2 := string(other.(FoldedKey))
+	return avltree.KeyOrdering(compareRunes(s1, s2, foldRune, false))
+}
+
+func (key FoldedKey) Copy() avltree.Key {
+	return key
+}
+
+// 大文字と小文字を同一視してprefixで始まる全てのFoldedKeyを範囲とする下限と上限を返す
+func FoldedPrefix(prefix string) (lower, upper avltree.Key) {
+	return newPrefixBounds(prefix, func(s, prefix string) int {
+		return compareRunes(s, prefix, foldRune, true)
+	})
+}
+
+// unicode.SimpleFoldで互いに移り合う文字の中で最小の文字を返す
+func foldRune(r rune) rune {
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
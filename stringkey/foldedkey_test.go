@@ -0,0 +1,50 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+func TestFoldedKey(t *testing.T) {
+	f := func(k1, k2 string) bool {
+		var key1 avltree.Key = FoldedKey(k1)
+		var key2 avltree.Key = FoldedKey(k2)
+		cmp := key1.CompareTo(key2)
+		if cmp != -key2.CompareTo(key1) || cmp.EqualTo() != strings.EqualFold(k1, k2) {
+			return false
+		}
+		// ASCIIの文字列では大文字にした文字列の順序と同じ
+		a1, a2 := toASCII(k1), toASCII(k2)
+		return FoldedKey(a1).CompareTo(FoldedKey(a2)) == avltree.KeyOrdering(strings.Compare(strings.ToUpper(a1), strings.ToUpper(a2)))
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFoldRune(t *testing.T) {
+	for _, s := range []string{"KkK", "Ssſ", "Σσς", "Ǆǅǆ"} {
+		key := FoldedKey(s[:len(string([]rune(s)[0]))])
+		for _, r := range s {
+			if !key.CompareTo(FoldedKey(string(r))).EqualTo() {
+				t.Fatal("not folded", s, string(r))
+			}
+		}
+	}
+}
+
+// 印字可能なASCII文字だけの文字列にする
+func toASCII(s string) string {
+	b := []byte(s)
+	for i := range b {
+		b[i] = ' ' + b[i]%('~'-' '+1)
+	}
+	return string(b)
+}
@@ -0,0 +1,85 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 数字(0から9)の並びを数値として比較するキー
+// "file2"は"file10"より小さく、数字の並びは数字以外の文字とは'/'と':'の間にあるものとして比較する
+// 数値の桁数に制限は無い
+// 数値として等しいが先頭の0の数が異なるなどで文字列が異なる場合はバイト列として比較する("file01"は"file1"より小さい)
+type NaturalKey string
+
+func (key NaturalKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	if bound, ok := other.(*prefixBound); ok {
+		return -bound.CompareTo(key)
+	}
+	s1 := string(key)
+	s2 := string(other.(NaturalKey))
+	if c := compareNatural(s1, s2, false); c != 0 {
+		return avltree.KeyOrdering(c)
+	}
+	return avltree.KeyOrdering(strings.Compare(s1, s2))
+}
+
+func (key NaturalKey) Copy() avltree.Key {
+	return key
+}
+
+// prefixで始まる全てのNaturalKeyを範囲とする下限と上限を返す
+// prefixの末尾の数字の並びはキーの数字の並び全体と数値として一致する必要がある
+// 例えばNaturalPrefix("file1")の範囲に"file1.txt"や"file01"は含まれるが"file10"は含まれない
+// ("file10"は"file2"より大きいため、"file1"で始まる全てのキーを一つの範囲にすることはできない)
+func NaturalPrefix(prefix string) (lower, upper avltree.Key) {
+	return newPrefixBounds(prefix, func(s, prefix string) int {
+		return compareNatural(s, prefix, true)
+	})
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// 先頭から続く数字の数を返す
+func countDigits(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+// 数字の並びを数値として比較する
+// prefixがtrueの場合はs2の全てが等しければs1の残りに関わらず0を返す
+func compareNatural(s1, s2 string, prefix bool) int {
+	for len(s1) > 0 && len(s2) > 0 {
+		if isDigit(s1[0]) && isDigit(s2[0]) {
+			d1, d2 := countDigits(s1), countDigits(s2)
+			n1 := strings.TrimLeft(s1[:d1], "0")
+			n2 := strings.TrimLeft(s2[:d2], "0")
+			if len(n1) < len(n2) {
+				return -1
+			} else if len(n1) > len(n2) {
+				return 1
+			}
+			if c := strings.Compare(n1, n2); c != 0 {
+				return c
+			}
+			s1, s2 = s1[d1:], s2[d2:]
+			continue
+		}
+		r1, w1 := utf8.DecodeRuneInString(s1)
+		r2, w2 := utf8.DecodeRuneInString(s2)
+		if r1 != r2 {
+			return compareRune(r1, r2)
+		}
+		s1, s2 = s1[w1:], s2[w2:]
+	}
+	return compareRemaining(s1, s2, prefix)
+}
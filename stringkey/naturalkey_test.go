@@ -0,0 +1,87 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// 数字や区切りの多い短い文字列にする
+func toNatural(b []byte) string {
+	const letters = "ab0123/:"
+	s := make([]byte, len(b)%12)
+	for i := range s {
+		s[i] = letters[b[i]%uint8(len(letters))]
+	}
+	return string(s)
+}
+
+// 数字の並びを先頭の0を除いて固定の桁数にそろえた文字列にする
+func padDigits(s string) string {
+	var sb strings.Builder
+	for len(s) > 0 {
+		if n := countDigits(s); n > 0 {
+			digits := strings.TrimLeft(s[:n], "0")
+			sb.WriteString(strings.Repeat("0", 20-len(digits)))
+			sb.WriteString(digits)
+			s = s[n:]
+		} else {
+			sb.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	return sb.String()
+}
+
+func TestNaturalKey(t *testing.T) {
+	f := func(b1, b2 []byte) bool {
+		s1, s2 := toNatural(b1), toNatural(b2)
+		var key1 avltree.Key = NaturalKey(s1)
+		var key2 avltree.Key = NaturalKey(s2)
+		cmp := key1.CompareTo(key2)
+		if c := strings.Compare(padDigits(s1), padDigits(s2)); c != 0 {
+			return cmp == avltree.KeyOrdering(c)
+		}
+		return cmp == avltree.KeyOrdering(strings.Compare(s1, s2))
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNaturalKeySort(t *testing.T) {
+	list := []string{"file10", "file2", "file01", "file1", "file1a", "x100000000000000000000000", "x99", "file", "file/", "file:"}
+	sort.Slice(list, func(i, j int) bool {
+		return NaturalKey(list[i]).CompareTo(NaturalKey(list[j])).LessThan()
+	})
+	want := []string{"file", "file/", "file01", "file1", "file1a", "file2", "file10", "file:", "x99", "x100000000000000000000000"}
+	if fmt.Sprint(list) != fmt.Sprint(want) {
+		t.Fatal("wrong order", list)
+	}
+}
+
+func ExampleNaturalKey() {
+	tree := simpletree.New(false)
+	for _, name := range []string{"img12.png", "img2.png", "img1.png", "img10.png", "IMG3.png"} {
+		avltree.Insert(tree, false, NaturalKey(name), len(name))
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Iterate! IMG3.png 8
+	// Iterate! img1.png 8
+	// Iterate! img2.png 8
+	// Iterate! img10.png 9
+	// Iterate! img12.png 9
+}
@@ -0,0 +1,96 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"unicode/utf8"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// 前方一致の範囲の下限または上限
+// prefixで始まる全てのキーより小さい(大きい)ものとして比較される
+// 範囲の指定にのみ使い、木のキーにしたり下限と上限どうしを比較したりしてはならない
+type prefixBound struct {
+	prefix string
+
+	// sがprefixで始まる場合は0、そうでない場合はsとprefixの順序を返す
+	comparePrefix func(s, prefix string) int
+
+	// prefixで始まるキーと比較したときの結果
+	ordering avltree.KeyOrdering
+}
+
+func newPrefixBounds(prefix string, comparePrefix func(s, prefix string) int) (lower, upper avltree.Key) {
+	lower = &prefixBound{prefix, comparePrefix, avltree.LessThanOtherKey}
+	upper = &prefixBound{prefix, comparePrefix, avltree.GreaterThanOtherKey}
+	return
+}
+
+func (bound *prefixBound) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	var s string
+	switch key := other.(type) {
+	case StringKey:
+		s = string(key)
+	case FoldedKey:
+		s = string(key)
+	case NaturalKey:
+		s = string(key)
+	case RuneKey:
+		s = string(key)
+	default:
+		panic("stringkey: prefix bound can only be compared with string keys")
+	}
+	switch c := bound.comparePrefix(s, bound.prefix); {
+	case c < 0:
+		return avltree.GreaterThanOtherKey
+	case c > 0:
+		return avltree.LessThanOtherKey
+	default:
+		return bound.ordering
+	}
+}
+
+func (bound *prefixBound) Copy() avltree.Key {
+	return bound
+}
+
+// s1とs2を先頭から1文字ずつmappingで変換した文字の順序で比較する
+// 不正なUTF-8のバイトはutf8.RuneErrorとして扱う
+// prefixがtrueの場合はs2の全ての文字が等しければs1の残りの文字に関わらず0を返す
+func compareRunes(s1, s2 string, mapping func(r rune) rune, prefix bool) int {
+	for len(s1) > 0 && len(s2) > 0 {
+		r1, n1 := utf8.DecodeRuneInString(s1)
+		r2, n2 := utf8.DecodeRuneInString(s2)
+		if r1, r2 = mapping(r1), mapping(r2); r1 != r2 {
+			return compareRune(r1, r2)
+		}
+		s1, s2 = s1[n1:], s2[n2:]
+	}
+	return compareRemaining(s1, s2, prefix)
+}
+
+// 比較し終えた残りの長さで順序を決める
+// 残りが無いほうが小さく、prefixがtrueの場合はs2の残りが無ければ0を返す
+func compareRemaining(s1, s2 string, prefix bool) int {
+	switch {
+	case len(s2) == 0 && (prefix || len(s1) == 0):
+		return 0
+	case len(s1) == 0:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareRune(r1, r2 rune) int {
+	switch {
+	case r1 < r2:
+		return -1
+	case r1 > r2:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,129 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// 前方一致の範囲で得たキーと全てのキーから条件で選んだキーが同じになるか確認する
+func checkPrefix(list []string, toKey func(s string) avltree.Key, lower, upper avltree.Key, match func(s string) bool) bool {
+	tree := simpletree.New(false)
+	for _, s := range list {
+		avltree.Insert(tree, false, toKey(s), s)
+	}
+	var got, want []string
+	avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		got = append(got, node.Value().(string))
+		return
+	})
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		if s := node.Value().(string); match(s) {
+			want = append(want, s)
+		}
+		return
+	})
+	return reflect.DeepEqual(got, want) && avltree.CountRange(tree, lower, upper) == len(want)
+}
+
+func TestStringPrefix(t *testing.T) {
+	f := func(bs [][]byte, p []byte) bool {
+		var list []string
+		for _, b := range bs {
+			list = append(list, toNatural(b))
+		}
+		prefix := toNatural(p)
+		prefix = prefix[:len(prefix)/2]
+		lower, upper := StringPrefix(prefix)
+		return checkPrefix(list, func(s string) avltree.Key { return StringKey(s) }, lower, upper, func(s string) bool {
+			return strings.HasPrefix(s, prefix)
+		})
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFoldedPrefix(t *testing.T) {
+	f := func(list []string, p string) bool {
+		for i := range list {
+			list[i] = toASCII(list[i])
+		}
+		prefix := toASCII(p)
+		prefix = prefix[:len(prefix)/4]
+		lower, upper := FoldedPrefix(prefix)
+		return checkPrefix(list, func(s string) avltree.Key { return FoldedKey(s) }, lower, upper, func(s string) bool {
+			return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+		})
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNaturalPrefix(t *testing.T) {
+	f := func(bs [][]byte, p []byte) bool {
+		var list []string
+		for _, b := range bs {
+			list = append(list, toNatural(b))
+		}
+		prefix := toNatural(p)
+		prefix = prefix[:len(prefix)/2]
+		lower, upper := NaturalPrefix(prefix)
+		return checkPrefix(list, func(s string) avltree.Key { return NaturalKey(s) }, lower, upper, func(s string) bool {
+			return strings.HasPrefix(padDigits(s), padDigits(prefix))
+		})
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunePrefix(t *testing.T) {
+	f := func(bs [][]byte, p []byte) bool {
+		var list []string
+		for _, b := range bs {
+			list = append(list, string(b))
+		}
+		prefix := []rune(string(p))
+		prefix = prefix[:len(prefix)/2]
+		lower, upper := RunePrefix(string(prefix))
+		return checkPrefix(list, func(s string) avltree.Key { return RuneKey(s) }, lower, upper, func(s string) bool {
+			runes := []rune(s)
+			return len(runes) >= len(prefix) && reflect.DeepEqual(runes[:len(prefix)], prefix)
+		})
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ExampleFoldedPrefix() {
+	tree := simpletree.New(false)
+	for _, word := range []string{"Apple", "apricot", "Banana", "APPLAUSE", "application", "avocado"} {
+		avltree.Insert(tree, false, FoldedKey(word), len(word))
+	}
+	lower, upper := FoldedPrefix("apPl")
+	avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Complete!", node.Key(), node.Value())
+		return
+	})
+	fmt.Println("Count!", avltree.CountRange(tree, lower, upper))
+	// Output:
+	// Complete! APPLAUSE 8
+	// Complete! Apple 5
+	// Complete! application 11
+	// Count! 3
+}
@@ -0,0 +1,26 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import "github.com/neetsdkasu/avltree"
+
+// StringKeyをキーとする木でprefixで始まるキーを持つノードを昇順に巡ってコールバックを呼び出す
+// 範囲はStringPrefixで求める
+// 戻り値のokはコールバックから中断を要求されなかった場合はtrue、中断を要求された場合はfalse
+func PrefixIterate(tree avltree.Tree, prefix string, callBack avltree.IterateCallBack) (ok bool) {
+	lower, upper := StringPrefix(prefix)
+	return avltree.RangeIterate(tree, false, lower, upper, callBack)
+}
+
+// StringKeyをキーとする木でprefixで始まるキーを持つノードの総数を求める
+func PrefixCount(tree avltree.Tree, prefix string) int {
+	lower, upper := StringPrefix(prefix)
+	return avltree.CountRange(tree, lower, upper)
+}
+
+// StringKeyをキーとする木でprefixで始まるキーを持つノード全てを削除する
+func PrefixDelete(tree avltree.Tree, prefix string) (modified avltree.Tree, values []avltree.KeyAndValue) {
+	lower, upper := StringPrefix(prefix)
+	return avltree.DeleteRange(tree, false, lower, upper)
+}
@@ -0,0 +1,97 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// 0xFFのバイトの多い短い文字列にする
+func toBytes(b []byte) string {
+	const letters = "\x00\x01a\xfe\xff\xff"
+	s := make([]byte, len(b)%6)
+	for i := range s {
+		s[i] = letters[b[i]%uint8(len(letters))]
+	}
+	return string(s)
+}
+
+func TestPrefixScan(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(list [][]byte, p []byte) bool {
+			tree := simpletree.New(dup)
+			for _, b := range list {
+				avltree.Insert(tree, false, StringKey(toBytes(b)), len(b))
+			}
+			prefix := toBytes(p)
+			prefix = prefix[:len(prefix)/2]
+			var want, rest []string
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				if key := string(node.Key().(StringKey)); strings.HasPrefix(key, prefix) {
+					want = append(want, key)
+				} else {
+					rest = append(rest, key)
+				}
+				return
+			})
+			var got []string
+			ok := PrefixIterate(tree, prefix, func(node avltree.Node) (breakIteration bool) {
+				got = append(got, string(node.Key().(StringKey)))
+				return
+			})
+			if !ok || !reflect.DeepEqual(got, want) || PrefixCount(tree, prefix) != len(want) {
+				return false
+			}
+			if len(want) > 0 {
+				// 中断を要求した場合はfalseを返す
+				got = nil
+				ok = PrefixIterate(tree, prefix, func(node avltree.Node) (breakIteration bool) {
+					got = append(got, string(node.Key().(StringKey)))
+					return true
+				})
+				if ok || !reflect.DeepEqual(got, want[:1]) {
+					return false
+				}
+			}
+			_, values := PrefixDelete(tree, prefix)
+			var deleted, remaining []string
+			for _, kv := range values {
+				deleted = append(deleted, string(kv.Key().(StringKey)))
+			}
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				remaining = append(remaining, string(node.Key().(StringKey)))
+				return
+			})
+			return reflect.DeepEqual(deleted, want) && reflect.DeepEqual(remaining, rest) && PrefixCount(tree, prefix) == 0
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
+
+func ExamplePrefixIterate() {
+	tree := simpletree.New(false)
+	for _, key := range []string{"user:42:name", "user:7:name", "user:42:mail", "user:420:name", "user:42:age"} {
+		avltree.Insert(tree, false, StringKey(key), len(key))
+	}
+	PrefixIterate(tree, "user:42:", func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	tree, deleted := PrefixDelete(tree, "user:42")
+	fmt.Println("Delete!", len(deleted), PrefixCount(tree, "user:"))
+	// Output:
+	// Iterate! user:42:age 11
+	// Iterate! user:42:mail 12
+	// Iterate! user:42:name 12
+	// Delete! 4 1
+}
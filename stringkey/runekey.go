@@ -0,0 +1,43 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"strings"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+// バイト列ではなく文字(rune)の並びとして比較するキー
+// 正しいUTF-8の文字列どうしではStringKeyと同じ順序になる(UTF-8のバイト順は文字コード順と一致する)
+// 不正なUTF-8のバイトはutf8.RuneError(U+FFFD)の位置にあるものとして比較し、
+// 文字の並びとして等しい場合のみバイト列として比較する
+type RuneKey string
+
+func identity(r rune) rune {
+	return r
+}
+
+func (key RuneKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	if bound, ok := other.(*prefixBound); ok {
+		return -bound.CompareTo(key)
+	}
+	s1 := string(key)
+	s2 := string(other.(RuneKey))
+	if c := compareRunes(s1, s2, identity, false); c != 0 {
+		return avltree.KeyOrdering(c)
+	}
+	return avltree.KeyOrdering(strings.Compare(s1, s2))
+}
+
+func (key RuneKey) Copy() avltree.Key {
+	return key
+}
+
+// 文字の並びがprefixで始まる全てのRuneKeyを範囲とする下限と上限を返す
+func RunePrefix(prefix string) (lower, upper avltree.Key) {
+	return newPrefixBounds(prefix, func(s, prefix string) int {
+		return compareRunes(s, prefix, identity, true)
+	})
+}
@@ -0,0 +1,48 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package stringkey
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+func compareRuneSlices(r1, r2 []rune) int {
+	for i := 0; i < len(r1) && i < len(r2); i++ {
+		if c := compareRune(r1[i], r2[i]); c != 0 {
+			return c
+		}
+	}
+	return compareRune(rune(len(r1)), rune(len(r2)))
+}
+
+func TestRuneKey(t *testing.T) {
+	f := func(b1, b2 []byte, k1, k2 string) bool {
+		// 正しいUTF-8の文字列ではStringKeyと同じ順序
+		if RuneKey(k1).CompareTo(RuneKey(k2)) != StringKey(k1).CompareTo(StringKey(k2)) {
+			return false
+		}
+		s1, s2 := string(b1), string(b2)
+		var key1 avltree.Key = RuneKey(s1)
+		var key2 avltree.Key = RuneKey(s2)
+		cmp := key1.CompareTo(key2)
+		if cmp.EqualTo() != (s1 == s2) || cmp != -key2.CompareTo(key1) {
+			return false
+		}
+		if c := compareRuneSlices([]rune(s1), []rune(s2)); c != 0 {
+			return cmp == avltree.KeyOrdering(c)
+		}
+		return cmp == StringKey(s1).CompareTo(StringKey(s2))
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+	// 不正なバイトはU+FFFDとして比較される
+	if !RuneKey("\xff").CompareTo(RuneKey("\U0001F600")).LessThan() || !StringKey("\xff").CompareTo(StringKey("\U0001F600")).GreaterThan() {
+		t.Fatal("invalid byte is not compared as U+FFFD")
+	}
+}
@@ -4,6 +4,16 @@
 // github.com/neetsdkasu/avltreeのKeyの実装例
 // string型をそのままキーにしてある
 // 標準パッケージのstrings.Compareの結果をそのままキーの比較の値として使っている
+//
+// 比較方法の異なる以下のキーも用意してある
+//  FoldedKey   大文字と小文字を同一視する(Unicodeの単純な大文字小文字の畳み込み)
+//  NaturalKey  数字の並びを数値として比較する("file2"は"file10"より小さい)
+//  RuneKey     バイト列ではなく文字(rune)の並びとして比較する
+//
+// StringPrefix,FoldedPrefix,NaturalPrefix,RunePrefixは指定の文字列で始まる全てのキーを範囲とする下限と上限を返す
+// RangeIterateやCountRangeなどに渡すことで前方一致の検索(入力補完など)ができる
+//
+// PrefixIterate,PrefixCount,PrefixDeleteはStringKeyをキーとする木でStringPrefixの範囲のノードを巡ったり数えたり削除したりする
 package stringkey
 
 import (
@@ -15,6 +25,9 @@ import (
 type StringKey string
 
 func (key StringKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	if bound, ok := other.(*prefixBound); ok {
+		return -bound.CompareTo(key)
+	}
 	s1 := string(key)
 	s2 := string(other.(StringKey))
 	return avltree.KeyOrdering(strings.Compare(s1, s2))
@@ -23,3 +36,16 @@ func (key StringKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
 func (key StringKey) Copy() avltree.Key {
 	return key
 }
+
+// prefixで始まる全てのStringKeyを範囲とする下限と上限を返す
+func StringPrefix(prefix string) (lower, upper avltree.Key) {
+	return newPrefixBounds(prefix, func(s, prefix string) int {
+		if len(s) < len(prefix) {
+			if c := strings.Compare(s, prefix[:len(s)]); c != 0 {
+				return c
+			}
+			return -1
+		}
+		return strings.Compare(s[:len(prefix)], prefix)
+	})
+}
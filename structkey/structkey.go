@@ -0,0 +1,325 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// 構造体のフィールドのタグから組(tuplekey.TupleKey)のキーを作る
+// タグには組の中での順番(1以上の数)と、降順で比較する場合は"desc"を書く
+//
+//	type Person struct {
+//		Name string `avl:"2"`
+//		Age  int    `avl:"1,desc"`
+//		Note string // タグの無いフィールドはキーに含まれない
+//	}
+//
+// この場合はAgeの降順、Nameの昇順の組がキーになる
+// タグの名前("avl"など)は利用者が指定するため、1つの構造体に異なるタグ名で複数の並び順を書いておける
+// SchemaOfやNewやFromStructsの引数tagにはフィールドの名前ではなくこのタグの名前を渡す(上の例ではFromStructs(tree, records, "avl")のように渡す)
+//
+// フィールドの値は以下のKeyに変換して組の要素にする
+//
+//	avltree.Keyを実装した型 ... そのまま
+//	符号付き整数型         ... int64key.Int64Key
+//	符号無し整数型         ... uint64key.Uint64Key
+//	浮動小数点数型         ... float64key.Float64Key
+//	string型              ... stringkey.StringKey
+//	bool型                ... intkey.IntKey(falseを0、trueを1とする)
+//	[]byte型              ... byteskey.BytesKey
+//	time.Time型           ... timekey.TimeKey
+//
+// キーに含めるフィールドは構造体の直下の公開されたフィールドでなければならない
+//
+// 構造体の型とタグ名ごとにフィールドの位置や変換の方法をSchemaとしてまとめ、作ったSchemaは使い回す
+// FromStructsは構造体のスライスの各要素をその要素から作ったキーで木に挿入する
+// Schema.PrefixとSchema.Rangeは先頭のいくつかのフィールドの値だけでRangeIterateなどの範囲の下限と上限を作る
+package structkey
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/byteskey"
+	"github.com/neetsdkasu/avltree/float64key"
+	"github.com/neetsdkasu/avltree/int64key"
+	"github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/stringkey"
+	"github.com/neetsdkasu/avltree/timekey"
+	"github.com/neetsdkasu/avltree/tuplekey"
+	"github.com/neetsdkasu/avltree/uint64key"
+)
+
+// 構造体の型とタグ名から求めたキーの作り方
+type Schema struct {
+	typ    reflect.Type
+	tag    string
+	fields []field
+}
+
+// キーに含めるフィールド
+type field struct {
+	name  string
+	index int
+	order int
+	desc  bool
+	toKey func(v reflect.Value) avltree.Key
+}
+
+type schemaID struct {
+	typ reflect.Type
+	tag string
+}
+
+// 作ったSchema(schemaIDから*Schemaへ)
+var schemas sync.Map
+
+var (
+	keyType  = reflect.TypeOf((*avltree.Key)(nil)).Elem()
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// recordの構造体の型(recordは構造体か構造体へのポインタ)とタグ名tagのSchemaを返す
+// 構造体でない場合、タグのあるフィールドが無い場合、タグが正しくない場合はpanicする
+func SchemaOf(record interface{}, tag string) *Schema {
+	typ := reflect.TypeOf(record)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("structkey: not struct %T", record))
+	}
+	id := schemaID{typ, tag}
+	if schema, ok := schemas.Load(id); ok {
+		return schema.(*Schema)
+	}
+	schema, _ := schemas.LoadOrStore(id, newSchema(typ, tag))
+	return schema.(*Schema)
+}
+
+func newSchema(typ reflect.Type, tag string) *Schema {
+	schema := &Schema{typ: typ, tag: tag}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		value, ok := sf.Tag.Lookup(tag)
+		if !ok || value == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			panic(fmt.Sprintf("structkey: unexported field %s.%s", typ, sf.Name))
+		}
+		f := field{name: sf.Name, index: i, toKey: converter(sf.Type)}
+		if f.toKey == nil {
+			panic(fmt.Sprintf("structkey: unsupported type %s of field %s.%s", sf.Type, typ, sf.Name))
+		}
+		options := strings.Split(value, ",")
+		order, err := strconv.Atoi(options[0])
+		if err != nil || order < 1 {
+			panic(fmt.Sprintf("structkey: invalid order %q of field %s.%s", options[0], typ, sf.Name))
+		}
+		f.order = order
+		for _, option := range options[1:] {
+			switch option {
+			case "asc":
+				f.desc = false
+			case "desc":
+				f.desc = true
+			default:
+				panic(fmt.Sprintf("structkey: unknown option %q of field %s.%s", option, typ, sf.Name))
+			}
+		}
+		schema.fields = append(schema.fields, f)
+	}
+	if len(schema.fields) == 0 {
+		panic(fmt.Sprintf("structkey: no field tagged %q in %s", tag, typ))
+	}
+	sort.SliceStable(schema.fields, func(i, j int) bool {
+		return schema.fields[i].order < schema.fields[j].order
+	})
+	for i := 1; i < len(schema.fields); i++ {
+		if schema.fields[i-1].order == schema.fields[i].order {
+			panic(fmt.Sprintf("structkey: duplicate order %d in %s", schema.fields[i].order, typ))
+		}
+	}
+	return schema
+}
+
+// フィールドの値をKeyに変換する関数を返す
+// 変換できない型の場合はnilを返す
+func converter(typ reflect.Type) func(v reflect.Value) avltree.Key {
+	switch {
+	case typ.Implements(keyType):
+		return func(v reflect.Value) avltree.Key {
+			return v.Interface().(avltree.Key).Copy()
+		}
+	case typ == timeType:
+		return func(v reflect.Value) avltree.Key {
+			return timekey.New(v.Interface().(time.Time))
+		}
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) avltree.Key {
+			return int64key.Int64Key(v.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v reflect.Value) avltree.Key {
+			return uint64key.Uint64Key(v.Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) avltree.Key {
+			return float64key.Float64Key(v.Float())
+		}
+	case reflect.String:
+		return func(v reflect.Value) avltree.Key {
+			return stringkey.StringKey(v.String())
+		}
+	case reflect.Bool:
+		return func(v reflect.Value) avltree.Key {
+			if v.Bool() {
+				return intkey.IntKey(1)
+			}
+			return intkey.IntKey(0)
+		}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return func(v reflect.Value) avltree.Key {
+				return byteskey.BytesKey(append([]byte(nil), v.Bytes()...))
+			}
+		}
+	}
+	return nil
+}
+
+// キーに含めるフィールドの名前を組の中での順に返す
+func (schema *Schema) Fields() []string {
+	names := make([]string, len(schema.fields))
+	for i, f := range schema.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+func (f *field) key(v reflect.Value) avltree.Key {
+	key := f.toKey(v)
+	if f.desc {
+		return tuplekey.Desc(key)
+	}
+	return key
+}
+
+// recordのキーを返す
+// recordがnilやnilポインタの場合、Schemaの構造体でもそのポインタでもない場合はpanicする
+func (schema *Schema) Key(record interface{}) tuplekey.TupleKey {
+	v := reflect.ValueOf(record)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		panic(fmt.Sprintf("structkey: nil record %T for %s", record, schema.typ))
+	}
+	if v.Kind() == reflect.Ptr && v.Type().Elem() == schema.typ {
+		v = v.Elem()
+	}
+	if v.Type() != schema.typ {
+		panic(fmt.Sprintf("structkey: %T is not %s", record, schema.typ))
+	}
+	key := make(tuplekey.TupleKey, len(schema.fields))
+	for i := range schema.fields {
+		f := &schema.fields[i]
+		key[i] = f.key(v.Field(f.index))
+	}
+	return key
+}
+
+// 先頭のフィールドから順に与えられた値をフィールドの型の値としてKeyに変換する
+// フィールドより多くの値を与えた場合や、値をフィールドの型に変換できない場合はpanicする
+func (schema *Schema) values(values []interface{}) tuplekey.TupleKey {
+	if len(values) > len(schema.fields) {
+		panic(fmt.Sprintf("structkey: too many values %d for %s", len(values), schema.typ))
+	}
+	key := make(tuplekey.TupleKey, len(values))
+	for i, value := range values {
+		f := &schema.fields[i]
+		typ := schema.typ.Field(f.index).Type
+		v := reflect.ValueOf(value)
+		switch {
+		case !v.IsValid():
+			panic(fmt.Sprintf("structkey: nil value for field %s.%s", schema.typ, f.name))
+		case v.Type() == typ:
+		case sameKind(v.Type(), typ) && v.Type().ConvertibleTo(typ):
+			v = v.Convert(typ)
+		default:
+			panic(fmt.Sprintf("structkey: %T value for field %s.%s of %s", value, schema.typ, f.name, typ))
+		}
+		key[i] = f.key(v)
+	}
+	return key
+}
+
+// 型変換してよい組み合わせか(数値と文字列の間の変換などは許さない)
+func sameKind(from, to reflect.Type) bool {
+	kindClass := func(kind reflect.Kind) reflect.Kind {
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return reflect.Int
+		case reflect.Float32, reflect.Float64:
+			return reflect.Float64
+		default:
+			return kind
+		}
+	}
+	return kindClass(from.Kind()) == kindClass(to.Kind())
+}
+
+// 先頭のフィールドから順にvaluesの値と等しい全てのキーを範囲とする下限と上限を返す
+// valuesが空の場合は全てのキーが範囲になる
+// 値はフィールドの型の値か、フィールドの型に変換できる同じ種類(整数、浮動小数点数など)の型の値である必要がある
+func (schema *Schema) Prefix(values ...interface{}) (lower, upper tuplekey.TupleKey) {
+	return schema.Range(values, values)
+}
+
+// 先頭のフィールドから順に比較してlowerValues以上upperValues以下となる全てのキーを範囲とする下限と上限を返す
+// lowerValuesとupperValuesは先頭のいくつかのフィールドの値で、要素数は異なってもよい
+// 降順のフィールドではキーの順序での下限(大きいほうの値)をlowerValuesに与える
+func (schema *Schema) Range(lowerValues, upperValues []interface{}) (lower, upper tuplekey.TupleKey) {
+	lower = schema.values(lowerValues)
+	upper = schema.values(upperValues)
+	// 全てのフィールドの値がある場合はキーと同じ組になる
+	if len(lower) < len(schema.fields) {
+		lower = append(lower, tuplekey.Min)
+	}
+	if len(upper) < len(schema.fields) {
+		upper = append(upper, tuplekey.Max)
+	}
+	return
+}
+
+// recordのキーを返す(SchemaOf(record, tag).Key(record)と同じ)
+func New(record interface{}, tag string) tuplekey.TupleKey {
+	return SchemaOf(record, tag).Key(record)
+}
+
+// recordsの各要素をその要素のキーと要素の値で木に挿入する
+// recordsは構造体のスライスか構造体へのポインタのスライスで、キーはタグ名tagのタグから作る
+// tagはフィールドの名前ではなく構造体のタグの名前(`avl:"1"`のタグならば"avl")
+// recordsがnilポインタの要素を含む場合はpanicする
+// 同一キーを許可しない木で既に同じキーのノードがある場合はその要素は挿入しない
+// 戻り値のcountは挿入した要素の数
+func FromStructs(tree avltree.Tree, records interface{}, tag string) (modified avltree.Tree, count int) {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("structkey: not slice %T", records))
+	}
+	schema := SchemaOf(reflect.Zero(v.Type().Elem()).Interface(), tag)
+	modified = tree
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i).Interface()
+		var ok bool
+		if modified, ok = avltree.Insert(modified, false, schema.Key(record), record); ok {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,311 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package structkey
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	"github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+// byAgeは(Ageの降順, Nameの昇順)、byNameは(Nameの昇順, Activeの昇順)
+type record struct {
+	Name   string `avl:"2" byName:"1"`
+	Age    int8   `avl:"1,desc"`
+	Active bool   `byName:"2,asc"`
+	Memo   string
+}
+
+func (r record) less(other record) bool {
+	if r.Age != other.Age {
+		return r.Age > other.Age
+	}
+	return r.Name < other.Name
+}
+
+// 同じ値が出やすい名前
+func (r record) normalize() record {
+	r.Name = string(rune('a' + len(r.Name)%4))
+	r.Age %= 8
+	return r
+}
+
+func TestKey(t *testing.T) {
+	f := func(r1, r2 record) bool {
+		r1, r2 = r1.normalize(), r2.normalize()
+		key1, key2 := New(r1, "avl"), New(&r2, "avl")
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return r1.less(r2)
+		case avltree.EqualToOtherKey:
+			return r1.Age == r2.Age && r1.Name == r2.Name
+		case avltree.GreaterThanOtherKey:
+			return r2.less(r1)
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	if SchemaOf(record{}, "avl") != SchemaOf(&record{}, "avl") {
+		t.Fatal("schema is not cached")
+	}
+	if fields := SchemaOf(record{}, "avl").Fields(); !reflect.DeepEqual(fields, []string{"Age", "Name"}) {
+		t.Fatal("wrong fields", fields)
+	}
+	if fields := SchemaOf(record{}, "byName").Fields(); !reflect.DeepEqual(fields, []string{"Name", "Active"}) {
+		t.Fatal("wrong fields", fields)
+	}
+
+	type allTypes struct {
+		I  int16         `avl:"1"`
+		U  uint          `avl:"2"`
+		F  float32       `avl:"3,desc"`
+		S  string        `avl:"4"`
+		B  bool          `avl:"5"`
+		BS []byte        `avl:"6"`
+		T  time.Time     `avl:"7"`
+		K  intkey.IntKey `avl:"8"`
+		X  []int         `avl:"-"`
+	}
+	r1 := allTypes{1, 2, 3, "x", true, []byte("y"), time.Unix(100, 0), 5, nil}
+	r2 := r1
+	r2.T = time.Unix(100, 0).In(time.UTC)
+	if !New(r1, "avl").CompareTo(New(r2, "avl")).EqualTo() {
+		t.Fatal("not equal")
+	}
+	r2.F = 4
+	if !New(r1, "avl").CompareTo(New(r2, "avl")).GreaterThan() {
+		t.Fatal("not desc")
+	}
+
+	type unexported struct {
+		a int `avl:"1"`
+	}
+	type unsupported struct {
+		A []int `avl:"1"`
+	}
+	type invalidOrder struct {
+		A int `avl:"0"`
+	}
+	type unknownOption struct {
+		A int `avl:"1,up"`
+	}
+	type duplicateOrder struct {
+		A int `avl:"1"`
+		B int `avl:"1"`
+	}
+	for _, r := range []interface{}{unexported{}, unsupported{}, invalidOrder{}, unknownOption{}, duplicateOrder{}, record{}, 1, nil} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("no panic %T", r)
+				}
+			}()
+			SchemaOf(r, "byAge")
+		}()
+	}
+}
+
+func TestKeyNil(t *testing.T) {
+	schema := SchemaOf(record{}, "avl")
+	for _, r := range []interface{}{nil, (*record)(nil), (*int)(nil), 1} {
+		func() {
+			defer func() {
+				if msg, ok := recover().(string); !ok || !strings.HasPrefix(msg, "structkey: ") {
+					t.Fatalf("wrong panic %v for %#v", msg, r)
+				}
+			}()
+			schema.Key(r)
+		}()
+	}
+	func() {
+		defer func() {
+			if msg, ok := recover().(string); !ok || !strings.HasPrefix(msg, "structkey: ") {
+				t.Fatalf("wrong panic %v", msg)
+			}
+		}()
+		FromStructs(simpletree.New(false), []*record{{Name: "a"}, nil}, "avl")
+	}()
+}
+
+func TestRange(t *testing.T) {
+	for _, dup := range []bool{false, true} {
+		f := func(records []record, lowerAge, upperAge int8, name string, useName bool) bool {
+			for i := range records {
+				records[i] = records[i].normalize()
+			}
+			tree, count := FromStructs(simpletree.New(dup), records, "avl")
+			if count != avltree.Count(tree) {
+				return false
+			}
+			lowerAge, upperAge = lowerAge%8, upperAge%8
+			name = record{Name: name}.normalize().Name
+			lowerValues := []interface{}{upperAge}
+			upperValues := []interface{}{lowerAge}
+			if useName {
+				lowerValues = append(lowerValues, name)
+			}
+			lower, upper := SchemaOf(record{}, "avl").Range(lowerValues, upperValues)
+			var got []record
+			avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+				got = append(got, node.Value().(record))
+				return
+			})
+			var want []record
+			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+				r := node.Value().(record)
+				if lowerAge <= r.Age && (r.Age < upperAge || (r.Age == upperAge && (!useName || name <= r.Name))) {
+					want = append(want, r)
+				}
+				return
+			})
+			return reflect.DeepEqual(got, want)
+		}
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(dup, err)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	records := []*record{
+		{Name: "carol", Active: true},
+		{Name: "alice", Active: false},
+		{Name: "bob", Active: true},
+		{Name: "alice", Active: true},
+		{Name: "alice", Active: true, Memo: "dup"},
+	}
+	tree, count := FromStructs(immutabletree.New(false), records, "byName")
+	if count != 4 || avltree.Count(tree) != 4 {
+		t.Fatal("wrong count", count)
+	}
+	schema := SchemaOf(record{}, "byName")
+	for _, tc := range []struct {
+		values []interface{}
+		want   int
+	}{
+		{nil, 4},
+		{[]interface{}{"alice"}, 2},
+		{[]interface{}{"alice", true}, 1},
+		{[]interface{}{"dave"}, 0},
+	} {
+		lower, upper := schema.Prefix(tc.values...)
+		if got := avltree.CountRange(tree, lower, upper); got != tc.want {
+			t.Fatal("wrong count", tc.values, got)
+		}
+	}
+	// 値はフィールドの型に変換される
+	lower, upper := SchemaOf(record{}, "avl").Prefix(uint(3))
+	if !New(record{Age: 3}, "avl").CompareTo(lower).GreaterThan() || !New(record{Age: 3}, "avl").CompareTo(upper).LessThan() {
+		t.Fatal("wrong prefix")
+	}
+	for _, values := range [][]interface{}{{1}, {"alice", 1}, {"alice", true, 1}, {nil}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("no panic", values)
+				}
+			}()
+			schema.Prefix(values...)
+		}()
+	}
+}
+
+func TestFromStructsOrder(t *testing.T) {
+	f := func(records []record) bool {
+		for i := range records {
+			records[i] = records[i].normalize()
+		}
+		tree, _ := FromStructs(simpletree.New(true), records, "avl")
+		var got []record
+		avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+			got = append(got, node.Value().(record))
+			return
+		})
+		want := append([]record(nil), records...)
+		sort.SliceStable(want, func(i, j int) bool {
+			return want[i].less(want[j])
+		})
+		return len(got) == len(want) && sameKeys(got, want)
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 同一キーのノードの順序は問わない
+func sameKeys(got, want []record) bool {
+	for i := range got {
+		if got[i].less(want[i]) || want[i].less(got[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func Example() {
+	type Person struct {
+		Name string `avl:"2"`
+		Age  int    `avl:"1,desc"`
+		City string `city:"1"`
+	}
+	people := []Person{
+		{"alice", 30, "Tokyo"},
+		{"bob", 25, "Osaka"},
+		{"carol", 30, "Osaka"},
+		{"dave", 41, "Tokyo"},
+		{"eve", 25, "Nagoya"},
+	}
+	// 3番目の引数はフィールドの名前ではなくタグの名前
+	tree, _ := FromStructs(simpletree.New(false), people, "avl")
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	schema := SchemaOf(Person{}, "avl")
+	lower, upper := schema.Prefix(30)
+	avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Age30!", node.Value())
+		return
+	})
+	// 降順のフィールドでは大きいほうの値が下限になる
+	lower, upper = schema.Range([]interface{}{35}, []interface{}{25, "bob"})
+	avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Range!", node.Value())
+		return
+	})
+	byCity, _ := FromStructs(simpletree.New(true), people, "city")
+	lower, upper = SchemaOf(Person{}, "city").Prefix("Osaka")
+	fmt.Println("Osaka!", avltree.CountRange(byCity, lower, upper))
+	// Output:
+	// Iterate! [41 dave] {dave 41 Tokyo}
+	// Iterate! [30 alice] {alice 30 Tokyo}
+	// Iterate! [30 carol] {carol 30 Osaka}
+	// Iterate! [25 bob] {bob 25 Osaka}
+	// Iterate! [25 eve] {eve 25 Nagoya}
+	// Age30! {alice 30 Tokyo}
+	// Age30! {carol 30 Osaka}
+	// Range! {alice 30 Tokyo}
+	// Range! {carol 30 Osaka}
+	// Range! {bob 25 Osaka}
+	// Osaka! 2
+}
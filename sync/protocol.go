@@ -0,0 +1,251 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package sync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	"github.com/neetsdkasu/avltree/merkletree"
+)
+
+// 通信の形式(数値はuvarint、バイト列は長さ(uvarint)に続けて内容)
+//
+// 最初にInitiate側がprotocolMagicとprotocolVersion(1バイト)を送る
+//
+// 以降、Initiate側とRespond側が交互にメッセージを送る
+//
+// Initiate側のメッセージ
+//
+//	+ Respond側の木に反映する組の数
+//	+ 組の並び
+//	    + キー (バイト列)
+//	    + 値 (バイト列)
+//	+ 範囲の数 (0の場合は最後のメッセージ)
+//	+ 範囲の並び
+//	    + 下限 (1バイトの有無のあとにバイト列、無い場合は最小のキーから)
+//	    + 上限 (1バイトの有無のあとにバイト列、無い場合は最大のキーまで、上限のキーは範囲に含まない)
+//	    + 範囲内の組の数
+//	    + 範囲内の組のハッシュ値 (384バイト)
+//
+// Respond側のメッセージ(範囲の数が0の場合はreplyDoneの1バイトのみ)
+//
+//	+ 範囲ごとの返答の並び
+//	    + replyMatch (1バイト)
+//	    または
+//	    + replySplit (1バイト)
+//	    + 範囲を分割するキー (バイト列)
+//	    または
+//	    + replyEntries (1バイト)
+//	    + 組の数
+//	    + 組の並び
+//	        + キー (バイト列)
+//	        + 値 (バイト列)
+
+const protocolMagic = "AVLTSYNC"
+
+const protocolVersion byte = 1
+
+const (
+	replyDone    byte = 0
+	replyMatch   byte = 1
+	replySplit   byte = 2
+	replyEntries byte = 3
+)
+
+// 受け取るバイト列の長さの上限
+const maxBytesLength = 1 << 26
+
+// 相手から受け取ったデータが通信の形式に従っていない
+var ErrProtocol = errors.New("sync: protocol error")
+
+func protocolError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrProtocol}, args...)...)
+}
+
+// 書き込みのエラーは最初のものを保持し、以降の書き込みは行わない
+type writer struct {
+	w     *bufio.Writer
+	codec codec.Codec
+	err   error
+}
+
+func (w *writer) write(data []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(data)
+	}
+}
+
+func (w *writer) writeByte(b byte) {
+	w.write([]byte{b})
+}
+
+func (w *writer) writeUvarint(x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	w.write(tmp[:n])
+}
+
+func (w *writer) writeBytes(data []byte) {
+	w.writeUvarint(uint64(len(data)))
+	w.write(data)
+}
+
+func (w *writer) writeKey(key avltree.Key) {
+	if w.err == nil {
+		var data []byte
+		data, w.err = w.codec.EncodeKey(key)
+		w.writeBytes(data)
+	}
+}
+
+func (w *writer) writeBound(key avltree.Key) {
+	if key == nil {
+		w.writeByte(0)
+	} else {
+		w.writeByte(1)
+		w.writeKey(key)
+	}
+}
+
+func (w *writer) writeEntries(list []entry) {
+	w.writeUvarint(uint64(len(list)))
+	for _, e := range list {
+		w.writeKey(e.key)
+		w.writeBytes(e.data)
+	}
+}
+
+func (w *writer) writeHash(hash merkletree.Hash) {
+	w.write(hash[:])
+}
+
+func (w *writer) flush() error {
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.err
+}
+
+// 読み込みのエラーは最初のものを保持し、以降の読み込みはゼロ値を返す
+type reader struct {
+	r     *bufio.Reader
+	codec codec.Codec
+	err   error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+	}
+}
+
+func (r *reader) read(data []byte) {
+	if r.err == nil {
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			r.fail(err)
+		}
+	}
+}
+
+func (r *reader) readByte() byte {
+	var tmp [1]byte
+	r.read(tmp[:])
+	return tmp[0]
+}
+
+func (r *reader) readUvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		r.fail(err)
+	}
+	return x
+}
+
+// 個数として読み取る
+func (r *reader) readCount() int {
+	x := r.readUvarint()
+	if x > maxBytesLength {
+		r.fail(protocolError("too large count %d", x))
+		return 0
+	}
+	return int(x)
+}
+
+func (r *reader) readBytes() []byte {
+	length := r.readUvarint()
+	if length > maxBytesLength {
+		r.fail(protocolError("too large data length %d", length))
+	}
+	if r.err != nil {
+		return nil
+	}
+	data := make([]byte, length)
+	r.read(data)
+	return data
+}
+
+func (r *reader) readKey() avltree.Key {
+	data := r.readBytes()
+	if r.err != nil {
+		return nil
+	}
+	key, err := r.codec.DecodeKey(data)
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	return key
+}
+
+func (r *reader) readBound() avltree.Key {
+	switch r.readByte() {
+	case 0:
+		return nil
+	case 1:
+		return r.readKey()
+	default:
+		r.fail(protocolError("invalid bound"))
+		return nil
+	}
+}
+
+// キーの昇順に並んだ組の並びを読み取る
+func (r *reader) readEntries() (list []entry) {
+	n := r.readCount()
+	for i := 0; i < n && r.err == nil; i++ {
+		key := r.readKey()
+		data := r.readBytes()
+		if r.err != nil {
+			return nil
+		}
+		value, err := r.codec.DecodeValue(data)
+		if err != nil {
+			r.fail(err)
+			return nil
+		}
+		if i > 0 && !key.CompareTo(list[i-1].key).GreaterThan() {
+			r.fail(protocolError("entries are not in ascending order"))
+			return nil
+		}
+		list = append(list, entry{key, value, data})
+	}
+	return
+}
+
+func (r *reader) readHash() (hash merkletree.Hash) {
+	r.read(hash[:])
+	return
+}
@@ -0,0 +1,362 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltree/merkletreeの木どうしの内容を通信で揃える(アンチエントロピー)
+//
+// 片方がInitiate、もう片方がRespondを同じ通信路(io.ReadWriter)で呼び出す
+// Initiate側はキーの範囲の組の数とハッシュ値をRespond側に送り、Respond側は自分の木の同じ範囲と比較して
+// 一致していればその範囲を終わりにし、一致しなければ範囲を分割するキーか範囲内の全ての組を返す
+// 分割された範囲は次の往復でまとめて比較するため、往復の回数は木の大きさの対数程度になる
+// 範囲内の組を受け取ったInitiate側は自分の組と突き合わせ、それぞれに足りない組と値の異なる組だけを反映する
+//
+// 揃えた結果は両方の木の和集合になる(片方にだけあるキーはもう片方に追加される)
+// 削除は伝わらないので、削除を伝えたい場合は削除を表す値(墓標)を使う
+// 両方にあって値(codecで変換したバイト列)が異なるキーはInitiate側に渡したResolverで値を決める
+// (Resolverがnilの場合はInitiate側の値を採用する)
+//
+// 両方の木は同じcodecを持つ同一キーを許可しないmerkletreeでなければならない
+// 通信や変換でエラーが発生した場合は元の木とエラーを返す(相手側の木は途中まで揃えられている場合がある)
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"net"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/codec"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/merkletree"
+//			"github.com/neetsdkasu/avltree/sync"
+//		)
+//		func Example_sync() {
+//			c := codec.New(codec.IntKey, codec.StringValue)
+//			tree1 := merkletree.New(false, c)
+//			tree2 := merkletree.New(false, c)
+//			tree1, _ = avltree.Insert(tree1, false, IntKey(1), "a")
+//			tree1, _ = avltree.Insert(tree1, false, IntKey(2), "b")
+//			tree2, _ = avltree.Insert(tree2, false, IntKey(2), "B")
+//			tree2, _ = avltree.Insert(tree2, false, IntKey(3), "c")
+//			conn1, conn2 := net.Pipe()
+//			done := make(chan avltree.Tree)
+//			go func() {
+//				tree, _, _ := sync.Respond(conn2, tree2)
+//				done <- tree
+//			}()
+//			resolver := func(key avltree.Key, local, remote interface{}) (resolved interface{}) {
+//				return local.(string) + remote.(string)
+//			}
+//			tree1, _, _ = sync.Initiate(conn1, tree1, resolver)
+//			tree2 = <-done
+//			avltree.Iterate(tree1, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Initiate!", node.Key(), node.Value())
+//				return
+//			})
+//			avltree.Iterate(tree2, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Respond!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Initiate! 1 a
+//			// Initiate! 2 bB
+//			// Initiate! 3 c
+//			// Respond! 1 a
+//			// Respond! 2 bB
+//			// Respond! 3 c
+//		}
+//
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/merkletree"
+)
+
+// 両方の木にあって値が異なるキーの値を決める
+// localはInitiate側の値、remoteはRespond側の値
+type Resolver = func(key avltree.Key, local, remote interface{}) (resolved interface{})
+
+// 同期の経過
+type Stats struct {
+	// メッセージの往復の回数
+	Rounds int
+
+	// 比較したキーの範囲の数
+	Ranges int
+
+	// 相手に送った組の数
+	Sent int
+
+	// 相手から受け取った組の数
+	Received int
+}
+
+// 範囲の組の数がこれ以下の場合は範囲を分割せずに組を送る
+const leafSize = 8
+
+// キーがlower以上upper未満の範囲(nilの場合はその側に制限が無い)
+type keyRange struct {
+	lower, upper avltree.Key
+}
+
+type entry struct {
+	key   avltree.Key
+	value interface{}
+
+	// codecで変換した値
+	data []byte
+}
+
+func merkleTree(tree avltree.Tree) *merkletree.MerkleTree {
+	mt, ok := tree.(*merkletree.MerkleTree)
+	if !ok {
+		panic("sync: tree is not merkletree")
+	}
+	if mt.AllowDuplicateKeys() {
+		panic("sync: duplicate keys are not supported")
+	}
+	return mt
+}
+
+func (rg *keyRange) contains(key avltree.Key) bool {
+	if rg.lower != nil && key.CompareTo(rg.lower).LessThan() {
+		return false
+	}
+	return rg.upper == nil || key.CompareTo(rg.upper).LessThan()
+}
+
+// 範囲内の組のハッシュ値と組の数
+func fingerprint(mt *merkletree.MerkleTree, rg keyRange) (hash merkletree.Hash, count int) {
+	hash, count = mt.RangeHash(rg.lower, nil)
+	if rg.upper != nil {
+		upperHash, upperCount := mt.RangeHash(rg.upper, nil)
+		hash = hash.Sub(upperHash)
+		count -= upperCount
+	}
+	return
+}
+
+// 範囲内の組の数がおよそ半分になるように分割するキーを返す
+// 範囲内の組の数countは2以上であること
+func splitKey(mt *merkletree.MerkleTree, rg keyRange, count int) avltree.Key {
+	start := 0
+	if rg.lower != nil {
+		_, upperCount := mt.RangeHash(rg.lower, nil)
+		start = mt.NodeCount() - upperCount
+	}
+	return mt.NodeAt(start + count/2).Key()
+}
+
+// 範囲内の組をキーの昇順に返す
+func entries(mt *merkletree.MerkleTree, rg keyRange) (list []entry, err error) {
+	avltree.RangeIterate(mt, false, rg.lower, nil, func(node avltree.Node) (breakIteration bool) {
+		if !rg.contains(node.Key()) {
+			return true
+		}
+		var data []byte
+		data, err = mt.Codec.EncodeValue(node.Value())
+		if err != nil {
+			return true
+		}
+		list = append(list, entry{node.Key(), node.Value(), data})
+		return
+	})
+	return
+}
+
+// Respond側の木を相手にしてtreeの内容を揃える
+// resolverがnilの場合は値が異なるキーはtreeの値を採用する
+func Initiate(rw io.ReadWriter, tree avltree.Tree, resolver Resolver) (modified avltree.Tree, stats Stats, err error) {
+	base := merkleTree(tree)
+	w := &writer{w: bufio.NewWriter(rw), codec: base.Codec}
+	r := &reader{r: bufio.NewReader(rw), codec: base.Codec}
+	w.write([]byte(protocolMagic))
+	w.writeByte(protocolVersion)
+	modified = tree
+	pending := []keyRange{{nil, nil}}
+	var updates []entry
+	for {
+		stats.Rounds++
+		stats.Ranges += len(pending)
+		stats.Sent += len(updates)
+		w.writeEntries(updates)
+		updates = nil
+		w.writeUvarint(uint64(len(pending)))
+		for _, rg := range pending {
+			hash, count := fingerprint(base, rg)
+			w.writeBound(rg.lower)
+			w.writeBound(rg.upper)
+			w.writeUvarint(uint64(count))
+			w.writeHash(hash)
+		}
+		if err := w.flush(); err != nil {
+			return tree, stats, err
+		}
+		if len(pending) == 0 {
+			if reply := r.readByte(); r.err == nil && reply != replyDone {
+				r.fail(protocolError("unexpected reply %d", reply))
+			}
+			if r.err != nil {
+				return tree, stats, r.err
+			}
+			return modified, stats, nil
+		}
+		var next []keyRange
+		for _, rg := range pending {
+			switch reply := r.readByte(); {
+			case r.err != nil:
+			case reply == replyMatch:
+			case reply == replySplit:
+				key := r.readKey()
+				if r.err != nil {
+					break
+				}
+				// 分割した両方の範囲が空でないこと
+				if !rg.contains(key) || (rg.lower != nil && key.CompareTo(rg.lower).EqualTo()) {
+					r.fail(protocolError("invalid split key"))
+					break
+				}
+				next = append(next, keyRange{rg.lower, key}, keyRange{key, rg.upper})
+			case reply == replyEntries:
+				remote := r.readEntries()
+				if r.err != nil {
+					break
+				}
+				stats.Received += len(remote)
+				var list []entry
+				modified, list, err = merge(base, modified, rg, remote, resolver)
+				if err != nil {
+					return tree, stats, err
+				}
+				updates = append(updates, list...)
+			default:
+				r.fail(protocolError("unexpected reply %d", reply))
+			}
+			if r.err != nil {
+				return tree, stats, r.err
+			}
+		}
+		pending = next
+	}
+}
+
+// 範囲内の自分の組と相手の組を突き合わせ、自分の木に反映したものと相手に送る組を返す
+func merge(base *merkletree.MerkleTree, tree avltree.Tree, rg keyRange, remote []entry, resolver Resolver) (modified avltree.Tree, updates []entry, err error) {
+	local, err := entries(base, rg)
+	if err != nil {
+		return tree, nil, err
+	}
+	for _, e := range remote {
+		if !rg.contains(e.key) {
+			return tree, nil, protocolError("entry out of range")
+		}
+	}
+	modified = tree
+	i, j := 0, 0
+	for i < len(local) || j < len(remote) {
+		var cmp avltree.KeyOrdering
+		switch {
+		case i == len(local):
+			cmp = avltree.GreaterThanOtherKey
+		case j == len(remote):
+			cmp = avltree.LessThanOtherKey
+		default:
+			cmp = local[i].key.CompareTo(remote[j].key)
+		}
+		switch {
+		case cmp.LessThan():
+			updates = append(updates, local[i])
+			i++
+		case cmp.GreaterThan():
+			modified, _ = avltree.Insert(modified, false, remote[j].key, remote[j].value)
+			j++
+		default:
+			l, r := local[i], remote[j]
+			i++
+			j++
+			if bytes.Equal(l.data, r.data) {
+				continue
+			}
+			resolved := l
+			if resolver != nil {
+				resolved.value = resolver(l.key, l.value, r.value)
+				if resolved.data, err = base.Codec.EncodeValue(resolved.value); err != nil {
+					return tree, nil, err
+				}
+			}
+			if !bytes.Equal(resolved.data, l.data) {
+				modified, _ = avltree.Replace(modified, l.key, resolved.value)
+			}
+			if !bytes.Equal(resolved.data, r.data) {
+				updates = append(updates, resolved)
+			}
+		}
+	}
+	return modified, updates, nil
+}
+
+// Initiate側の木を相手にしてtreeの内容を揃える
+func Respond(rw io.ReadWriter, tree avltree.Tree) (modified avltree.Tree, stats Stats, err error) {
+	base := merkleTree(tree)
+	w := &writer{w: bufio.NewWriter(rw), codec: base.Codec}
+	r := &reader{r: bufio.NewReader(rw), codec: base.Codec}
+	header := make([]byte, len(protocolMagic)+1)
+	r.read(header)
+	if r.err != nil {
+		return tree, stats, r.err
+	}
+	if string(header[:len(protocolMagic)]) != protocolMagic || header[len(protocolMagic)] != protocolVersion {
+		return tree, stats, protocolError("invalid header")
+	}
+	modified = tree
+	for {
+		updates := r.readEntries()
+		count := r.readCount()
+		if r.err != nil {
+			return tree, stats, r.err
+		}
+		stats.Rounds++
+		stats.Ranges += count
+		stats.Received += len(updates)
+		for _, e := range updates {
+			modified, _ = avltree.Insert(modified, true, e.key, e.value)
+		}
+		if count == 0 {
+			w.writeByte(replyDone)
+			if err := w.flush(); err != nil {
+				return tree, stats, err
+			}
+			return modified, stats, nil
+		}
+		for i := 0; i < count; i++ {
+			rg := keyRange{r.readBound(), r.readBound()}
+			remoteCount := r.readCount()
+			remoteHash := r.readHash()
+			if r.err != nil {
+				return tree, stats, r.err
+			}
+			hash, localCount := fingerprint(base, rg)
+			switch {
+			case hash == remoteHash && localCount == remoteCount:
+				w.writeByte(replyMatch)
+			case localCount <= leafSize:
+				list, err := entries(base, rg)
+				if err != nil {
+					return tree, stats, err
+				}
+				stats.Sent += len(list)
+				w.writeByte(replyEntries)
+				w.writeEntries(list)
+			default:
+				w.writeByte(replySplit)
+				w.writeKey(splitKey(base, rg, localCount))
+			}
+		}
+		if err := w.flush(); err != nil {
+			return tree, stats, err
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/merkletree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+var testCodec = codec.New(codec.IntKey, codec.IntValue)
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+func toMap(tree avltree.Tree) map[int]int {
+	m := map[int]int{}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		m[int(node.Key().(IntKey))] = node.Value().(int)
+		return
+	})
+	return m
+}
+
+func makeTree(list []keyAndValue) avltree.Tree {
+	tree := merkletree.New(false, testCodec)
+	for _, kv := range list {
+		tree, _ = avltree.Insert(tree, true, IntKey(int16(kv.Key)), kv.Value)
+	}
+	return tree
+}
+
+type result struct {
+	tree  avltree.Tree
+	stats Stats
+	err   error
+}
+
+func run(tree1, tree2 avltree.Tree, resolver Resolver) (result1, result2 result) {
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+	done := make(chan result)
+	go func() {
+		defer conn2.Close()
+		tree, stats, err := Respond(conn2, tree2)
+		done <- result{tree, stats, err}
+	}()
+	result1.tree, result1.stats, result1.err = Initiate(conn1, tree1, resolver)
+	conn1.Close()
+	result2 = <-done
+	return
+}
+
+func maxResolver(key avltree.Key, local, remote interface{}) (resolved interface{}) {
+	if local.(int) < remote.(int) {
+		return remote
+	}
+	return local
+}
+
+func TestSameAsUnion(t *testing.T) {
+	f := func(list1, list2 []keyAndValue, useResolver bool) bool {
+		tree1, tree2 := makeTree(list1), makeTree(list2)
+		map1, map2 := toMap(tree1), toMap(tree2)
+		want := map[int]int{}
+		for k, v := range map2 {
+			want[k] = v
+		}
+		for k, v := range map1 {
+			if v2, ok := map2[k]; ok && useResolver && v2 > v {
+				v = v2
+			}
+			want[k] = v
+		}
+		var resolver Resolver
+		if useResolver {
+			resolver = maxResolver
+		}
+		result1, result2 := run(tree1, tree2, resolver)
+		if result1.err != nil || result2.err != nil {
+			t.Fatal(result1.err, result2.err)
+		}
+		if !reflect.DeepEqual(toMap(result1.tree), want) || !reflect.DeepEqual(toMap(result2.tree), want) {
+			return false
+		}
+		if result1.tree.(*merkletree.MerkleTree).RootHash() != result2.tree.(*merkletree.MerkleTree).RootHash() {
+			return false
+		}
+		// 元の木は変更されない
+		if !reflect.DeepEqual(toMap(tree1), map1) || !reflect.DeepEqual(toMap(tree2), map2) {
+			return false
+		}
+		s1, s2 := result1.stats, result2.stats
+		return s1.Rounds == s2.Rounds && s1.Ranges == s2.Ranges && s1.Sent == s2.Received && s1.Received == s2.Sent
+	}
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFewDifferences(t *testing.T) {
+	list := []keyAndValue{}
+	for k := 0; k < 10000; k++ {
+		list = append(list, keyAndValue{k, k})
+	}
+	tree1 := makeTree(list)
+	tree2 := makeTree(list)
+	result1, _ := run(tree1, tree2, nil)
+	if result1.err != nil || result1.stats.Rounds != 2 || result1.stats.Ranges != 1 {
+		t.Fatal("same trees", result1.err, result1.stats)
+	}
+	tree1, _ = avltree.Replace(tree1, IntKey(1234), -1)
+	tree2, _ = avltree.Delete(tree2, IntKey(5678))
+	result1, result2 := run(tree1, tree2, nil)
+	if result1.err != nil || result2.err != nil {
+		t.Fatal(result1.err, result2.err)
+	}
+	stats := result1.stats
+	// 分割ごとに範囲の組の数は半分ほどになる
+	if stats.Rounds > 14 || stats.Ranges > 4*14 || stats.Sent != 2 || stats.Received > 2*leafSize {
+		t.Fatal("too many exchanges", stats)
+	}
+	if toMap(result1.tree)[1234] != -1 || toMap(result2.tree)[1234] != -1 || len(toMap(result2.tree)) != 10000 {
+		t.Fatal("not synchronized")
+	}
+}
+
+func TestInvalidHeader(t *testing.T) {
+	var rw bytes.Buffer
+	rw.WriteString("AVLTSYNX\x01")
+	tree := makeTree(nil)
+	if modified, _, err := Respond(&rw, tree); !errors.Is(err, ErrProtocol) || modified != tree {
+		t.Fatal("invalid header is not detected", err)
+	}
+}
+
+func TestInvalidReply(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+	defer conn1.Close()
+	go func() {
+		defer conn2.Close()
+		buf := make([]byte, 1024)
+		conn2.Read(buf)
+		conn2.Write([]byte{99})
+	}()
+	tree := makeTree([]keyAndValue{{1, 1}})
+	if modified, _, err := Initiate(conn1, tree, nil); !errors.Is(err, ErrProtocol) || modified != tree {
+		t.Fatal("invalid reply is not detected", err)
+	}
+}
+
+func TestClosedConnection(t *testing.T) {
+	conn1, conn2 := net.Pipe()
+	conn2.Close()
+	tree := makeTree([]keyAndValue{{1, 1}})
+	if _, _, err := Initiate(conn1, tree, nil); err == nil {
+		t.Fatal("no error")
+	}
+}
+
+func TestNotMerkleTree(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	var rw bytes.Buffer
+	Initiate(&rw, simpletree.New(false), nil)
+}
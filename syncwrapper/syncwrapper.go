@@ -0,0 +1,496 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのための並行に使用できるラッパーの実装例
+// 任意のavltree.Treeをsync.RWMutexで保護する
+//
+// Find,Range,Count,Iterateなどの読み取りのみの操作は共有ロックで、Insert,Deleteなどの変更の操作は排他ロックで実行される
+// ロックの外に木のノードを持ち出さないよう、Find,Range,Min,Maxなどはノードではなくキーと値の複製(avltree.KeyAndValue)を返す
+// (キーや値そのものが指すデータの保護はしない)
+//
+// コールバックはロックを保持したまま呼び出されるため、コールバックの中から同じAVLTreeのメソッドを呼び出してはならない
+// 排他ロックでは必ずデッドロックし、共有ロックでも他のゴルーチンが排他ロックを待っているとデッドロックするため、
+// コールバックの中からの呼び出しを検出した場合はpanicになる
+// (検出はコールバックを呼び出す操作が実行中のときのみ行い、コールバックから起動した別のゴルーチンからの呼び出しは検出できない)
+// コールバックに渡されたノードをコールバックの外で使用してはならない
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"sync"
+//			"github.com/neetsdkasu/avltree"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//			"github.com/neetsdkasu/avltree/syncwrapper"
+//		)
+//		func Example_syncwrapper() {
+//			w := syncwrapper.New(simpletree.New(false))
+//			var wg sync.WaitGroup
+//			for i := 0; i < 4; i++ {
+//				wg.Add(1)
+//				go func(i int) {
+//					defer wg.Done()
+//					for k := i; k < 100; k += 4 {
+//						w.Insert(IntKey(k), k*k)
+//					}
+//				}(i)
+//			}
+//			wg.Wait()
+//			if kv := w.Find(IntKey(12)); kv != nil {
+//				fmt.Println("Find!", kv.Key(), kv.Value())
+//			}
+//			fmt.Println("Count!", w.Count())
+//			w.RangeIterate(IntKey(97), nil, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("RangeIterate!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// Find! 12 144
+//			// Count! 100
+//			// RangeIterate! 97 9409
+//			// RangeIterate! 98 9604
+//			// RangeIterate! 99 9801
+//		}
+//
+package syncwrapper
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type AVLTree struct {
+	mutex sync.RWMutex
+	tree  avltree.Tree
+	guard callBackGuard
+}
+
+// コールバックを呼び出す操作を実行中のゴルーチンを記録し、コールバックの中からの呼び出しを検出する
+type callBackGuard struct {
+	active int32
+	mutex  sync.Mutex
+	owners map[uint64]int
+}
+
+type keyAndValueCopy struct {
+	key   avltree.Key
+	value interface{}
+}
+
+func New(tree avltree.Tree) *AVLTree {
+	return &AVLTree{tree: tree}
+}
+
+// 現在のゴルーチンのIDを返す
+// runtime.Stackの出力の先頭の "goroutine 123 [running]:" から取り出す
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	var id uint64
+	for _, c := range buf[len("goroutine "):n] {
+		if c < '0' || '9' < c {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+func (guard *callBackGuard) check() {
+	if atomic.LoadInt32(&guard.active) == 0 {
+		return
+	}
+	id := goroutineID()
+	guard.mutex.Lock()
+	reentered := guard.owners[id] > 0
+	guard.mutex.Unlock()
+	if reentered {
+		panic("syncwrapper: re-entrant call from callback")
+	}
+}
+
+func (guard *callBackGuard) enter() uint64 {
+	id := goroutineID()
+	guard.mutex.Lock()
+	if guard.owners == nil {
+		guard.owners = make(map[uint64]int)
+	}
+	guard.owners[id]++
+	guard.mutex.Unlock()
+	atomic.AddInt32(&guard.active, 1)
+	return id
+}
+
+func (guard *callBackGuard) leave(id uint64) {
+	atomic.AddInt32(&guard.active, -1)
+	guard.mutex.Lock()
+	if guard.owners[id] > 1 {
+		guard.owners[id]--
+	} else {
+		delete(guard.owners, id)
+	}
+	guard.mutex.Unlock()
+}
+
+func (tree *AVLTree) lock() {
+	tree.guard.check()
+	tree.mutex.Lock()
+}
+
+func (tree *AVLTree) unlock() {
+	tree.mutex.Unlock()
+}
+
+func (tree *AVLTree) rlock() {
+	tree.guard.check()
+	tree.mutex.RLock()
+}
+
+func (tree *AVLTree) runlock() {
+	tree.mutex.RUnlock()
+}
+
+func (tree *AVLTree) lockForCallBack() uint64 {
+	tree.lock()
+	return tree.guard.enter()
+}
+
+func (tree *AVLTree) unlockForCallBack(id uint64) {
+	tree.guard.leave(id)
+	tree.unlock()
+}
+
+func (tree *AVLTree) rlockForCallBack() uint64 {
+	tree.rlock()
+	return tree.guard.enter()
+}
+
+func (tree *AVLTree) runlockForCallBack(id uint64) {
+	tree.guard.leave(id)
+	tree.runlock()
+}
+
+func (kv *keyAndValueCopy) Key() avltree.Key {
+	return kv.key
+}
+
+func (kv *keyAndValueCopy) Value() interface{} {
+	return kv.value
+}
+
+func copyNode(node avltree.Node) avltree.KeyAndValue {
+	if node == nil {
+		return nil
+	}
+	return &keyAndValueCopy{node.Key(), node.Value()}
+}
+
+func copyNodes(nodes []avltree.Node) (values []avltree.KeyAndValue) {
+	for _, node := range nodes {
+		values = append(values, copyNode(node))
+	}
+	return
+}
+
+func (tree *AVLTree) Insert(key avltree.Key, value interface{}) (ok bool) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, ok = avltree.Insert(tree.tree, false, key, value)
+	return
+}
+
+func (tree *AVLTree) InsertOrReplace(key avltree.Key, value interface{}) (ok bool) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, ok = avltree.Insert(tree.tree, true, key, value)
+	return
+}
+
+func (tree *AVLTree) Delete(key avltree.Key) (deletedValue avltree.KeyAndValue) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, deletedValue = avltree.Delete(tree.tree, key)
+	return
+}
+
+func (tree *AVLTree) Update(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.Update(tree.tree, key, callBack)
+	return
+}
+
+func (tree *AVLTree) Replace(key avltree.Key, value interface{}) (ok bool) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, ok = avltree.Replace(tree.tree, key, value)
+	return
+}
+
+func (tree *AVLTree) Alter(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValue avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValue, ok = avltree.Alter(tree.tree, key, callBack)
+	return
+}
+
+func (tree *AVLTree) Clear() {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree = avltree.Clear(tree.tree)
+}
+
+func (tree *AVLTree) Release() {
+	tree.lock()
+	defer tree.unlock()
+	avltree.Release(&tree.tree)
+}
+
+func (tree *AVLTree) Find(key avltree.Key) (value avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNode(avltree.Find(tree.tree, key))
+}
+
+func (tree *AVLTree) Iterate(callBack avltree.IterateCallBack) {
+	id := tree.rlockForCallBack()
+	defer tree.runlockForCallBack(id)
+	avltree.Iterate(tree.tree, false, callBack)
+}
+
+func (tree *AVLTree) IterateRev(callBack avltree.IterateCallBack) {
+	id := tree.rlockForCallBack()
+	defer tree.runlockForCallBack(id)
+	avltree.Iterate(tree.tree, true, callBack)
+}
+
+func (tree *AVLTree) Range(lower, upper avltree.Key) (values []avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNodes(avltree.Range(tree.tree, false, lower, upper))
+}
+
+func (tree *AVLTree) RangeRev(lower, upper avltree.Key) (values []avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNodes(avltree.Range(tree.tree, true, lower, upper))
+}
+
+func (tree *AVLTree) RangeIterate(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	id := tree.rlockForCallBack()
+	defer tree.runlockForCallBack(id)
+	avltree.RangeIterate(tree.tree, false, lower, upper, callBack)
+}
+
+func (tree *AVLTree) RangeIterateRev(lower, upper avltree.Key, callBack avltree.IterateCallBack) {
+	id := tree.rlockForCallBack()
+	defer tree.runlockForCallBack(id)
+	avltree.RangeIterate(tree.tree, true, lower, upper, callBack)
+}
+
+func (tree *AVLTree) Count() int {
+	tree.rlock()
+	defer tree.runlock()
+	return avltree.Count(tree.tree)
+}
+
+func (tree *AVLTree) CountRange(lower, upper avltree.Key) int {
+	tree.rlock()
+	defer tree.runlock()
+	return avltree.CountRange(tree.tree, lower, upper)
+}
+
+func (tree *AVLTree) Min() (value avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNode(avltree.Min(tree.tree))
+}
+
+func (tree *AVLTree) Max() (value avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNode(avltree.Max(tree.tree))
+}
+
+func (tree *AVLTree) DeleteAll(key avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, deletedValues = avltree.DeleteAll(tree.tree, key)
+	return
+}
+
+func (tree *AVLTree) UpdateAll(key avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateAll(tree.tree, key, callBack)
+	return
+}
+
+func (tree *AVLTree) ReplaceAll(key avltree.Key, value interface{}) (ok bool) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, ok = avltree.ReplaceAll(tree.tree, key, value)
+	return
+}
+
+func (tree *AVLTree) AlterAll(key avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterAll(tree.tree, key, callBack)
+	return
+}
+
+func (tree *AVLTree) FindAll(key avltree.Key) (values []avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNodes(avltree.FindAll(tree.tree, key))
+}
+
+func (tree *AVLTree) MinAll() (values []avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNodes(avltree.MinAll(tree.tree))
+}
+
+func (tree *AVLTree) MaxAll() (values []avltree.KeyAndValue) {
+	tree.rlock()
+	defer tree.runlock()
+	return copyNodes(avltree.MaxAll(tree.tree))
+}
+
+func (tree *AVLTree) DeleteIterate(callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues = avltree.DeleteIterate(tree.tree, false, callBack)
+	return
+}
+
+func (tree *AVLTree) DeleteIterateRev(callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues = avltree.DeleteIterate(tree.tree, true, callBack)
+	return
+}
+
+func (tree *AVLTree) DeleteRange(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, deletedValues = avltree.DeleteRange(tree.tree, false, lower, upper)
+	return
+}
+
+func (tree *AVLTree) DeleteRangeRev(lower, upper avltree.Key) (deletedValues []avltree.KeyAndValue) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, deletedValues = avltree.DeleteRange(tree.tree, true, lower, upper)
+	return
+}
+
+func (tree *AVLTree) DeleteRangeIterate(lower, upper avltree.Key, callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues = avltree.DeleteRangeIterate(tree.tree, false, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) DeleteRangeIterateRev(lower, upper avltree.Key, callBack avltree.DeleteIterateCallBack) (deletedValues []avltree.KeyAndValue) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues = avltree.DeleteRangeIterate(tree.tree, true, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateIterate(callBack avltree.UpdateIterateCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateIterate(tree.tree, false, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateIterateRev(callBack avltree.UpdateIterateCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateIterate(tree.tree, true, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateRange(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateRange(tree.tree, false, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateRangeRev(lower, upper avltree.Key, callBack avltree.UpdateValueCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateRange(tree.tree, true, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateRangeIterate(lower, upper avltree.Key, callBack avltree.UpdateIterateCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateRangeIterate(tree.tree, false, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) UpdateRangeIterateRev(lower, upper avltree.Key, callBack avltree.UpdateIterateCallBack) (ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, ok = avltree.UpdateRangeIterate(tree.tree, true, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) ReplaceRange(lower, upper avltree.Key, value interface{}) (ok bool) {
+	tree.lock()
+	defer tree.unlock()
+	tree.tree, ok = avltree.ReplaceRange(tree.tree, lower, upper, value)
+	return
+}
+
+func (tree *AVLTree) AlterIterate(callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterIterate(tree.tree, false, callBack)
+	return
+}
+
+func (tree *AVLTree) AlterIterateRev(callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterIterate(tree.tree, true, callBack)
+	return
+}
+
+func (tree *AVLTree) AlterRange(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterRange(tree.tree, false, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) AlterRangeRev(lower, upper avltree.Key, callBack avltree.AlterNodeCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterRange(tree.tree, true, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) AlterRangeIterate(lower, upper avltree.Key, callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterRangeIterate(tree.tree, false, lower, upper, callBack)
+	return
+}
+
+func (tree *AVLTree) AlterRangeIterateRev(lower, upper avltree.Key, callBack avltree.AlterIterateCallBack) (deletedValues []avltree.KeyAndValue, ok bool) {
+	id := tree.lockForCallBack()
+	defer tree.unlockForCallBack(id)
+	tree.tree, deletedValues, ok = avltree.AlterRangeIterate(tree.tree, true, lower, upper, callBack)
+	return
+}
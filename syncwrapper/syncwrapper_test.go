@@ -0,0 +1,236 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package syncwrapper
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	"github.com/neetsdkasu/avltree/simplewrapper"
+)
+
+// go test -race で実行することを想定したテストを含む
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree":    simpletree.New,
+	"immutabletree": immutabletree.New,
+}
+
+func toKeyValueInts(list []avltree.KeyAndValue) (result []int) {
+	for _, kv := range list {
+		result = append(result, int(kv.Key().(IntKey)), kv.Value().(int))
+	}
+	return
+}
+
+func getAllAscKeyAndValues(iterate func(callBack avltree.IterateCallBack)) (result []int) {
+	iterate(func(node avltree.Node) (breakIteration bool) {
+		result = append(result, int(node.Key().(IntKey)), node.Value().(int))
+		return
+	})
+	return
+}
+
+func TestSameAsSimpleWrapper(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(list []keyAndValue, k1, k2 int) []int {
+			w := New(newTree(false))
+			for i, kv := range list {
+				switch i % 4 {
+				case 3:
+					w.Delete(IntKey(kv.Key))
+				default:
+					w.Insert(IntKey(kv.Key), kv.Value)
+				}
+			}
+			w.UpdateRange(IntKey(k1), IntKey(k2), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+				newValue = oldValue.(int) / 2
+				return
+			})
+			result := toKeyValueInts(w.Range(IntKey(k1), IntKey(k2)))
+			result = append(result, w.CountRange(IntKey(k1), IntKey(k2)))
+			return append(result, getAllAscKeyAndValues(w.Iterate)...)
+		}
+
+		g := func(list []keyAndValue, k1, k2 int) []int {
+			w := simplewrapper.New(newTree(false))
+			for i, kv := range list {
+				switch i % 4 {
+				case 3:
+					w.Delete(IntKey(kv.Key))
+				default:
+					w.Insert(IntKey(kv.Key), kv.Value)
+				}
+			}
+			w.UpdateRange(IntKey(k1), IntKey(k2), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+				newValue = oldValue.(int) / 2
+				return
+			})
+			result := []int{}
+			for _, node := range w.Range(IntKey(k1), IntKey(k2)) {
+				result = append(result, int(node.Key().(IntKey)), node.Value().(int))
+			}
+			if len(result) == 0 {
+				result = nil
+			}
+			result = append(result, w.CountRange(IntKey(k1), IntKey(k2)))
+			return append(result, getAllAscKeyAndValues(w.Iterate)...)
+		}
+
+		if err := quick.CheckEqual(f, g, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	for name, newTree := range treeMakers {
+		const writers = 4
+		const readers = 4
+		const size = 1000
+		w := New(newTree(false))
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for k := i; k < size; k += writers {
+					w.Insert(IntKey(k), k)
+					w.Update(IntKey(k), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+						newValue = oldValue.(int) * 2
+						return
+					})
+				}
+			}(i)
+		}
+		errs := make(chan string, readers)
+		for i := 0; i < readers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < size; k++ {
+					if kv := w.Find(IntKey(k)); kv != nil {
+						if v := kv.Value().(int); v != k && v != k*2 {
+							errs <- "invalid value"
+							return
+						}
+					}
+					prev := -1
+					w.RangeIterate(IntKey(k), IntKey(k+10), func(node avltree.Node) (breakIteration bool) {
+						key := int(node.Key().(IntKey))
+						if key <= prev {
+							errs <- "invalid order"
+							return true
+						}
+						prev = key
+						return
+					})
+					if n := w.Count(); n < 0 || size < n {
+						errs <- "invalid count"
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatal(name, err)
+		}
+		want := []int{}
+		for k := 0; k < size; k++ {
+			want = append(want, k, k*2)
+		}
+		if got := getAllAscKeyAndValues(w.Iterate); !reflect.DeepEqual(got, want) {
+			t.Fatal(name, "unexpected result")
+		}
+	}
+}
+
+func expectPanic(t *testing.T, name string, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic on re-entrant call in", name)
+		}
+	}()
+	f()
+}
+
+func TestReentrantCallBackPanics(t *testing.T) {
+	w := New(simpletree.New(false))
+	for k := 0; k < 10; k++ {
+		w.Insert(IntKey(k), k)
+	}
+	expectPanic(t, "Iterate", func() {
+		w.Iterate(func(node avltree.Node) (breakIteration bool) {
+			w.Find(node.Key())
+			return
+		})
+	})
+	expectPanic(t, "RangeIterate", func() {
+		w.RangeIterate(IntKey(3), IntKey(5), func(node avltree.Node) (breakIteration bool) {
+			w.Delete(node.Key())
+			return
+		})
+	})
+	expectPanic(t, "Update", func() {
+		w.Update(IntKey(1), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+			w.Insert(IntKey(100), 100)
+			return
+		})
+	})
+	expectPanic(t, "AlterRange", func() {
+		w.AlterRange(nil, nil, func(node avltree.AlterNode) (request avltree.AlterRequest) {
+			w.Count()
+			return node.Keep()
+		})
+	})
+
+	// panicのあともロックは解放されている
+	if w.Count() != 10 || w.Find(IntKey(100)) != nil {
+		t.Fatal("tree is broken")
+	}
+}
+
+func TestCallBackInOtherGoroutine(t *testing.T) {
+	w := New(simpletree.New(false))
+	for k := 0; k < 10; k++ {
+		w.Insert(IntKey(k), k)
+	}
+	inCallBack := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.Iterate(func(node avltree.Node) (breakIteration bool) {
+			close(inCallBack)
+			<-done
+			return true
+		})
+	}()
+	<-inCallBack
+	// 他のゴルーチンがコールバックを実行中でも共有ロックの操作は実行でき、panicにもならない
+	if kv := w.Find(IntKey(5)); kv == nil || kv.Value() != 5 {
+		t.Fatal("Find failed")
+	}
+	if w.Count() != 10 {
+		t.Fatal("Count failed")
+	}
+	close(done)
+	w.Insert(IntKey(10), 10)
+	if w.Count() != 11 {
+		t.Fatal("Insert failed")
+	}
+}
@@ -0,0 +1,54 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// time.Time型をキーにしてある
+// 時刻(エポックからの秒とナノ秒)の順序をキーの順序としてある
+//
+// time.Nowなどが返す値に含まれるモノトニック時計の読みは比較に使わない
+// (time.TimeのBeforeやAfterは両方にモノトニック時計の読みがある場合はそれで比較するため、
+// 壁時計の時刻が同じでも異なる順序になることがある)
+// TimeKey(time.Now())のように直接変換した場合もTime,String,Copyはモノトニック時計の読みを取り除いた値を返す
+// 場所(Location)が異なっても同じ時刻であれば同じキーになる
+package timekey
+
+import (
+	"time"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type TimeKey time.Time
+
+// モノトニック時計の読みを取り除いたTimeKeyを返す
+func New(t time.Time) TimeKey {
+	return TimeKey(t.Round(0))
+}
+
+// モノトニック時計の読みを取り除いた時刻を返す
+func (key TimeKey) Time() time.Time {
+	return time.Time(key).Round(0)
+}
+
+func (key TimeKey) String() string {
+	return key.Time().String()
+}
+
+func (key TimeKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	t1 := key.Time()
+	t2 := other.(TimeKey).Time()
+	s1, s2 := t1.Unix(), t2.Unix()
+	n1, n2 := t1.Nanosecond(), t2.Nanosecond()
+	switch {
+	case s1 < s2 || (s1 == s2 && n1 < n2):
+		return avltree.LessThanOtherKey
+	case s1 > s2 || (s1 == s2 && n1 > n2):
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (key TimeKey) Copy() avltree.Key {
+	return TimeKey(key.Time())
+}
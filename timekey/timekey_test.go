@@ -0,0 +1,92 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package timekey
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+func TestTimeKey(t *testing.T) {
+	f := func(s1, s2 int32, n1, n2 uint32, utc bool) bool {
+		t1 := time.Unix(int64(s1), int64(n1%1000000000))
+		t2 := time.Unix(int64(s2), int64(n2%1000000000))
+		if utc {
+			t2 = t2.UTC()
+		}
+		var key1 avltree.Key = New(t1)
+		var key2 avltree.Key = New(t2)
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			return t1.Before(t2)
+		case avltree.EqualToOtherKey:
+			return t1.Equal(t2)
+		case avltree.GreaterThanOtherKey:
+			return t1.After(t2)
+		default:
+			return false
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// モノトニック時計の読みに関わらず壁時計の時刻で比較する
+func TestMonotonic(t *testing.T) {
+	now := time.Now()
+	wall := now.Round(0)
+	if !TimeKey(now).CompareTo(New(wall)).EqualTo() || !New(wall).CompareTo(TimeKey(now)).EqualTo() {
+		t.Fatal("monotonic reading is used")
+	}
+	if !TimeKey(now.Add(time.Nanosecond)).CompareTo(New(wall)).GreaterThan() {
+		t.Fatal("wrong order")
+	}
+	if New(now).Time() != wall {
+		t.Fatal("monotonic reading is not stripped")
+	}
+	// 直接変換した場合もTime,String,Copyではモノトニック時計の読みを取り除く
+	key := TimeKey(now)
+	if key.Time() != wall || key.Copy().(TimeKey).Time() != wall || time.Time(key.Copy().(TimeKey)) != wall {
+		t.Fatal("monotonic reading is not stripped")
+	}
+	if s := key.String(); strings.Contains(s, "m=") || s != wall.String() {
+		t.Fatal("monotonic reading is in string", s)
+	}
+}
+
+func Example() {
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tree := simpletree.New(false)
+	avltree.Insert(tree, false, New(base.Add(time.Hour)), 345)
+	avltree.Insert(tree, false, New(base.Add(-time.Minute)), 890)
+	avltree.Insert(tree, false, New(base), 666)
+	avltree.Insert(tree, false, New(base.Add(time.Second)), 12345)
+	avltree.Delete(tree, New(base.Add(-time.Minute)))
+	avltree.Update(tree, New(base), func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+		newValue = oldValue.(int) * 3
+		return
+	})
+	if node := avltree.Find(tree, New(base.In(time.FixedZone("JST", 9*60*60)))); node != nil {
+		fmt.Println("Find!", node.Key(), node.Value())
+	}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Iterate!", node.Key(), node.Value())
+		return
+	})
+	// Output:
+	// Find! 2024-01-02 03:04:05 +0000 UTC 1998
+	// Iterate! 2024-01-02 03:04:05 +0000 UTC 1998
+	// Iterate! 2024-01-02 03:04:06 +0000 UTC 12345
+	// Iterate! 2024-01-02 04:04:05 +0000 UTC 345
+}
@@ -0,0 +1,128 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// 複数のKeyを要素とする組(タプル)をキーにしてある
+// 先頭の要素から順に比較し(辞書順)、全ての要素が等しい場合は要素数の少ないほうが小さい
+// 同じ位置の要素は同じ型のKeyでなければならない
+//
+// 要素をDescKeyで包むとその要素だけ降順で比較される
+//
+// MinとMaxは組の中でどのような要素よりも小さい(大きい)ものとして比較される特別な要素で、
+// RangeIterateなどの範囲の下限や上限に使う
+// Prefixは先頭の要素が指定のものと等しい全てのキーを範囲とする下限と上限を返す
+package tuplekey
+
+import (
+	"fmt"
+
+	"github.com/neetsdkasu/avltree"
+)
+
+type TupleKey []avltree.Key
+
+// 要素を降順で比較するためのKey
+type DescKey struct {
+	Key avltree.Key
+}
+
+type infinity int
+
+const (
+	// どのような要素よりも小さい要素
+	Min infinity = -1
+
+	// どのような要素よりも大きい要素
+	Max infinity = 1
+)
+
+func New(components ...avltree.Key) TupleKey {
+	return TupleKey(components)
+}
+
+func Desc(key avltree.Key) DescKey {
+	return DescKey{key}
+}
+
+// 先頭の要素がprefixと等しい全てのキー(prefixと等しいキーも含む)を範囲とする下限と上限を返す
+// prefixが空の場合は全てのキーが範囲になる
+// 下限はprefixそのもの(prefixを先頭に持つより長いキーはprefixより大きい)
+func Prefix(prefix ...avltree.Key) (lower, upper TupleKey) {
+	lower = make(TupleKey, len(prefix))
+	upper = make(TupleKey, len(prefix)+1)
+	copy(lower, prefix)
+	copy(upper, prefix)
+	upper[len(prefix)] = Max
+	return
+}
+
+func compareComponent(a, b avltree.Key) avltree.KeyOrdering {
+	infA, okA := a.(infinity)
+	infB, okB := b.(infinity)
+	switch {
+	case okA && okB && infA < infB:
+		return avltree.LessThanOtherKey
+	case okA && okB && infA > infB:
+		return avltree.GreaterThanOtherKey
+	case okA && okB:
+		return avltree.EqualToOtherKey
+	case okA:
+		return avltree.KeyOrdering(infA)
+	case okB:
+		return avltree.KeyOrdering(-infB)
+	default:
+		return a.CompareTo(b)
+	}
+}
+
+func (key TupleKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	tuple := other.(TupleKey)
+	for i := 0; i < len(key) && i < len(tuple); i++ {
+		if cmp := compareComponent(key[i], tuple[i]); !cmp.EqualTo() {
+			return cmp
+		}
+	}
+	switch {
+	case len(key) < len(tuple):
+		return avltree.LessThanOtherKey
+	case len(key) > len(tuple):
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+}
+
+func (key TupleKey) Copy() avltree.Key {
+	newKey := make(TupleKey, len(key))
+	for i, component := range key {
+		newKey[i] = component.Copy()
+	}
+	return newKey
+}
+
+func (key DescKey) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	return other.(DescKey).Key.CompareTo(key.Key)
+}
+
+func (key DescKey) Copy() avltree.Key {
+	return DescKey{key.Key.Copy()}
+}
+
+func (key DescKey) String() string {
+	return fmt.Sprint(key.Key)
+}
+
+func (inf infinity) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	return compareComponent(inf, other)
+}
+
+func (inf infinity) Copy() avltree.Key {
+	return inf
+}
+
+func (inf infinity) String() string {
+	if inf == Min {
+		return "Min"
+	}
+	return "Max"
+}
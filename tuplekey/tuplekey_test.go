@@ -0,0 +1,209 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package tuplekey
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+	. "github.com/neetsdkasu/avltree/stringkey"
+)
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+// (int8の昇順, int8の降順, stringの昇順)の組
+type tuple struct {
+	A int8
+	B int8
+	C string
+}
+
+func (t tuple) key() TupleKey {
+	return New(IntKey(t.A), Desc(IntKey(t.B)), StringKey(t.C))
+}
+
+func (t tuple) less(other tuple) bool {
+	if t.A != other.A {
+		return t.A < other.A
+	}
+	if t.B != other.B {
+		return t.B > other.B
+	}
+	return t.C < other.C
+}
+
+func TestTupleKey(t *testing.T) {
+	f := func(t1, t2 tuple, n1, n2 uint8) bool {
+		key1, key2 := t1.key(), t2.key()
+		switch key1.CompareTo(key2) {
+		case avltree.LessThanOtherKey:
+			if !t1.less(t2) {
+				return false
+			}
+		case avltree.EqualToOtherKey:
+			if t1 != t2 {
+				return false
+			}
+		case avltree.GreaterThanOtherKey:
+			if !t2.less(t1) {
+				return false
+			}
+		default:
+			return false
+		}
+		// 要素の少ない組は要素の多い組の先頭部分と比較される
+		p1, p2 := key1[:n1%4], key2[:n2%4]
+		cmp := p1.CompareTo(p2)
+		for i := 0; i < len(p1) && i < len(p2); i++ {
+			if c := p1[i].CompareTo(p2[i]); !c.EqualTo() {
+				return cmp == c
+			}
+		}
+		switch {
+		case len(p1) < len(p2):
+			return cmp.LessThan()
+		case len(p1) > len(p2):
+			return cmp.GreaterThan()
+		default:
+			return cmp.EqualTo()
+		}
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	f := func(list []tuple, a, b int8, n uint8) bool {
+		tree := simpletree.New(false)
+		for _, tp := range list {
+			tp.A %= 4
+			tp.B %= 4
+			avltree.Insert(tree, true, tp.key(), 0)
+		}
+		prefix := New(IntKey(a%4), Desc(IntKey(b%4)))[:n%3]
+		lower, upper := Prefix(prefix...)
+		var got, want []TupleKey
+		avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+			got = append(got, node.Key().(TupleKey))
+			return
+		})
+		avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+			key := node.Key().(TupleKey)
+			if key[:len(prefix)].CompareTo(prefix).EqualTo() {
+				want = append(want, key)
+			}
+			return
+		})
+		if avltree.CountRange(tree, lower, upper) != len(want) {
+			return false
+		}
+		return reflect.DeepEqual(got, want) && sort.SliceIsSorted(got, func(i, j int) bool {
+			return got[i].CompareTo(got[j]).LessThan()
+		})
+	}
+
+	if err := quick.Check(f, cfg1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// prefixと等しいキーも範囲に含まれる
+func TestPrefixEqualKey(t *testing.T) {
+	tree := simpletree.New(false)
+	for _, key := range []TupleKey{
+		New(IntKey(1)),
+		New(IntKey(1), Desc(IntKey(2))),
+		New(IntKey(1), Desc(IntKey(2)), IntKey(3)),
+		New(IntKey(1), Desc(IntKey(3))),
+		New(IntKey(0), Desc(IntKey(2))),
+		New(IntKey(2)),
+	} {
+		avltree.Insert(tree, false, key, 0)
+	}
+	for _, tc := range []struct {
+		prefix TupleKey
+		want   int
+	}{
+		{New(), 6},
+		{New(IntKey(1)), 4},
+		{New(IntKey(1), Desc(IntKey(2))), 2},
+		{New(IntKey(1), Desc(IntKey(2)), IntKey(3)), 1},
+	} {
+		lower, upper := Prefix(tc.prefix...)
+		if got := avltree.CountRange(tree, lower, upper); got != tc.want {
+			t.Fatal("wrong count", tc.prefix, got)
+		}
+	}
+
+	// 下限は渡したprefixを複製したもの
+	prefix := []avltree.Key{IntKey(1)}
+	lower, _ := Prefix(prefix...)
+	prefix[0] = IntKey(5)
+	if !lower.CompareTo(New(IntKey(1))).EqualTo() {
+		t.Fatal("prefix is not copied")
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	key := New(IntKey(1), Desc(IntKey(2)))
+	if !New(IntKey(1), Min).CompareTo(key).LessThan() || !key.CompareTo(New(IntKey(1), Min)).GreaterThan() {
+		t.Fatal("Min is not less than Desc component")
+	}
+	if !New(IntKey(1), Max).CompareTo(key).GreaterThan() || !key.CompareTo(New(IntKey(1), Max)).LessThan() {
+		t.Fatal("Max is not greater than Desc component")
+	}
+	if !New(Min).CompareTo(New(Max)).LessThan() || !New(Max).CompareTo(New(Max)).EqualTo() {
+		t.Fatal("wrong comparison between Min and Max")
+	}
+	if !New(Max).CompareTo(New(IntKey(1), Max)).GreaterThan() {
+		t.Fatal("Max is not greater than longer tuple")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	key := New(IntKey(1), Desc(IntKey(2)), Max)
+	copied := key.Copy().(TupleKey)
+	if !reflect.DeepEqual(key, copied) {
+		t.Fatal("not same")
+	}
+	key[0] = IntKey(100)
+	if !copied.CompareTo(New(IntKey(1), Desc(IntKey(2)), Max)).EqualTo() {
+		t.Fatal("copy shares components")
+	}
+}
+
+func Example() {
+	tree := simpletree.New(false)
+	insert := func(tenant string, timestamp, id int) {
+		key := New(StringKey(tenant), Desc(IntKey(timestamp)), IntKey(id))
+		avltree.Insert(tree, false, key, "")
+	}
+	insert("acme", 100, 1)
+	insert("acme", 300, 2)
+	insert("acme", 200, 3)
+	insert("acme", 300, 1)
+	insert("bolt", 500, 9)
+	insert("apex", 400, 5)
+	lower, upper := Prefix(StringKey("acme"))
+	avltree.RangeIterate(tree, false, lower, upper, func(node avltree.Node) (breakIteration bool) {
+		fmt.Println("Tenant!", node.Key())
+		return
+	})
+	lower, upper = Prefix(StringKey("acme"), Desc(IntKey(300)))
+	fmt.Println("Count!", avltree.CountRange(tree, lower, upper))
+	// Output:
+	// Tenant! [acme 300 1]
+	// Tenant! [acme 300 2]
+	// Tenant! [acme 200 3]
+	// Tenant! [acme 100 1]
+	// Count! 2
+}
@@ -0,0 +1,29 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeのKeyの実装例
+// uint64型をそのままキーにしてある
+// uint64型の自然順序をそのままキーの順序としてある
+package uint64key
+
+import "github.com/neetsdkasu/avltree"
+
+type Uint64Key uint64
+
+func (key Uint64Key) CompareTo(other avltree.Key) avltree.KeyOrdering {
+	v1 := uint64(key)
+	v2 := uint64(other.(Uint64Key))
+	switch {
+	case v1 < v2:
+		return avltree.LessThanOtherKey
+	case v1 > v2:
+		return avltree.GreaterThanOtherKey
+	default:
+		return avltree.EqualToOtherKey
+	}
+	// 差分を取る方式は符号なしなので使えない
+}
+
+func (key Uint64Key) Copy() avltree.Key {
+	return key
+}
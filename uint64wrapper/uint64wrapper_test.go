@@ -0,0 +1,62 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package uint64wrapper
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/int64arraytree"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+// ラッパーの実装はintwrapperと同じテンプレートから生成しているため
+// ここではuint64型の値の範囲と順序(math.MaxInt64より大きいキーが後ろに並ぶこと)だけを確認する
+
+var cfg1000 = &quick.Config{MaxCount: 1000}
+
+var treeMakers = map[string]func(allowDuplicateKeys bool) avltree.Tree{
+	"simpletree": simpletree.New,
+	"int64arraytree": func(allowDuplicateKeys bool) avltree.Tree {
+		return int64arraytree.NewWithTypes(int64arraytree.KeyTypeUint64, int64arraytree.ValueTypeUint64, allowDuplicateKeys)
+	},
+}
+
+func TestUint64Range(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(values map[uint64]uint64) bool {
+			values[0] = math.MaxUint64
+			values[math.MaxUint64] = 0
+			values[math.MaxInt64+1] = math.MaxInt64
+			tree := New(newTree(false))
+			keys := []uint64{}
+			for key, value := range values {
+				tree.Insert(key, value)
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i] < keys[j]
+			})
+			nodes := tree.Range(0, math.MaxUint64)
+			if len(nodes) != len(keys) || tree.Min().Key() != 0 || tree.Max().Key() != math.MaxUint64 {
+				return false
+			}
+			for i, node := range nodes {
+				if node.Key() != keys[i] || node.Value() != values[keys[i]] {
+					return false
+				}
+			}
+			return tree.CountRange(math.MaxInt64+1, math.MaxUint64) == len(keys)-sort.Search(len(keys), func(i int) bool {
+				return keys[i] > math.MaxInt64
+			})
+		}
+
+		if err := quick.Check(f, cfg1000); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// ログファイルのレコードの形式(数値はビッグエンディアン)
+//
+//	+ ペイロードの長さ (4バイト)
+//	+ ペイロードのCRC32(IEEE) (4バイト)
+//	+ ペイロード
+//	    + LSN (8バイト)
+//	    + 操作の種類 (1バイト)
+//	    + キーの長さ (uvarint)
+//	    + キー
+//	    + 値 (opPutのときのみ、ペイロードの残り全て)
+//
+// １回の変更操作で書いたレコードの後ろにはopCommitのレコード(キーは空)を書く
+// opCommitのレコードが無い操作のレコードは復元の際に適用しない
+//
+// チェックポイントファイルの形式
+//
+//	+ checkpointMagic (8バイト)
+//	+ チェックポイントに含まれる最後のレコードのLSN (8バイト)
+//	+ キーと値の組の数 (8バイト)
+//	+ キーと値の組の並び(キーの昇順)
+//	    + キーの長さ (uvarint)
+//	    + キー
+//	    + 値の長さ (uvarint)
+//	    + 値
+//	+ ここまでの全体のCRC32(IEEE) (4バイト)
+
+const (
+	opPut    byte = 1
+	opDelete byte = 2
+	opCommit byte = 3
+)
+
+const recordHeaderSize = 8
+
+const checkpointMagic = "AVLTCKPT"
+
+// ログファイルまたはチェックポイントファイルが壊れている
+// (ログファイルの末尾の書きかけのレコードは壊れているとはみなさず無視する)
+var ErrCorrupted = errors.New("wal: corrupted")
+
+type record struct {
+	lsn   uint64
+	op    byte
+	key   []byte
+	value []byte
+}
+
+func corrupted(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrCorrupted}, args...)...)
+}
+
+func appendRecord(buf []byte, rec *record) []byte {
+	start := len(buf)
+	buf = append(buf, make([]byte, recordHeaderSize)...)
+	buf = appendUint64(buf, rec.lsn)
+	buf = append(buf, rec.op)
+	buf = appendBytes(buf, rec.key)
+	if rec.op == opPut {
+		buf = append(buf, rec.value...)
+	}
+	payload := buf[start+recordHeaderSize:]
+	binary.BigEndian.PutUint32(buf[start:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[start+4:], crc32.ChecksumIEEE(payload))
+	return buf
+}
+
+// dataの先頭のレコードを読み取る
+// 戻り値のsizeは読み取ったレコードのバイト数
+// レコードが途中で終わっている場合(書きかけの場合)はtornがtrueになる
+// 長さがdataの終わりを越えていても、後ろに正しいレコードがある場合は書きかけではなく長さが壊れているとみなす
+// (書きかけのレコードはログファイルの末尾にしか無いため)
+func readRecord(data []byte) (rec *record, size int, torn bool, err error) {
+	if len(data) < recordHeaderSize {
+		return nil, 0, true, nil
+	}
+	length := int(binary.BigEndian.Uint32(data))
+	checksum := binary.BigEndian.Uint32(data[4:])
+	size = recordHeaderSize + length
+	if length < 0 || size > len(data) {
+		if containsRecord(data[recordHeaderSize:]) {
+			return nil, 0, false, corrupted("record length %d exceeds the log", length)
+		}
+		return nil, 0, true, nil
+	}
+	payload := data[recordHeaderSize:size]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		// 最後のレコードのチェックサムの不一致は書きかけとみなす
+		if size == len(data) {
+			return nil, 0, true, nil
+		}
+		return nil, 0, false, corrupted("checksum mismatch")
+	}
+	rec, err = decodePayload(payload)
+	return rec, size, false, err
+}
+
+// dataのいずれかの位置から始まる正しい(チェックサムが一致し解釈できる)レコードがあるか
+func containsRecord(data []byte) bool {
+	for i := 0; i+recordHeaderSize <= len(data); i++ {
+		size := recordHeaderSize + int(binary.BigEndian.Uint32(data[i:]))
+		if size > len(data)-i {
+			continue
+		}
+		payload := data[i+recordHeaderSize : i+size]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[i+4:]) {
+			continue
+		}
+		if _, err := decodePayload(payload); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func decodePayload(payload []byte) (*record, error) {
+	if len(payload) < 9 {
+		return nil, corrupted("too short record")
+	}
+	rec := &record{
+		lsn: binary.BigEndian.Uint64(payload),
+		op:  payload[8],
+	}
+	rest := payload[9:]
+	key, rest, ok := readBytes(rest)
+	if !ok {
+		return nil, corrupted("invalid key length")
+	}
+	rec.key = key
+	switch rec.op {
+	case opPut:
+		rec.value = rest
+	case opDelete, opCommit:
+		if len(rest) != 0 {
+			return nil, corrupted("unexpected value in record")
+		}
+	default:
+		return nil, corrupted("unknown operation %d", rec.op)
+	}
+	return rec, nil
+}
+
+func appendUint64(buf []byte, x uint64) []byte {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], x)
+	return append(buf, tmp[:]...)
+}
+
+func appendBytes(buf []byte, data []byte) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], uint64(len(data)))
+	buf = append(buf, tmp[:n]...)
+	return append(buf, data...)
+}
+
+func readBytes(data []byte) (value, rest []byte, ok bool) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data)-n) {
+		return nil, nil, false
+	}
+	end := n + int(length)
+	return data[n:end], data[end:], true
+}
+
+// チェックポイントファイルの内容を読み取り、キーと値の組を順にcallBackに渡す
+func readCheckpoint(data []byte, callBack func(key, value []byte) error) (lsn uint64, err error) {
+	headerSize := len(checkpointMagic) + 16
+	if len(data) < headerSize+4 || string(data[:len(checkpointMagic)]) != checkpointMagic {
+		return 0, corrupted("invalid checkpoint header")
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return 0, corrupted("checkpoint checksum mismatch")
+	}
+	lsn = binary.BigEndian.Uint64(data[len(checkpointMagic):])
+	count := binary.BigEndian.Uint64(data[len(checkpointMagic)+8:])
+	rest := body[headerSize:]
+	for i := uint64(0); i < count; i++ {
+		key, r, ok := readBytes(rest)
+		if !ok {
+			return 0, corrupted("invalid checkpoint entry")
+		}
+		value, r, ok := readBytes(r)
+		if !ok {
+			return 0, corrupted("invalid checkpoint entry")
+		}
+		rest = r
+		if err := callBack(key, value); err != nil {
+			return 0, err
+		}
+	}
+	if len(rest) != 0 {
+		return 0, corrupted("trailing data in checkpoint")
+	}
+	return lsn, nil
+}
@@ -0,0 +1,550 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+// github.com/neetsdkasu/avltreeの木の変更を先行書き込みログ(write-ahead log)に記録し、クラッシュ後に木を復元する実装例
+//
+// Openで得た木(LoggedTree)はavltree.MutationObserverを実装していて、
+// avltree.InsertやDelete,Clearなどによる変更をディレクトリ内のログファイルにレコードとして追記する
+// 各レコードは長さとCRC32のチェックサムとLSN(ログの通し番号)を持つ
+// 変更操作が完了する(SetRootが呼ばれる)たびにコミットのレコードを加えてファイルに書き出し、SyncOnCommitがtrueの場合はfsyncも行う
+//
+// Checkpointで木の内容全体をチェックポイントファイルに書き出し、ログファイルを空にする
+// チェックポイントファイルは一時ファイルに書いてからリネームで置き換えるため、書き出しの途中でクラッシュしても前のチェックポイントが残る
+// CheckpointIntervalに正の値を指定した場合はその数のレコードを書くたびに自動でチェックポイントを作る
+//
+// Openではチェックポイントファイルを読み込み、そのあとログファイルのレコードを順に適用して木を復元する
+// 変更操作の単位で適用するため、DeleteRangeなど複数のレコードを書く操作が途中まで適用されることはない
+// ログファイルの末尾の書きかけのレコードやコミットされていない操作のレコードは無視して切り捨てる
+// 末尾以外のレコードが壊れている場合はErrCorruptedをラップしたエラーを返し、ログファイルは切り詰めない
+//
+// 同一キーを許可する木は扱えない(値の置き換えや削除の対象のノードを特定できないため)
+// 常に最新の木(変更操作の戻り値の木)に対して変更操作を行う必要がある(古いバージョンの木を変更しようとするとpanicになる)
+// avltree.Releaseは変更として記録しない(最新の木を解放するとLogを閉じ、古いバージョンを解放しても何もしない)
+// 並行に使用することはできない
+//
+// コード例
+//
+//		import (
+//			"fmt"
+//			"os"
+//			"github.com/neetsdkasu/avltree"
+//			"github.com/neetsdkasu/avltree/codec"
+//			. "github.com/neetsdkasu/avltree/intkey"
+//			"github.com/neetsdkasu/avltree/simpletree"
+//			"github.com/neetsdkasu/avltree/wal"
+//		)
+//		func Example_wal() {
+//			dir, _ := os.MkdirTemp("", "wal")
+//			defer os.RemoveAll(dir)
+//			c := codec.New(codec.IntKey, codec.StringValue)
+//			log, tree, _ := wal.Open(dir, simpletree.New(false), c)
+//			tree, _ = avltree.Insert(tree, false, IntKey(1), "one")
+//			tree, _ = avltree.Insert(tree, false, IntKey(2), "two")
+//			log.Checkpoint()
+//			tree, _ = avltree.Insert(tree, false, IntKey(3), "three")
+//			tree, _ = avltree.Delete(tree, IntKey(1))
+//			fmt.Println("LSN!", log.LSN())
+//			log.Close()
+//			log, tree, _ = wal.Open(dir, simpletree.New(false), c)
+//			defer log.Close()
+//			avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+//				fmt.Println("Recovered!", node.Key(), node.Value())
+//				return
+//			})
+//			// Output:
+//			// LSN! 8
+//			// Recovered! 2 two
+//			// Recovered! 3 three
+//		}
+//
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+)
+
+const (
+	logFileName        = "wal.log"
+	checkpointFileName = "checkpoint"
+	temporaryFileName  = "checkpoint.tmp"
+)
+
+type Log struct {
+	dir    string
+	codec  codec.Codec
+	file   *os.File
+	writer *bufio.Writer
+	buf    []byte
+
+	// 最後に書いたレコードのLSN
+	lsn uint64
+
+	// 最後のチェックポイント以降に書いたレコードの数
+	recordCount int
+
+	// 最後のコミット以降にレコードを書いたかどうか
+	uncommitted bool
+
+	// 最新の木
+	latest *LoggedTree
+
+	// ファイルへの書き出しで起きた最初のエラー
+	err    error
+	closed bool
+
+	// 正の値の場合はこの数のレコードを書くたびに自動でチェックポイントを作る
+	CheckpointInterval int
+
+	// trueの場合は変更操作が完了するたびにログファイルをfsyncする
+	SyncOnCommit bool
+}
+
+// 変更をLogに記録する木
+type LoggedTree struct {
+	Inner avltree.RealTree
+	Log   *Log
+}
+
+// ラップしている木がavltree.KeyComparatorを実装している場合のLoggedTree
+// 比較方法もラップしている木に委譲する
+type comparatorTree struct {
+	*LoggedTree
+}
+
+// ディレクトリdirのチェックポイントとログから木を復元し、以降の変更を記録するLogと復元した木を返す
+// ディレクトリが存在しない場合は作成する
+// treeには同一キーを許可しない空の木を渡す必要がある(復元した内容はtreeに挿入される)
+func Open(dir string, tree avltree.Tree, c codec.Codec) (log *Log, recovered avltree.Tree, err error) {
+	inner := tree.(avltree.RealTree)
+	if inner.AllowDuplicateKeys() {
+		panic("wal: duplicate keys are not supported")
+	}
+	if inner.Root() != nil {
+		panic("wal: tree is not empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	log = &Log{
+		dir:   dir,
+		codec: c,
+	}
+	checkpointLSN, err := log.loadCheckpoint(&inner)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.lsn = checkpointLSN
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := log.replay(file, checkpointLSN, &inner); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	log.file = file
+	log.writer = bufio.NewWriter(file)
+	log.latest = &LoggedTree{Inner: inner, Log: log}
+	return log, log.current(), nil
+}
+
+func (log *Log) loadCheckpoint(tree *avltree.RealTree) (lsn uint64, err error) {
+	data, err := os.ReadFile(filepath.Join(log.dir, checkpointFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return readCheckpoint(data, func(keyData, valueData []byte) error {
+		key, value, err := log.decode(keyData, valueData)
+		if err != nil {
+			return err
+		}
+		*tree = insert(*tree, key, value)
+		return nil
+	})
+}
+
+// ログファイルのレコードのうちcheckpointLSNより後のものを木に適用する
+// 末尾の書きかけのレコードはファイルから切り捨てる
+func (log *Log) replay(file *os.File, checkpointLSN uint64, tree *avltree.RealTree) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	// committedは最後のコミットのレコードの直後の位置
+	offset, committed := 0, 0
+	lsn := log.lsn
+	var pending []*record
+	for offset < len(data) {
+		rec, size, torn, err := readRecord(data[offset:])
+		if err != nil {
+			return err
+		}
+		if torn {
+			break
+		}
+		offset += size
+		if rec.lsn <= checkpointLSN {
+			committed = offset
+			continue
+		}
+		if rec.lsn != lsn+1 {
+			return corrupted("unexpected LSN %d (expected %d)", rec.lsn, lsn+1)
+		}
+		lsn = rec.lsn
+		if rec.op != opCommit {
+			pending = append(pending, rec)
+			continue
+		}
+		for _, rec := range pending {
+			if err := log.apply(rec, tree); err != nil {
+				return err
+			}
+		}
+		log.recordCount += len(pending) + 1
+		log.lsn = lsn
+		pending = pending[:0]
+		committed = offset
+	}
+	offset = committed
+	if offset < len(data) {
+		if err := file.Truncate(int64(offset)); err != nil {
+			return err
+		}
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	_, err = file.Seek(int64(offset), io.SeekStart)
+	return err
+}
+
+func (log *Log) apply(rec *record, tree *avltree.RealTree) error {
+	switch rec.op {
+	case opPut:
+		key, value, err := log.decode(rec.key, rec.value)
+		if err != nil {
+			return err
+		}
+		*tree = insert(*tree, key, value)
+	case opDelete:
+		key, err := log.codec.DecodeKey(rec.key)
+		if err != nil {
+			return err
+		}
+		modified, _ := avltree.Delete(*tree, key)
+		*tree = modified.(avltree.RealTree)
+	}
+	return nil
+}
+
+func insert(tree avltree.RealTree, key avltree.Key, value interface{}) avltree.RealTree {
+	modified, _ := avltree.Insert(tree, true, key, value)
+	return modified.(avltree.RealTree)
+}
+
+func (log *Log) decode(keyData, valueData []byte) (key avltree.Key, value interface{}, err error) {
+	if key, err = log.codec.DecodeKey(keyData); err != nil {
+		return nil, nil, err
+	}
+	if value, err = log.codec.DecodeValue(valueData); err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+// 最新の木を返す
+func (log *Log) Tree() avltree.Tree {
+	return log.current()
+}
+
+// 最新の木を返す
+// ラップしている木がavltree.KeyComparatorを実装している場合のみ比較方法を委譲する型で包む
+func (log *Log) current() avltree.RealTree {
+	return log.latest.wrap()
+}
+
+// ラップしている木がavltree.KeyComparatorを実装している場合のみ比較方法を委譲する型で包む
+func (tree *LoggedTree) wrap() avltree.RealTree {
+	if _, ok := tree.Inner.(avltree.KeyComparator); ok {
+		return comparatorTree{tree}
+	}
+	return tree
+}
+
+// 最後に書いたレコードのLSNを返す
+func (log *Log) LSN() uint64 {
+	return log.lsn
+}
+
+// ファイルへの書き出しで起きた最初のエラーを返す
+// エラーが起きたあとはレコードはファイルに書き出されない
+func (log *Log) Err() error {
+	return log.err
+}
+
+func (log *Log) setError(err error) {
+	if log.err == nil {
+		log.err = err
+	}
+}
+
+// レコードをバッファに追加する
+// キーや値を変換できない場合はpanicになる
+func (log *Log) append(op byte, key avltree.Key, value interface{}) {
+	if log.closed {
+		panic("wal: closed log")
+	}
+	rec := &record{
+		lsn: log.lsn + 1,
+		op:  op,
+	}
+	var err error
+	if op != opCommit {
+		if rec.key, err = log.codec.EncodeKey(key); err != nil {
+			panic(err)
+		}
+	}
+	if op == opPut {
+		if rec.value, err = log.codec.EncodeValue(value); err != nil {
+			panic(err)
+		}
+	}
+	log.uncommitted = op != opCommit
+	log.lsn = rec.lsn
+	log.recordCount++
+	log.buf = appendRecord(log.buf[:0], rec)
+	if log.err == nil {
+		_, err = log.writer.Write(log.buf)
+		log.setError(err)
+	}
+}
+
+// 変更操作の完了時に呼ばれる
+func (log *Log) commit() {
+	if log.uncommitted {
+		log.append(opCommit, nil, nil)
+	}
+	if log.err != nil {
+		return
+	}
+	if log.CheckpointInterval > 0 && log.recordCount >= log.CheckpointInterval {
+		log.setError(log.Checkpoint())
+		return
+	}
+	if err := log.writer.Flush(); err != nil {
+		log.setError(err)
+		return
+	}
+	if log.SyncOnCommit {
+		log.setError(log.file.Sync())
+	}
+}
+
+// バッファのレコードをログファイルに書き出してfsyncする
+func (log *Log) Sync() error {
+	if log.err != nil {
+		return log.err
+	}
+	if err := log.writer.Flush(); err != nil {
+		log.setError(err)
+		return err
+	}
+	if err := log.file.Sync(); err != nil {
+		log.setError(err)
+		return err
+	}
+	return nil
+}
+
+// 最新の木の内容全体をチェックポイントファイルに書き出し、ログファイルを空にする
+func (log *Log) Checkpoint() error {
+	if log.closed {
+		panic("wal: closed log")
+	}
+	if err := log.Sync(); err != nil {
+		return err
+	}
+	if err := log.writeCheckpoint(); err != nil {
+		log.setError(err)
+		return err
+	}
+	// チェックポイントの置き換えのあとログファイルを空にする前にクラッシュしても
+	// チェックポイントのLSN以下のレコードはOpenで無視される
+	if err := log.file.Truncate(0); err != nil {
+		log.setError(err)
+		return err
+	}
+	if _, err := log.file.Seek(0, io.SeekStart); err != nil {
+		log.setError(err)
+		return err
+	}
+	log.writer.Reset(log.file)
+	log.recordCount = 0
+	return nil
+}
+
+func (log *Log) writeCheckpoint() error {
+	tree := log.latest.Inner
+	data := []byte(checkpointMagic)
+	data = appendUint64(data, log.lsn)
+	data = appendUint64(data, uint64(avltree.Count(tree)))
+	var err error
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		var keyData, valueData []byte
+		if keyData, err = log.codec.EncodeKey(node.Key()); err != nil {
+			return true
+		}
+		if valueData, err = log.codec.EncodeValue(node.Value()); err != nil {
+			return true
+		}
+		data = appendBytes(data, keyData)
+		data = appendBytes(data, valueData)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(data))
+	data = append(data, checksum[:]...)
+
+	temporary := filepath.Join(log.dir, temporaryFileName)
+	file, err := os.OpenFile(temporary, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary, filepath.Join(log.dir, checkpointFileName)); err != nil {
+		return err
+	}
+	return syncDir(log.dir)
+}
+
+// リネームを永続化するためにディレクトリをfsyncする
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// バッファのレコードを書き出してログファイルを閉じる
+// 閉じたあとに木を変更しようとするとpanicになる
+func (log *Log) Close() error {
+	if log.closed {
+		return fmt.Errorf("wal: already closed")
+	}
+	err := log.Sync()
+	if closeErr := log.file.Close(); err == nil {
+		err = closeErr
+	}
+	log.closed = true
+	return err
+}
+
+func (tree *LoggedTree) checkLatest() {
+	if tree.Log.latest != tree {
+		panic("wal: modifying an old version")
+	}
+}
+
+func (tree *LoggedTree) Root() avltree.Node {
+	return tree.Inner.Root()
+}
+
+func (tree *LoggedTree) NewNode(leftChild, rightChild avltree.Node, height int, key avltree.Key, value interface{}) avltree.RealNode {
+	return tree.Inner.NewNode(leftChild, rightChild, height, key, value)
+}
+
+// 変更操作の完了時に呼ばれるのでレコードをファイルに書き出す
+// 変更操作でノードが削除される場合は必ず削除のレコードが書かれるため、
+// レコードを書いていないのにルートがnilになるのはavltree.Releaseによる解放の場合で、そのときは木もLogも変更しない
+func (tree *LoggedTree) SetRoot(newRoot avltree.RealNode) avltree.RealTree {
+	if newRoot == nil && !tree.Log.uncommitted {
+		return tree.wrap()
+	}
+	tree.checkLatest()
+	newInner := tree.Inner.SetRoot(newRoot)
+	if newInner != tree.Inner {
+		tree.Log.latest = &LoggedTree{Inner: newInner, Log: tree.Log}
+	}
+	tree.Log.commit()
+	return tree.Log.current()
+}
+
+func (tree *LoggedTree) AllowDuplicateKeys() bool {
+	return false
+}
+
+func (tree *LoggedTree) NodeCount() int {
+	return avltree.Count(tree.Inner)
+}
+
+// ラップしている木がavltree.NodeReleaserを実装している場合のみ処理を委譲する
+func (tree *LoggedTree) ReleaseNode(node avltree.RealNode) {
+	if releaser, ok := tree.Inner.(avltree.NodeReleaser); ok {
+		releaser.ReleaseNode(node)
+	}
+}
+
+func (tree comparatorTree) CompareKeys(key1, key2 avltree.Key) avltree.KeyOrdering {
+	return tree.Inner.(avltree.KeyComparator).CompareKeys(key1, key2)
+}
+
+// avltree.Releaseの最後に呼ばれる
+// 最新の木を解放した場合はLogを閉じる(木の内容はログに記録済みなので次のOpenで復元できる)
+// 閉じる際のエラーはErrで得られる
+// ラップしている木がavltree.TreeReleaserを実装している場合は処理を委譲する
+func (tree *LoggedTree) ReleaseTree() {
+	if releaser, ok := tree.Inner.(avltree.TreeReleaser); ok {
+		releaser.ReleaseTree()
+	}
+	if log := tree.Log; log.latest == tree && !log.closed {
+		log.setError(log.Close())
+	}
+}
+
+// ラップしている木がavltree.TreeCleanerを実装している場合のみ処理を委譲する
+func (tree *LoggedTree) CleanUpTree() {
+	if cleaner, ok := tree.Inner.(avltree.TreeCleaner); ok {
+		cleaner.CleanUpTree()
+	}
+}
+
+func (tree *LoggedTree) NodeInserted(key avltree.Key, value interface{}) {
+	tree.checkLatest()
+	tree.Log.append(opPut, key, value)
+}
+
+func (tree *LoggedTree) NodeReplaced(key avltree.Key, oldValue, newValue interface{}) {
+	tree.checkLatest()
+	tree.Log.append(opPut, key, newValue)
+}
+
+func (tree *LoggedTree) NodeDeleted(key avltree.Key, value interface{}) {
+	tree.checkLatest()
+	tree.Log.append(opDelete, key, nil)
+}
@@ -0,0 +1,375 @@
+// Author: Leonardone @ NEETSDKASU
+// License: MIT
+
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/neetsdkasu/avltree"
+	"github.com/neetsdkasu/avltree/codec"
+	"github.com/neetsdkasu/avltree/comparatortree"
+	"github.com/neetsdkasu/avltree/immutabletree"
+	"github.com/neetsdkasu/avltree/intarraytree"
+	. "github.com/neetsdkasu/avltree/intkey"
+	"github.com/neetsdkasu/avltree/simpletree"
+)
+
+var cfg100 = &quick.Config{MaxCount: 100}
+
+var testCodec = codec.New(codec.IntKey, codec.IntValue)
+
+type keyAndValue struct {
+	Key   int
+	Value int
+}
+
+var treeMakers = map[string]func() avltree.Tree{
+	"simpletree":    func() avltree.Tree { return simpletree.New(false) },
+	"immutabletree": func() avltree.Tree { return immutabletree.New(false) },
+	"intarraytree":  func() avltree.Tree { return intarraytree.New(false) },
+}
+
+func toMap(tree avltree.Tree) map[int]int {
+	m := map[int]int{}
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		m[int(node.Key().(IntKey))] = node.Value().(int)
+		return
+	})
+	return m
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func open(t *testing.T, dir string, newTree func() avltree.Tree) (*Log, avltree.Tree) {
+	log, tree, err := Open(dir, newTree(), testCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log, tree
+}
+
+// listの内容で様々な変更操作を行う
+func mutate(tree avltree.Tree, i int, kv keyAndValue) avltree.Tree {
+	key := IntKey(int8(kv.Key))
+	switch i % 6 {
+	case 0, 1:
+		tree, _ = avltree.Insert(tree, i%2 == 1, key, kv.Value)
+	case 2:
+		tree, _ = avltree.Delete(tree, key)
+	case 3:
+		tree, _ = avltree.Replace(tree, key, kv.Value)
+	case 4:
+		tree, _ = avltree.DeleteRange(tree, false, key, key+IntKey(kv.Value%8))
+	case 5:
+		tree, _ = avltree.UpdateRange(tree, true, nil, key, func(key avltree.Key, oldValue interface{}) (newValue interface{}, keepOldValue bool) {
+			return oldValue.(int) / 2, oldValue.(int)%2 == 0
+		})
+	}
+	return tree
+}
+
+func TestRecover(t *testing.T) {
+	for name, newTree := range treeMakers {
+		f := func(list []keyAndValue, interval uint8, checkpointAt uint8) bool {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			log, tree := open(t, dir, newTree)
+			log.CheckpointInterval = int(interval % 16)
+			for i, kv := range list {
+				tree = mutate(tree, i, kv)
+				if i == int(checkpointAt) {
+					if err := log.Checkpoint(); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if log.Tree() != tree {
+					t.Fatal(name, "not latest")
+				}
+			}
+			if i := len(list) / 2; i < len(list) {
+				tree = avltree.Clear(tree)
+				for _, kv := range list[i:] {
+					tree = mutate(tree, 0, kv)
+				}
+			}
+			want := toMap(tree)
+			lsn := log.LSN()
+			if err := log.Close(); err != nil {
+				t.Fatal(err)
+			}
+			log, tree = open(t, dir, newTree)
+			defer log.Close()
+			if log.LSN() != lsn {
+				t.Fatal(name, "wrong LSN", log.LSN(), lsn)
+			}
+			return reflect.DeepEqual(toMap(tree), want)
+		}
+		if err := quick.Check(f, cfg100); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+}
+
+// ログファイルを任意の位置で切り詰めても、いずれかの変更操作の完了時点の内容に復元される
+func TestTornTail(t *testing.T) {
+	f := func(list []keyAndValue, cut uint16) bool {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		logFile := filepath.Join(dir, logFileName)
+		log, tree := open(t, dir, treeMakers["simpletree"])
+		states := []map[int]int{{}}
+		sizes := []int64{0}
+		for i, kv := range list {
+			tree = mutate(tree, i, kv)
+			info, err := os.Stat(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != sizes[len(sizes)-1] {
+				states = append(states, toMap(tree))
+				sizes = append(sizes, info.Size())
+			}
+		}
+		log.Close()
+		size := int64(cut) % (sizes[len(sizes)-1] + 1)
+		if err := os.Truncate(logFile, size); err != nil {
+			t.Fatal(err)
+		}
+		want := states[0]
+		for i, s := range sizes {
+			if s <= size {
+				want = states[i]
+			}
+		}
+		log, tree = open(t, dir, treeMakers["simpletree"])
+		if !reflect.DeepEqual(toMap(tree), want) {
+			t.Fatal("wrong state", size, sizes)
+		}
+		// 切り捨てたあとも続けて記録できる
+		tree, _ = avltree.Insert(tree, true, IntKey(1000), 1)
+		want = toMap(tree)
+		log.Close()
+		log, tree = open(t, dir, treeMakers["simpletree"])
+		defer log.Close()
+		return reflect.DeepEqual(toMap(tree), want)
+	}
+	if err := quick.Check(f, cfg100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCorrupted(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["simpletree"])
+	for k := 0; k < 10; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	log.Close()
+	logFile := filepath.Join(dir, logFileName)
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[recordHeaderSize+3] ^= 0xFF
+	if err := os.WriteFile(logFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Open(dir, simpletree.New(false), testCodec); !errors.Is(err, ErrCorrupted) {
+		t.Fatal("corruption is not detected", err)
+	}
+}
+
+// 途中のレコードの長さが壊れている場合は書きかけとみなさず、ログファイルも切り詰めない
+func TestCorruptedLength(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["simpletree"])
+	for k := 0; k < 10; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	log.Close()
+	logFile := filepath.Join(dir, logFileName)
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5番目のレコードの長さを壊す
+	offset := 0
+	for i := 0; i < 4; i++ {
+		offset += recordHeaderSize + int(binary.BigEndian.Uint32(data[offset:]))
+	}
+	for _, length := range []uint32{uint32(len(data)), 0xFFFFFFFF} {
+		corruptedData := append([]byte(nil), data...)
+		binary.BigEndian.PutUint32(corruptedData[offset:], length)
+		if err := os.WriteFile(logFile, corruptedData, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := Open(dir, simpletree.New(false), testCodec); !errors.Is(err, ErrCorrupted) {
+			t.Fatal("corruption is not detected", length, err)
+		}
+		if info, err := os.Stat(logFile); err != nil || info.Size() != int64(len(data)) {
+			t.Fatal("log file is truncated", err)
+		}
+	}
+}
+
+// チェックポイントの置き換えのあとログファイルを空にする前にクラッシュした場合
+func TestCrashAfterCheckpointRename(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["simpletree"])
+	for k := 0; k < 10; k++ {
+		tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+	}
+	log.Sync()
+	logFile := filepath.Join(dir, logFileName)
+	saved, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	tree, _ = avltree.Delete(tree, IntKey(3))
+	want := toMap(tree)
+	log.Close()
+	// チェックポイント以前のレコードが残っている状態を再現する
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logFile, append(saved, data...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	log, tree = open(t, dir, treeMakers["simpletree"])
+	defer log.Close()
+	if !reflect.DeepEqual(toMap(tree), want) {
+		t.Fatal("wrong state", toMap(tree))
+	}
+}
+
+func TestModifyOldVersion(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["immutabletree"])
+	defer log.Close()
+	old := tree
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 1)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	avltree.Insert(old, false, IntKey(2), 2)
+}
+
+// Releaseは削除として記録されず、解放したあとも次のOpenで内容が復元される
+func TestRelease(t *testing.T) {
+	for name, newTree := range treeMakers {
+		dir := tempDir(t)
+		log, tree := open(t, dir, newTree)
+		for k := 0; k < 10; k++ {
+			tree, _ = avltree.Insert(tree, false, IntKey(k), k)
+		}
+		want := toMap(tree)
+		lsn := log.LSN()
+		avltree.Release(&tree)
+		if log.LSN() != lsn || log.Err() != nil {
+			t.Fatal(name, "release is logged", log.LSN(), log.Err())
+		}
+		if log.Close() == nil {
+			t.Fatal(name, "log is not closed")
+		}
+		log, tree = open(t, dir, newTree)
+		if !reflect.DeepEqual(toMap(tree), want) {
+			t.Fatal(name, "wrong state", toMap(tree))
+		}
+		log.Close()
+		os.RemoveAll(dir)
+	}
+
+	// 古いバージョンを解放しても最新の木は変わらず記録も続けられる
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["immutabletree"])
+	tree, _ = avltree.Insert(tree, false, IntKey(1), 1)
+	old := tree
+	tree, _ = avltree.Insert(tree, false, IntKey(2), 2)
+	avltree.Release(&old)
+	tree, _ = avltree.Insert(tree, false, IntKey(3), 3)
+	want := toMap(tree)
+	if len(want) != 3 {
+		t.Fatal("wrong state", want)
+	}
+	log.Close()
+	log, tree = open(t, dir, treeMakers["immutabletree"])
+	defer log.Close()
+	if !reflect.DeepEqual(toMap(tree), want) {
+		t.Fatal("wrong state", toMap(tree))
+	}
+}
+
+func TestClosedLog(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	log, tree := open(t, dir, treeMakers["simpletree"])
+	log.Close()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	avltree.Insert(tree, false, IntKey(1), 1)
+}
+
+func TestKeyComparator(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	newTree := func() avltree.Tree {
+		return comparatortree.New(simpletree.New(false), func(a, b interface{}) int {
+			return int(b.(IntKey)) - int(a.(IntKey))
+		})
+	}
+	log, tree := open(t, dir, treeMakers["simpletree"])
+	if _, ok := tree.(avltree.KeyComparator); ok {
+		t.Fatal("implements KeyComparator without comparator")
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+	log, tree = open(t, dir, newTree)
+	if _, ok := tree.(avltree.KeyComparator); !ok {
+		t.Fatal("not implements KeyComparator")
+	}
+	for _, v := range []int{2, 3, 1} {
+		tree, _ = avltree.Insert(tree, false, IntKey(v), v)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	log, tree = open(t, dir, newTree)
+	defer log.Close()
+	var keys []avltree.Key
+	avltree.Iterate(tree, false, func(node avltree.Node) (breakIteration bool) {
+		keys = append(keys, node.Key())
+		return
+	})
+	if !reflect.DeepEqual(keys, []avltree.Key{IntKey(3), IntKey(2), IntKey(1)}) {
+		t.Fatal("wrong order", keys)
+	}
+}